@@ -0,0 +1,155 @@
+package gocassa
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BatchType is the type of batch the ops of a multi op are executed in
+type BatchType byte
+
+const (
+	// LoggedBatch is applied entirely or not at all, at a performance cost
+	LoggedBatch BatchType = iota
+	// UnloggedBatch doesn't guarantee its statements are all applied. It
+	// should only write to a single partition, where it saves round trips
+	UnloggedBatch
+	// CounterBatch is an unlogged batch of counter updates, which counter
+	// tables can only be written to in
+	CounterBatch
+)
+
+func (b BatchType) String() string {
+	switch b {
+	case LoggedBatch:
+		return "logged"
+	case UnloggedBatch:
+		return "unlogged"
+	case CounterBatch:
+		return "counter"
+	}
+	return fmt.Sprintf("BatchType(%d)", byte(b))
+}
+
+// batchWrite describes what a write op writes, so the batches it's run in can
+// be checked before they're executed
+type batchWrite struct {
+	table string
+	// partition identifies the partition written to, if singlePartition
+	partition       string
+	singlePartition bool
+	// counter is set if the op only updates counters
+	counter bool
+	// conditional is set if the op is a lightweight transaction
+	conditional bool
+}
+
+// batchedOp is implemented by the ops of tables which can describe what they
+// write
+type batchedOp interface {
+	// batchWrite returns false if the op doesn't write
+	batchWrite() (batchWrite, bool)
+}
+
+func newBatchWrite(keySpace, table string, keys Keys, m map[string]interface{}, rs []Relation, update bool) batchWrite {
+	w := batchWrite{
+		table:           table,
+		singlePartition: true,
+		counter:         update && isCounterUpdate(m),
+	}
+	parts := []string{keySpace, table}
+	for _, pk := range keys.PartitionKeys {
+		value, ok := partitionKeyValue(pk, m, rs)
+		if !ok {
+			w.singlePartition = false
+			return w
+		}
+		parts = append(parts, fmt.Sprintf("%v", convertToPrimitive(value)))
+	}
+	w.partition = strings.Join(parts, "\x00")
+	return w
+}
+
+// isCounterUpdate returns whether every column is incremented, as only
+// counter updates can be run in counter batches
+func isCounterUpdate(m map[string]interface{}) bool {
+	for _, value := range m {
+		if modifier, ok := value.(Modifier); !ok || modifier.op != ModifierCounterIncrement {
+			return false
+		}
+	}
+	return len(m) > 0
+}
+
+// checkBatch returns an error if the ops can't be run in a batch of the given
+// type. Unlogged batches should write to a single partition, and counter
+// batches can only contain counter updates. No batch can contain conditional
+// writes, as whether they were applied isn't reported for batches
+func checkBatch(batchType BatchType, ops []Op) error {
+	for _, op := range ops {
+		if w, ok := opBatchWrite(op); ok && w.conditional {
+			return fmt.Errorf("conditional writes to %s can't be run in a batch, run them on their own instead", w.table)
+		}
+	}
+
+	switch batchType {
+	case UnloggedBatch:
+		partition := ""
+		for i, op := range ops {
+			w, ok := opBatchWrite(op)
+			if !ok {
+				return fmt.Errorf("unlogged batch can only contain writes to gocassa tables")
+			}
+			if !w.singlePartition {
+				return fmt.Errorf("unlogged batch writes to %s without restricting its partition key to a single value", w.table)
+			}
+			if i == 0 {
+				partition = w.partition
+			} else if w.partition != partition {
+				return fmt.Errorf("unlogged batch spans multiple partitions, use a logged batch instead")
+			}
+		}
+	case CounterBatch:
+		for _, op := range ops {
+			if w, ok := opBatchWrite(op); !ok || !w.counter {
+				return fmt.Errorf("counter batch can only contain counter updates")
+			}
+		}
+	}
+	return nil
+}
+
+func opBatchWrite(op Op) (batchWrite, bool) {
+	if o, ok := op.(batchedOp); ok {
+		return o.batchWrite()
+	}
+	return batchWrite{}, false
+}
+
+func (o *singleOp) batchWrite() (batchWrite, bool) {
+	if !o.isWrite() {
+		return batchWrite{}, false
+	}
+	w := newBatchWrite(o.f.t.keySpace.name, o.f.t.Name(), o.f.t.info.keys, o.m, o.f.rs, o.opType == updateOpType)
+	w.conditional = o.isConditional()
+	return w, true
+}
+
+// partitionKeyValue returns the single value a partition key column is set to,
+// either by the fields written or by the relations of the op
+func partitionKeyValue(column string, m map[string]interface{}, rs []Relation) (interface{}, bool) {
+	for field, value := range m {
+		if strings.EqualFold(field, column) {
+			return value, true
+		}
+	}
+	for _, rel := range rs {
+		if !strings.EqualFold(rel.Field(), column) || len(rel.Terms()) != 1 {
+			continue
+		}
+		if rel.Comparator() == CmpEquality || rel.Comparator() == CmpIn {
+			return rel.Terms()[0], true
+		}
+	}
+	return nil, false
+}
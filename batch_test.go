@@ -0,0 +1,155 @@
+package gocassa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchedPost struct {
+	UserId string
+	Id     string
+	Title  string
+}
+
+func TestRunBatchWithContext(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	ks := NewConnection(qe).KeySpace("app")
+	tbl := ks.MapTable("users", "Id", interceptedUser{})
+	ct := ks.CounterTable("views", []string{"Site"}, []string{"Page"}, pageViews{})
+
+	op := ct.Increment(map[string]interface{}{"Site": "a", "Page": "/"}, "Views", 1).
+		Add(ct.Decrement(map[string]interface{}{"Site": "b", "Page": "/"}, "Users", 1))
+	require.NoError(t, op.RunBatchWithContext(context.Background(), CounterBatch))
+	assert.Equal(t, CounterBatch, qe.batchType)
+
+	op = tbl.Set(interceptedUser{Id: "1"}).Add(tbl.Set(interceptedUser{Id: "2"}))
+	require.NoError(t, op.RunLoggedBatchWithContext(context.Background()))
+	assert.Equal(t, LoggedBatch, qe.batchType)
+}
+
+func TestCounterBatchOnlyCounterUpdates(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}, batchType: LoggedBatch}
+	ks := NewConnection(qe).KeySpace("app")
+	tbl := ks.MapTable("users", "Id", interceptedUser{})
+	ct := ks.CounterTable("views", []string{"Site"}, []string{"Page"}, pageViews{})
+
+	increment := ct.Increment(map[string]interface{}{"Site": "a", "Page": "/"}, "Views", 1)
+	for _, op := range []Op{
+		tbl.Set(interceptedUser{Id: "1"}).Add(tbl.Set(interceptedUser{Id: "2"})),
+		increment.Add(tbl.Update("1", map[string]interface{}{"Name": "jane"})),
+		increment.Add(ct.Table().Where(Eq("Site", "a"), Eq("Page", "/")).Delete()),
+	} {
+		assert.EqualError(t, op.PreflightBatch(CounterBatch), "counter batch can only contain counter updates")
+		assert.EqualError(t, op.RunBatchWithContext(context.Background(), CounterBatch),
+			"counter batch can only contain counter updates")
+	}
+	assert.Equal(t, LoggedBatch, qe.batchType)
+	assert.NoError(t, increment.PreflightBatch(CounterBatch))
+}
+
+func TestUnloggedBatchSinglePartition(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	ks := NewConnection(qe).KeySpace("app")
+	tbl := ks.Table("posts", batchedPost{}, Keys{PartitionKeys: []string{"UserId"}, ClusteringColumns: []string{"Id"}})
+
+	op := tbl.Set(batchedPost{UserId: "1", Id: "a"}).
+		Add(tbl.Where(Eq("UserId", "1"), Eq("Id", "b")).Delete()).
+		Add(tbl.Where(In("UserId", "1")).Delete())
+	require.NoError(t, op.RunBatchWithContext(context.Background(), UnloggedBatch))
+	assert.Equal(t, UnloggedBatch, qe.batchType)
+}
+
+func TestUnloggedBatchMultiplePartitions(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}, batchType: LoggedBatch}
+	ks := NewConnection(qe).KeySpace("app")
+	tbl := ks.Table("posts", batchedPost{}, Keys{PartitionKeys: []string{"UserId"}, ClusteringColumns: []string{"Id"}})
+	other := ks.Table("comments", batchedPost{}, Keys{PartitionKeys: []string{"UserId"}, ClusteringColumns: []string{"Id"}})
+
+	op := tbl.Set(batchedPost{UserId: "1", Id: "a"}).Add(tbl.Set(batchedPost{UserId: "2", Id: "a"}))
+	assert.EqualError(t, op.RunBatchWithContext(context.Background(), UnloggedBatch),
+		"unlogged batch spans multiple partitions, use a logged batch instead")
+	// The check is reported by preflighting the batch too
+	assert.EqualError(t, op.PreflightBatch(UnloggedBatch),
+		"unlogged batch spans multiple partitions, use a logged batch instead")
+	assert.NoError(t, op.PreflightBatch(LoggedBatch))
+
+	op = tbl.Set(batchedPost{UserId: "1", Id: "a"}).Add(other.Set(batchedPost{UserId: "1", Id: "a"}))
+	assert.Error(t, op.RunBatchWithContext(context.Background(), UnloggedBatch))
+
+	op = tbl.Set(batchedPost{UserId: "1", Id: "a"}).Add(tbl.Where(In("UserId", "1", "2")).Delete())
+	assert.EqualError(t, op.RunBatchWithContext(context.Background(), UnloggedBatch),
+		"unlogged batch writes to posts__UserId__Id without restricting its partition key to a single value")
+	assert.Nil(t, qe.stmt)
+
+	require.NoError(t, op.RunBatchWithContext(context.Background(), LoggedBatch))
+}
+
+func TestMockBatchChecks(t *testing.T) {
+	ks := NewMockKeySpace()
+	tbl := ks.Table("posts", batchedPost{}, Keys{PartitionKeys: []string{"UserId"}, ClusteringColumns: []string{"Id"}})
+	ct := ks.CounterTable("views", []string{"Site"}, []string{"Page"}, pageViews{})
+	ctx := context.Background()
+
+	op := tbl.Set(batchedPost{UserId: "1", Id: "a"}).Add(tbl.Where(Eq("UserId", "1"), Eq("Id", "b")).Delete())
+	require.NoError(t, op.RunBatchWithContext(ctx, UnloggedBatch))
+
+	// The mock rejects the batches Cassandra would, without running any op
+	op = tbl.Set(batchedPost{UserId: "1", Id: "c"}).Add(tbl.Set(batchedPost{UserId: "2", Id: "c"}))
+	assert.EqualError(t, op.RunBatchWithContext(ctx, UnloggedBatch),
+		"unlogged batch spans multiple partitions, use a logged batch instead")
+	assert.EqualError(t, op.PreflightBatch(UnloggedBatch),
+		"unlogged batch spans multiple partitions, use a logged batch instead")
+	assert.EqualError(t, op.RunBatchWithContext(ctx, CounterBatch), "counter batch can only contain counter updates")
+	var posts []batchedPost
+	require.NoError(t, tbl.Where(Eq("UserId", "1")).Read(&posts).Run())
+	assert.Equal(t, []batchedPost{{UserId: "1", Id: "a"}}, posts)
+
+	op = ct.Increment(map[string]interface{}{"Site": "a", "Page": "/"}, "Views", 1).
+		Add(ct.Increment(map[string]interface{}{"Site": "b", "Page": "/"}, "Views", 1))
+	require.NoError(t, op.RunBatchWithContext(ctx, CounterBatch))
+}
+
+func TestConditionalWritesNotBatched(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	errConditional := "conditional writes to users_map_Id can't be run in a batch, run them on their own instead"
+	for name, ks := range map[string]KeySpace{
+		"real": NewConnection(qe).KeySpace("app"),
+		"mock": NewMockKeySpace(),
+	} {
+		tbl := ks.MapTable("users", "Id", interceptedUser{})
+		require.NoError(t, tbl.Set(interceptedUser{Id: "1", Name: "Jane"}).Run(), name)
+
+		// Whether the condition held isn't reported for batches, so they're
+		// rejected rather than failing silently
+		qe.stmt = nil
+		for _, op := range []Op{
+			tbl.Set(interceptedUser{Id: "2"}).Add(tbl.SetIfNotExists(interceptedUser{Id: "1"})),
+			tbl.Set(interceptedUser{Id: "2"}).Add(tbl.UpdateIf("1", []Relation{Eq("Name", "Joe")},
+				map[string]interface{}{"Name": "Jill"})),
+			tbl.Set(interceptedUser{Id: "2"}).Add(tbl.DeleteIfExists("3")),
+		} {
+			assert.EqualError(t, op.PreflightBatch(LoggedBatch), errConditional, name)
+			assert.EqualError(t, op.RunLoggedBatchWithContext(context.Background()), errConditional, name)
+			assert.EqualError(t, op.RunAtomically(), errConditional, name)
+			assert.EqualError(t, op.RunBatchWithContext(context.Background(), UnloggedBatch), errConditional, name)
+		}
+		assert.Nil(t, qe.stmt, name)
+
+		var user interceptedUser
+		require.NoError(t, tbl.Read("1", &user).Run(), name)
+		if name == "mock" {
+			assert.Equal(t, "Jane", user.Name)
+			assert.IsType(t, RowNotFoundError{}, tbl.Read("2", &user).Run())
+		}
+	}
+}
+
+func TestBatchTypeString(t *testing.T) {
+	assert.Equal(t, "logged", LoggedBatch.String())
+	assert.Equal(t, "unlogged", UnloggedBatch.String())
+	assert.Equal(t, "counter", CounterBatch.String())
+	assert.Equal(t, "BatchType(7)", BatchType(7).String())
+}
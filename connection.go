@@ -2,37 +2,130 @@ package gocassa
 
 import (
 	"fmt"
+	"strconv"
 )
 
 type connection struct {
-	q QueryExecutor
+	q          QueryExecutor
+	tracer     Tracer
+	logger     Logger
+	logOptions LogOptions
 }
 
 // Connect to a cluster.
 // If you are happy with default the options use this, if you need anything fancier, use `NewConnection`
-func Connect(nodeIps []string, username, password string) (Connection, error) {
+func Connect(nodeIps []string, username, password string, opts ...ConnectionOption) (Connection, error) {
 	qe, err := newGoCQLBackend(nodeIps, username, password)
 	if err != nil {
 		return nil, err
 	}
-	return &connection{
-		q: qe,
-	}, nil
+	return NewConnection(qe, opts...), nil
 }
 
 // NewConnection creates a Connection with a custom query executor.
 // Use `Connect` if you just want to talk to Cassandra with the default options.
 // See `GoCQLSessionToQueryExecutor` if you want to use a gocql session with your own options as a `QueryExecutor`
-func NewConnection(q QueryExecutor) Connection {
-	return &connection{
+func NewConnection(q QueryExecutor, opts ...ConnectionOption) Connection {
+	c := &connection{
 		q: q,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ReplicationStrategy is the strategy Cassandra uses to place the replicas of a keyspace
+type ReplicationStrategy string
+
+const (
+	// SimpleStrategy places replicas without regard for datacenters, so it is only suitable for
+	// clusters with a single datacenter
+	SimpleStrategy ReplicationStrategy = "SimpleStrategy"
+	// NetworkTopologyStrategy places a number of replicas in each datacenter
+	NetworkTopologyStrategy ReplicationStrategy = "NetworkTopologyStrategy"
+)
+
+// KeySpaceOptions configures the replication of a keyspace
+type KeySpaceOptions struct {
+	// Strategy defaults to NetworkTopologyStrategy if DatacenterRF is set, and SimpleStrategy otherwise
+	Strategy ReplicationStrategy
+	// ReplicationFactor is the number of replicas with SimpleStrategy, it defaults to 1
+	ReplicationFactor int
+	// DatacenterRF is the number of replicas in each datacenter with NetworkTopologyStrategy
+	DatacenterRF map[string]int
+	// DurableWrites specifies whether writes go through the commit log. If nil, it is considered not set
+	DurableWrites *bool
+	// IfNotExists only creates the keyspace if it doesn't exist already. It's ignored when altering
+	IfNotExists bool
+}
+
+// cql returns the WITH clause of a CREATE or ALTER KEYSPACE statement
+func (o KeySpaceOptions) cql() (string, error) {
+	strategy := o.Strategy
+	if strategy == "" {
+		strategy = SimpleStrategy
+		if len(o.DatacenterRF) > 0 {
+			strategy = NetworkTopologyStrategy
+		}
+	}
+
+	replication := map[string]string{"class": string(strategy)}
+	switch strategy {
+	case SimpleStrategy:
+		if len(o.DatacenterRF) > 0 {
+			return "", fmt.Errorf("replication factors of datacenters can't be set with %s", strategy)
+		}
+		rf := o.ReplicationFactor
+		if rf <= 0 {
+			rf = 1
+		}
+		replication["replication_factor"] = strconv.Itoa(rf)
+	case NetworkTopologyStrategy:
+		if len(o.DatacenterRF) == 0 {
+			return "", fmt.Errorf("%s needs the replication factor of at least one datacenter", strategy)
+		}
+		for dc, rf := range o.DatacenterRF {
+			replication[dc] = strconv.Itoa(rf)
+		}
+	default:
+		return "", fmt.Errorf("unknown replication strategy %s", strategy)
+	}
+
+	with := "replication = " + cqlMap(replication)
+	if o.DurableWrites != nil {
+		with += fmt.Sprintf(" AND durable_writes = %t", *o.DurableWrites)
+	}
+	return with, nil
 }
 
-// CreateKeySpace creates a keyspace with the given name. Only used to create test keyspaces.
+// CreateKeySpace creates a keyspace with the given name, replicated once with SimpleStrategy.
+// Only used to create test keyspaces, use CreateKeySpaceWithOptions otherwise.
 func (c *connection) CreateKeySpace(name string) error {
-	query := fmt.Sprintf("CREATE KEYSPACE %s WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1 };", name)
-	stmt := cqlStatement{query: query}
+	return c.CreateKeySpaceWithOptions(name, KeySpaceOptions{})
+}
+
+// CreateKeySpaceWithOptions creates a keyspace with the given name and replication.
+func (c *connection) CreateKeySpaceWithOptions(name string, opts KeySpaceOptions) error {
+	with, err := opts.cql()
+	if err != nil {
+		return err
+	}
+	create := "CREATE KEYSPACE"
+	if opts.IfNotExists {
+		create = "CREATE KEYSPACE IF NOT EXISTS"
+	}
+	stmt := cqlStatement{query: fmt.Sprintf("%s %s WITH %s", create, name, with)}
+	return c.q.Execute(stmt)
+}
+
+// AlterKeySpace changes the replication of the keyspace having the given name.
+func (c *connection) AlterKeySpace(name string, opts KeySpaceOptions) error {
+	with, err := opts.cql()
+	if err != nil {
+		return err
+	}
+	stmt := cqlStatement{query: fmt.Sprintf("ALTER KEYSPACE %s WITH %s", name, with)}
 	return c.q.Execute(stmt)
 }
 
@@ -46,8 +139,11 @@ func (c *connection) DropKeySpace(name string) error {
 // KeySpace returns the keyspace having the given name.
 func (c *connection) KeySpace(name string) KeySpace {
 	k := &k{
-		qe:   c.q,
-		name: name,
+		qe:         c.q,
+		name:       name,
+		tracer:     c.tracer,
+		logger:     c.logger,
+		logOptions: c.logOptions,
 	}
 	k.tableFactory = k
 	return k
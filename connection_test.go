@@ -0,0 +1,91 @@
+package gocassa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateKeySpaceWithOptions(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	conn := NewConnection(qe)
+
+	require.NoError(t, conn.CreateKeySpace("test"))
+	assert.Equal(t, "CREATE KEYSPACE test WITH replication = {'class': 'SimpleStrategy', 'replication_factor': '1'}", qe.stmt.Query())
+
+	durableWrites := false
+	require.NoError(t, conn.CreateKeySpaceWithOptions("events", KeySpaceOptions{
+		DatacenterRF:  map[string]int{"europe-west2": 3, "europe-west1": 2},
+		DurableWrites: &durableWrites,
+		IfNotExists:   true,
+	}))
+	assert.Equal(t, "CREATE KEYSPACE IF NOT EXISTS events WITH replication = {'class': 'NetworkTopologyStrategy', "+
+		"'europe-west1': '2', 'europe-west2': '3'} AND durable_writes = false", qe.stmt.Query())
+
+	require.NoError(t, conn.AlterKeySpace("test", KeySpaceOptions{Strategy: SimpleStrategy, ReplicationFactor: 3, IfNotExists: true}))
+	assert.Equal(t, "ALTER KEYSPACE test WITH replication = {'class': 'SimpleStrategy', 'replication_factor': '3'}", qe.stmt.Query())
+
+	// Datacenter names are escaped
+	require.NoError(t, conn.AlterKeySpace("test", KeySpaceOptions{DatacenterRF: map[string]int{"dc'1": 1}}))
+	assert.Equal(t, "ALTER KEYSPACE test WITH replication = {'class': 'NetworkTopologyStrategy', 'dc''1': '1'}", qe.stmt.Query())
+
+	qe.stmt = nil
+	assert.Error(t, conn.CreateKeySpaceWithOptions("events", KeySpaceOptions{Strategy: NetworkTopologyStrategy}))
+	assert.Error(t, conn.AlterKeySpace("events", KeySpaceOptions{Strategy: SimpleStrategy, DatacenterRF: map[string]int{"dc1": 1}}))
+	assert.Error(t, conn.AlterKeySpace("events", KeySpaceOptions{Strategy: "LocalStrategy"}))
+	assert.Nil(t, qe.stmt)
+}
+
+// keySpaceQE returns canned rows for queries of system_schema.keyspaces
+type keySpaceQE struct {
+	*OptionCheckingQE
+	keyspaces []map[string]interface{}
+}
+
+func (qe *keySpaceQE) Query(stmt Statement, scanner Scanner) error {
+	return qe.QueryWithOptions(Options{}, stmt, scanner)
+}
+
+func (qe *keySpaceQE) QueryWithOptions(opts Options, stmt Statement, scanner Scanner) error {
+	_, err := scanner.ScanIter(newMockIterator(qe.keyspaces, stmt.(SelectStatement).fields))
+	return err
+}
+
+func TestKeySpaceReplication(t *testing.T) {
+	qe := &keySpaceQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	ks := NewConnection(qe).KeySpace("events")
+
+	_, err := ks.Replication()
+	assert.EqualError(t, err, "keyspace events does not exist")
+
+	qe.keyspaces = []map[string]interface{}{{
+		"durable_writes": true,
+		"replication": map[string]string{
+			"class":        "org.apache.cassandra.locator.NetworkTopologyStrategy",
+			"europe-west1": "2",
+			"europe-west2": "3",
+		},
+	}}
+	opts, err := ks.Replication()
+	require.NoError(t, err)
+	assert.Equal(t, NetworkTopologyStrategy, opts.Strategy)
+	assert.Equal(t, map[string]int{"europe-west1": 2, "europe-west2": 3}, opts.DatacenterRF)
+	assert.Equal(t, true, *opts.DurableWrites)
+
+	qe.keyspaces = []map[string]interface{}{{
+		"durable_writes": false,
+		"replication": map[string]string{
+			"class":              "org.apache.cassandra.locator.SimpleStrategy",
+			"replication_factor": "1",
+		},
+	}}
+	opts, err = ks.Replication()
+	require.NoError(t, err)
+	durableWrites := false
+	assert.Equal(t, KeySpaceOptions{Strategy: SimpleStrategy, ReplicationFactor: 1, DurableWrites: &durableWrites}, opts)
+
+	opts, err = NewMockKeySpace().Replication()
+	require.NoError(t, err)
+	assert.Equal(t, SimpleStrategy, opts.Strategy)
+}
@@ -0,0 +1,101 @@
+package gocassa
+
+import (
+	"fmt"
+)
+
+type counterT struct {
+	t              Table
+	partitionKeys  []string
+	clusteringKeys []string
+	counterFields  map[string]struct{}
+}
+
+func (c *counterT) Table() Table                        { return c.t }
+func (c *counterT) Create() error                       { return c.Table().Create() }
+func (c *counterT) CreateIfNotExist() error             { return c.Table().CreateIfNotExist() }
+func (c *counterT) Name() string                        { return c.Table().Name() }
+func (c *counterT) Recreate() error                     { return c.Table().Recreate() }
+func (c *counterT) CreateStatement() (Statement, error) { return c.Table().CreateStatement() }
+func (c *counterT) CreateIfNotExistStatement() (Statement, error) {
+	return c.Table().CreateIfNotExistStatement()
+}
+
+func (c *counterT) Increment(keys map[string]interface{}, field string, delta int) Op {
+	if _, ok := c.counterFields[field]; !ok {
+		return errOp{err: fmt.Errorf("%s is not a counter field of %s", field, c.Name())}
+	}
+
+	relations, err := c.relations(keys, true)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return c.Table().
+		Where(relations...).
+		Update(map[string]interface{}{field: CounterIncrement(delta)})
+}
+
+func (c *counterT) Decrement(keys map[string]interface{}, field string, delta int) Op {
+	return c.Increment(keys, field, -delta)
+}
+
+func (c *counterT) Get(keys map[string]interface{}, pointer interface{}) Op {
+	relations, err := c.relations(keys, true)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return c.Table().
+		Where(relations...).
+		ReadOne(pointer)
+}
+
+func (c *counterT) List(partitionKeys map[string]interface{}, pointerToASlice interface{}) Op {
+	relations, err := c.relations(partitionKeys, false)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return c.Table().
+		Where(relations...).
+		Read(pointerToASlice)
+}
+
+func (c *counterT) Reset(keys map[string]interface{}) Op {
+	relations, err := c.relations(keys, true)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return c.Table().
+		Where(relations...).
+		Delete()
+}
+
+func (c *counterT) WithOptions(o Options) CounterTable {
+	return &counterT{
+		t:              c.Table().WithOptions(o),
+		partitionKeys:  c.partitionKeys,
+		clusteringKeys: c.clusteringKeys,
+		counterFields:  c.counterFields,
+	}
+}
+
+// relations returns equality relations for the given keys, which must hold
+// every partition key and, if includeClustering is set, every clustering key
+func (c *counterT) relations(keys map[string]interface{}, includeClustering bool) ([]Relation, error) {
+	fields := c.partitionKeys
+	if includeClustering {
+		fields = append(append([]string{}, c.partitionKeys...), c.clusteringKeys...)
+	}
+	if len(keys) != len(fields) {
+		return nil, fmt.Errorf("expected values for keys %v but got %v", fields, keys)
+	}
+
+	relations := make([]Relation, 0, len(fields))
+	for _, field := range fields {
+		value, ok := keys[field]
+		if !ok {
+			return nil, fmt.Errorf("missing value for key %s", field)
+		}
+		relations = append(relations, Eq(field, value))
+	}
+	return relations, nil
+}
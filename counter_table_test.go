@@ -0,0 +1,64 @@
+package gocassa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pageViews struct {
+	Site  string
+	Page  string
+	Views Counter
+	Users Counter
+}
+
+func TestCounterTable(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	conn := &connection{q: qe}
+	ks := conn.KeySpace("stats")
+	ct := ks.CounterTable("views", []string{"Site"}, []string{"Page"}, pageViews{})
+
+	stmt, err := ct.CreateStatement()
+	assert.NoError(t, err)
+	assert.Contains(t, stmt.Query(), "views counter")
+	assert.Contains(t, stmt.Query(), "PRIMARY KEY ((site), page)")
+
+	err = ct.Increment(map[string]interface{}{"Site": "monzo.com", "Page": "/"}, "Views", 2).Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE stats.views_counter SET Views = Views + ? WHERE site = ? AND page = ?", qe.stmt.Query())
+	assert.Equal(t, []interface{}{2, "monzo.com", "/"}, qe.stmt.Values())
+
+	err = ct.Decrement(map[string]interface{}{"Site": "monzo.com", "Page": "/"}, "Views", 2).Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE stats.views_counter SET Views = Views - ? WHERE site = ? AND page = ?", qe.stmt.Query())
+	assert.Equal(t, []interface{}{2, "monzo.com", "/"}, qe.stmt.Values())
+
+	// Only counter fields can be incremented, and every key must be given
+	assert.Error(t, ct.Increment(map[string]interface{}{"Site": "monzo.com", "Page": "/"}, "Page", 1).Run())
+	assert.Error(t, ct.Increment(map[string]interface{}{"Site": "monzo.com"}, "Views", 1).Run())
+	assert.Error(t, ct.List(map[string]interface{}{"Site": "monzo.com", "Page": "/"}, &[]pageViews{}).Run())
+}
+
+func TestCounterTableValidation(t *testing.T) {
+	conn := &connection{q: &OptionCheckingQE{opts: &Options{}}}
+	ks := conn.KeySpace("stats")
+
+	type mixed struct {
+		Site  string
+		Views Counter
+		Name  string
+	}
+	assert.Panics(t, func() {
+		ks.CounterTable("views", []string{"Site"}, nil, mixed{})
+	})
+	assert.Panics(t, func() {
+		ks.CounterTable("views", []string{"Missing"}, nil, pageViews{})
+	})
+
+	// Tables mixing counters and regular columns can't be created
+	_, err := ks.Table("views", mixed{}, Keys{PartitionKeys: []string{"Site"}}).CreateStatement()
+	assert.Error(t, err)
+	_, err = ks.Table("views", pageViews{}, Keys{PartitionKeys: []string{"Site", "Page"}}).CreateStatement()
+	assert.NoError(t, err)
+}
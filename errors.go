@@ -2,6 +2,7 @@ package gocassa
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -21,18 +22,54 @@ func (r RowNotFoundError) Error() string {
 	return fmt.Sprintf("%v:%v: No rows returned", f, r.line)
 }
 
+// NotAppliedError is returned by conditional writes (lightweight transactions)
+// when the condition did not hold and the write was not applied. Current holds
+// the values currently stored for the row, keyed by lowercase column name. It
+// is empty if the row does not exist.
+type NotAppliedError struct {
+	Current map[string]interface{}
+}
+
+func (e NotAppliedError) Error() string {
+	return "conditional write was not applied"
+}
+
+// MultiError holds the errors of the ops run by RunConcurrently with
+// Options.CollectErrors set
+type MultiError []error
+
+func (e MultiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d ops failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
 // errOp is an Op which represents a known error, which will always return during preflighting (preventing any execution
 // in a multiOp scenario)
 type errOp struct{ err error }
 
-func (o errOp) Run() error                                        { return o.err }
-func (o errOp) RunWithContext(_ context.Context) error            { return o.err }
-func (o errOp) RunAtomically() error                              { return o.err }
-func (o errOp) RunAtomicallyWithContext(_ context.Context) error  { return o.err }
-func (o errOp) RunLoggedBatchWithContext(_ context.Context) error { return o.err }
-func (o errOp) Add(ops ...Op) Op                                  { return multiOp{o}.Add(ops...) }
-func (o errOp) Options() Options                                  { return Options{} }
-func (o errOp) WithOptions(_ Options) Op                          { return o }
-func (o errOp) Preflight() error                                  { return o.err }
-func (o errOp) GenerateStatement() Statement                      { return noOpStatement{} }
-func (o errOp) QueryExecutor() QueryExecutor                      { return nil }
+func (o errOp) Run() error                                               { return o.err }
+func (o errOp) RunWithContext(_ context.Context) error                   { return o.err }
+func (o errOp) RunAtomically() error                                     { return o.err }
+func (o errOp) RunAtomicallyWithContext(_ context.Context) error         { return o.err }
+func (o errOp) RunLoggedBatchWithContext(_ context.Context) error        { return o.err }
+func (o errOp) RunConcurrently(_ context.Context, _ int) error           { return o.err }
+func (o errOp) RunBatchWithContext(_ context.Context, _ BatchType) error { return o.err }
+func (o errOp) Add(ops ...Op) Op                                         { return multiOp{o}.Add(ops...) }
+func (o errOp) Options() Options                                         { return Options{} }
+func (o errOp) WithOptions(_ Options) Op                                 { return o }
+func (o errOp) Preflight() error                                         { return o.err }
+func (o errOp) PreflightBatch(_ BatchType) error                         { return o.err }
+func (o errOp) GenerateStatement() Statement                             { return noOpStatement{} }
+func (o errOp) QueryExecutor() QueryExecutor                             { return nil }
+
+// errConditionalTimestamp is returned by the preflight check of conditional
+// writes given a custom timestamp, which Cassandra rejects as lightweight
+// transactions are timestamped by Paxos
+var errConditionalTimestamp = errors.New("can't set a custom timestamp on a conditional write")
+
+func viewWriteError(name string) error {
+	return fmt.Errorf("can't write to %s as it is a materialized view", name)
+}
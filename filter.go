@@ -1,5 +1,11 @@
 package gocassa
 
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
 type filter struct {
 	t  t
 	rs []Relation
@@ -14,6 +20,9 @@ func (f filter) Relations() []Relation {
 }
 
 func (f filter) Update(m map[string]interface{}) Op {
+	if err := validateUpdate(m); err != nil {
+		return errOp{err: err}
+	}
 	return newWriteOp(f.t.keySpace.qe, f, updateOpType, m)
 }
 
@@ -21,6 +30,89 @@ func (f filter) Delete() Op {
 	return newWriteOp(f.t.keySpace.qe, f, deleteOpType, nil)
 }
 
+func (f filter) DeleteColumns(fields ...string) Op {
+	if err := validateDeletions(f.t.info.keys, fields, nil); err != nil {
+		return errOp{err: err}
+	}
+	op := newWriteOp(f.t.keySpace.qe, f, deleteOpType, nil)
+	op.deleteColumns = fields
+	return op
+}
+
+func (f filter) DeleteElements(elements map[string]Modifier) Op {
+	if err := validateDeletions(f.t.info.keys, nil, elements); err != nil {
+		return errOp{err: err}
+	}
+	op := newWriteOp(f.t.keySpace.qe, f, deleteOpType, nil)
+	op.deleteElements = elements
+	return op
+}
+
+// validateDeletions checks the columns and collection elements to be deleted,
+// as deleting none would delete the whole row instead
+func validateDeletions(keys Keys, columns []string, elements map[string]Modifier) error {
+	if len(columns) == 0 && len(elements) == 0 {
+		return fmt.Errorf("no columns to delete, use Delete to delete the whole row")
+	}
+
+	keyColumns := append(append([]string{}, keys.PartitionKeys...), keys.ClusteringColumns...)
+	isKey := func(column string) bool {
+		for _, key := range keyColumns {
+			if strings.EqualFold(key, column) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, column := range columns {
+		if isKey(column) {
+			return fmt.Errorf("can't delete primary key column %s, use Delete to delete the whole row", column)
+		}
+	}
+	for column, modifier := range elements {
+		if isKey(column) {
+			return fmt.Errorf("can't delete elements of primary key column %s", column)
+		}
+		if !modifier.deletesElement() {
+			return fmt.Errorf("modifier of column %s doesn't delete an element, use Update instead", column)
+		}
+	}
+	return nil
+}
+
+// validateUpdate checks an update doesn't use modifiers which delete
+// collection elements, as they can't be expressed in an UPDATE statement
+func validateUpdate(m map[string]interface{}) error {
+	for column, value := range m {
+		if modifier, ok := value.(Modifier); ok && modifier.deletesElement() {
+			return fmt.Errorf("modifier of column %s deletes an element, use DeleteElements instead", column)
+		}
+	}
+	return nil
+}
+
+func (f filter) UpdateIf(conditions []Relation, m map[string]interface{}) Op {
+	if err := validateUpdate(m); err != nil {
+		return errOp{err: err}
+	}
+	op := newWriteOp(f.t.keySpace.qe, f, updateOpType, m)
+	op.conditions = conditions
+	return op
+}
+
+func (f filter) DeleteIf(conditions ...Relation) Op {
+	op := newWriteOp(f.t.keySpace.qe, f, deleteOpType, nil)
+	op.conditions = conditions
+	return op
+}
+
+func (f filter) DeleteIfExists() Op {
+	op := newWriteOp(f.t.keySpace.qe, f, deleteOpType, nil)
+	op.ifExists = true
+	return op
+}
+
 //
 // Reads
 //
@@ -40,3 +132,43 @@ func (f filter) ReadOne(pointer interface{}) Op {
 		opType: singleReadOpType,
 		result: pointer}
 }
+
+func (f filter) ReadPage(pageSize int, pageState []byte, pointerToASlice interface{}, nextPageState *[]byte) Op {
+	if pageSize < 1 {
+		return errOp{err: fmt.Errorf("page size must be positive, got %d", pageSize)}
+	}
+	if nextPageState == nil {
+		return errOp{err: fmt.Errorf("nextPageState must not be nil, the state of the next page is stored in it")}
+	}
+	return &singleOp{
+		qe:            f.t.keySpace.qe,
+		f:             f,
+		opType:        readPageOpType,
+		result:        pointerToASlice,
+		pageSize:      pageSize,
+		pageState:     pageState,
+		nextPageState: nextPageState}
+}
+
+func (f filter) Iter() Iterator {
+	return f.iter(Options{})
+}
+
+func (f filter) IterWithContext(ctx context.Context) Iterator {
+	return f.iter(Options{Context: ctx})
+}
+
+func (f filter) iter(opts Options) Iterator {
+	// Unlike ops, iterators aren't run later with their own options, so
+	// table options (such as the context) apply here
+	opts = f.t.options.Merge(opts)
+	op := &singleOp{
+		qe:      f.t.keySpace.qe,
+		f:       f,
+		opType:  readOpType,
+		options: opts}
+	stmt := op.generateSelect(opts)
+	iter := newIterator(opts.Context, stmt, op.qe.QueryIterWithOptions(opts, stmt))
+	iter.done = op.logIter(stmt)
+	return iter
+}
@@ -32,6 +32,23 @@ func (o *flakeSeriesT) CreateIfNotExistStatement() (Statement, error) {
 }
 
 func (o *flakeSeriesT) Set(v interface{}) Op {
+	m, err := o.row(v)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return o.Table().Set(m)
+}
+
+func (o *flakeSeriesT) SetIfNotExists(v interface{}) Op {
+	m, err := o.row(v)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return o.Table().SetIfNotExists(m)
+}
+
+// row converts the row struct to a map holding its timestamp and bucket
+func (o *flakeSeriesT) row(v interface{}) (map[string]interface{}, error) {
 	m, ok := toMap(v)
 	if !ok {
 		panic("Can't set: not able to convert")
@@ -43,41 +60,58 @@ func (o *flakeSeriesT) Set(v interface{}) Op {
 
 	timestamp, err := flakeToTime(id)
 	if err != nil {
-		return errOp{err: err}
+		return nil, err
 	}
 
 	m[flakeTimestampFieldName] = timestamp
 	m[bucketFieldName] = bucket(timestamp, o.bucketSize)
-
-	return o.Table().Set(m)
+	return m, nil
 }
 
-func (o *flakeSeriesT) Update(id string, m map[string]interface{}) Op {
+// rowFilter filters the single row with the given id
+func (o *flakeSeriesT) rowFilter(id string) (Filter, error) {
 	timestamp, err := flakeToTime(id)
 	if err != nil {
-		return errOp{err: err}
+		return nil, err
 	}
 	bucket := bucket(timestamp, o.bucketSize)
 
 	return o.Table().
 		Where(Eq(bucketFieldName, bucket),
 			Eq(flakeTimestampFieldName, timestamp),
-			Eq(o.idField, id)).
-		Update(m)
+			Eq(o.idField, id)), nil
+}
+
+func (o *flakeSeriesT) Update(id string, m map[string]interface{}) Op {
+	f, err := o.rowFilter(id)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return f.Update(m)
+}
+
+func (o *flakeSeriesT) UpdateIf(id string, conditions []Relation, m map[string]interface{}) Op {
+	f, err := o.rowFilter(id)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return f.UpdateIf(conditions, m)
 }
 
 func (o *flakeSeriesT) Delete(id string) Op {
-	timestamp, err := flakeToTime(id)
+	f, err := o.rowFilter(id)
 	if err != nil {
 		return errOp{err: err}
 	}
-	bucket := bucket(timestamp, o.bucketSize)
+	return f.Delete()
+}
 
-	return o.Table().
-		Where(Eq(bucketFieldName, bucket),
-			Eq(flakeTimestampFieldName, timestamp),
-			Eq(o.idField, id)).
-		Delete()
+func (o *flakeSeriesT) DeleteIfExists(id string) Op {
+	f, err := o.rowFilter(id)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return f.DeleteIfExists()
 }
 
 func (o *flakeSeriesT) Read(id string, pointer interface{}) Op {
@@ -94,6 +128,14 @@ func (o *flakeSeriesT) Read(id string, pointer interface{}) Op {
 }
 
 func (o *flakeSeriesT) List(startTime, endTime time.Time, pointerToASlice interface{}) Op {
+	return o.listFilter(startTime, endTime).Read(pointerToASlice)
+}
+
+func (o *flakeSeriesT) ListIter(startTime, endTime time.Time) Iterator {
+	return o.listFilter(startTime, endTime).Iter()
+}
+
+func (o *flakeSeriesT) listFilter(startTime, endTime time.Time) Filter {
 	buckets := []interface{}{}
 	for bucket := o.Buckets(startTime); bucket.Bucket().Before(endTime); bucket = bucket.Next() {
 		buckets = append(buckets, bucket.Bucket())
@@ -101,8 +143,7 @@ func (o *flakeSeriesT) List(startTime, endTime time.Time, pointerToASlice interf
 	return o.Table().
 		Where(In(bucketFieldName, buckets...),
 			GTE(flakeTimestampFieldName, startTime),
-			LT(flakeTimestampFieldName, endTime)).
-		Read(pointerToASlice)
+			LT(flakeTimestampFieldName, endTime))
 }
 
 func (o *flakeSeriesT) Buckets(start time.Time) Buckets {
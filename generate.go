@@ -3,11 +3,19 @@ package gocassa
 import (
 	"errors"
 	"fmt"
+	"math/big"
+	"net"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+
+	r "github.com/monzo/gocassa/reflect"
 )
 
 // CREATE TABLE users (
@@ -28,36 +36,36 @@ import (
 // );
 //
 
-func createTableIfNotExist(keySpace, cf string, partitionKeys, colKeys []string, fields []string, values []interface{}, order []ClusteringOrderColumn, compoundKey, compact bool, compressor string) (Statement, error) {
-	return createTableStmt("CREATE TABLE IF NOT EXISTS", keySpace, cf, partitionKeys, colKeys, fields, values, order, compoundKey, compact, compressor)
+func createTableIfNotExist(keySpace, cf string, partitionKeys, colKeys, staticCols []string, fields []string, values []interface{}, fieldInfo map[string]r.Field, order []ClusteringOrderColumn, compoundKey, compact bool, compressor string, props TableProperties) (Statement, error) {
+	return createTableStmt("CREATE TABLE IF NOT EXISTS", keySpace, cf, partitionKeys, colKeys, staticCols, fields, values, fieldInfo, order, compoundKey, compact, compressor, props)
 }
 
-func createTable(keySpace, cf string, partitionKeys, colKeys []string, fields []string, values []interface{}, order []ClusteringOrderColumn, compoundKey, compact bool, compressor string) (Statement, error) {
-	return createTableStmt("CREATE TABLE", keySpace, cf, partitionKeys, colKeys, fields, values, order, compoundKey, compact, compressor)
+func createTable(keySpace, cf string, partitionKeys, colKeys, staticCols []string, fields []string, values []interface{}, fieldInfo map[string]r.Field, order []ClusteringOrderColumn, compoundKey, compact bool, compressor string, props TableProperties) (Statement, error) {
+	return createTableStmt("CREATE TABLE", keySpace, cf, partitionKeys, colKeys, staticCols, fields, values, fieldInfo, order, compoundKey, compact, compressor, props)
 }
 
-func createTableStmt(createStmt, keySpace, cf string, partitionKeys, colKeys []string, fields []string, values []interface{}, order []ClusteringOrderColumn, compoundKey, compact bool, compressor string) (Statement, error) {
+func createTableStmt(createStmt, keySpace, cf string, partitionKeys, colKeys, staticCols []string, fields []string, values []interface{}, fieldInfo map[string]r.Field, order []ClusteringOrderColumn, compoundKey, compact bool, compressor string, props TableProperties) (Statement, error) {
+	if err := validateCounterColumns(partitionKeys, colKeys, fields, values); err != nil {
+		return nil, err
+	}
+	if err := validateStaticColumns(partitionKeys, colKeys, staticCols); err != nil {
+		return nil, err
+	}
+
 	firstLine := fmt.Sprintf("%s %v.%v (", createStmt, keySpace, cf)
 	fieldLines := []string{}
 	for i, _ := range fields {
-		typeStr, err := stringTypeOf(values[i])
+		typeStr, err := columnTypeOf(values[i], fieldInfo[strings.ToLower(fields[i])])
 		if err != nil {
 			return nil, err
 		}
 		l := "    " + strings.ToLower(fields[i]) + " " + typeStr
+		if containsField(staticCols, fields[i]) {
+			l += " static"
+		}
 		fieldLines = append(fieldLines, l)
 	}
-	//key generation
-	str := ""
-	if len(colKeys) > 0 { //key (or composite key) + clustering columns
-		str = "    PRIMARY KEY ((%v), %v)"
-	} else if compoundKey { //compound key just one set of parenthesis
-		str = "    PRIMARY KEY (%v %v)"
-	} else { //otherwise is a composite key without colKeys
-		str = "    PRIMARY KEY ((%v %v))"
-	}
-
-	fieldLines = append(fieldLines, fmt.Sprintf(str, j(partitionKeys), j(colKeys)))
+	fieldLines = append(fieldLines, "    "+primaryKeyCQL(partitionKeys, colKeys, compoundKey))
 
 	lines := []string{
 		firstLine,
@@ -65,31 +73,29 @@ func createTableStmt(createStmt, keySpace, cf string, partitionKeys, colKeys []s
 		")",
 	}
 
+	withs := []string{}
 	if len(order) > 0 {
 		orderStrs := make([]string, len(order))
 		for i, o := range order {
 			orderStrs[i] = fmt.Sprintf("%v %v", strings.ToLower(o.Column), o.Direction.String())
 		}
-		orderLine := fmt.Sprintf("WITH CLUSTERING ORDER BY (%v)", strings.Join(orderStrs, ", "))
-		lines = append(lines, orderLine)
+		withs = append(withs, fmt.Sprintf("CLUSTERING ORDER BY (%v)", strings.Join(orderStrs, ", ")))
 	}
 
 	if compact {
-		compactLineStart := "WITH"
-		if len(order) > 0 {
-			compactLineStart = "AND"
-		}
-		compactLine := fmt.Sprintf("%v COMPACT STORAGE", compactLineStart)
-		lines = append(lines, compactLine)
+		withs = append(withs, "COMPACT STORAGE")
 	}
 
-	if len(compressor) > 0 {
-		compressionLineStart := "WITH"
-		if len(order) > 0 || compact {
-			compressionLineStart = "AND"
+	for _, prop := range tableProperties(props, compressor) {
+		withs = append(withs, fmt.Sprintf("%v = %v", prop.name, prop.value))
+	}
+
+	for i, with := range withs {
+		lineStart := "WITH"
+		if i > 0 {
+			lineStart = "AND"
 		}
-		compressionLine := fmt.Sprintf("%v compression = {'sstable_compression': '%v'}", compressionLineStart, compressor)
-		lines = append(lines, compressionLine)
+		lines = append(lines, fmt.Sprintf("%v %v", lineStart, with))
 	}
 
 	lines = append(lines, ";")
@@ -97,6 +103,300 @@ func createTableStmt(createStmt, keySpace, cf string, partitionKeys, colKeys []s
 	return cqlStatement{query: qry}, nil
 }
 
+// tableProperty is a property of a table, as CQL. matches returns whether a
+// table in Cassandra has the property already
+type tableProperty struct {
+	name    string
+	value   string
+	matches func(current schemaTableMarshal) bool
+}
+
+// tableProperties returns the properties which are set, in the order they
+// appear in CQL. The legacy compressor is only used if props has no Compression
+func tableProperties(props TableProperties, compressor string) []tableProperty {
+	result := []tableProperty{}
+	if c := props.Compaction; c != nil {
+		options := map[string]string{}
+		for k, v := range c.Options {
+			options[k] = v
+		}
+		options["class"] = c.Class
+		result = append(result, tableProperty{
+			name:  "compaction",
+			value: cqlMap(options),
+			matches: func(current schemaTableMarshal) bool {
+				return strings.HasSuffix(current.Compaction["class"], c.Class) && mapContains(current.Compaction, c.Options)
+			},
+		})
+	}
+	if ttl := int(props.DefaultTTL / time.Second); ttl > 0 {
+		result = append(result, tableProperty{
+			name:    "default_time_to_live",
+			value:   strconv.Itoa(ttl),
+			matches: func(current schemaTableMarshal) bool { return current.DefaultTTL == ttl },
+		})
+	}
+	if props.GCGrace != nil {
+		gcGrace := int(*props.GCGrace / time.Second)
+		result = append(result, tableProperty{
+			name:    "gc_grace_seconds",
+			value:   strconv.Itoa(gcGrace),
+			matches: func(current schemaTableMarshal) bool { return current.GCGraceSeconds == gcGrace },
+		})
+	}
+	if c := props.Caching; c != nil {
+		caching := map[string]string{}
+		if c.Keys != "" {
+			caching["keys"] = c.Keys
+		}
+		if c.RowsPerPartition != "" {
+			caching["rows_per_partition"] = c.RowsPerPartition
+		}
+		result = append(result, tableProperty{
+			name:    "caching",
+			value:   cqlMap(caching),
+			matches: func(current schemaTableMarshal) bool { return mapContains(current.Caching, caching) },
+		})
+	}
+	if chance := props.BloomFilterFPChance; chance > 0 {
+		result = append(result, tableProperty{
+			name:    "bloom_filter_fp_chance",
+			value:   strconv.FormatFloat(chance, 'f', -1, 64),
+			matches: func(current schemaTableMarshal) bool { return current.BloomFilterFPChance == chance },
+		})
+	}
+	if comment := props.Comment; comment != "" {
+		result = append(result, tableProperty{
+			name:    "comment",
+			value:   cqlString(comment),
+			matches: func(current schemaTableMarshal) bool { return current.Comment == comment },
+		})
+	}
+	if c := props.Compression; c != nil {
+		options := map[string]string{}
+		if c.ChunkLengthKB > 0 {
+			options["chunk_length_in_kb"] = strconv.Itoa(c.ChunkLengthKB)
+		}
+		value := cqlMap(map[string]string{"enabled": "false"})
+		if c.Class != "" {
+			compression := map[string]string{"class": c.Class}
+			for k, v := range options {
+				compression[k] = v
+			}
+			value = cqlMap(compression)
+		}
+		result = append(result, tableProperty{
+			name:  "compression",
+			value: value,
+			matches: func(current schemaTableMarshal) bool {
+				if c.Class == "" {
+					return current.Compression["enabled"] == "false"
+				}
+				return strings.HasSuffix(current.Compression["class"], c.Class) && mapContains(current.Compression, options)
+			},
+		})
+	} else if compressor != "" {
+		result = append(result, tableProperty{
+			name:  "compression",
+			value: fmt.Sprintf("{'sstable_compression': '%v'}", compressor),
+			matches: func(current schemaTableMarshal) bool {
+				return strings.HasSuffix(current.Compression["class"], compressor)
+			},
+		})
+	}
+	return result
+}
+
+// cqlMap returns the CQL literal of a map, with its class first if it has one
+func cqlMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if k != "class" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if _, ok := m["class"]; ok {
+		keys = append([]string{"class"}, keys...)
+	}
+
+	entries := make([]string, len(keys))
+	for i, k := range keys {
+		entries[i] = fmt.Sprintf("%s: %s", cqlString(k), cqlString(m[k]))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+func cqlString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// mapContains returns whether m has every entry of sub, ignoring the case of
+// their values
+func mapContains(m, sub map[string]string) bool {
+	for k, v := range sub {
+		if !strings.EqualFold(m[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+func primaryKeyCQL(partitionKeys, colKeys []string, compoundKey bool) string {
+	str := ""
+	if len(colKeys) > 0 { //key (or composite key) + clustering columns
+		str = "PRIMARY KEY ((%v), %v)"
+	} else if compoundKey { //compound key just one set of parenthesis
+		str = "PRIMARY KEY (%v %v)"
+	} else { //otherwise is a composite key without colKeys
+		str = "PRIMARY KEY ((%v %v))"
+	}
+	return fmt.Sprintf(str, j(partitionKeys), j(colKeys))
+}
+
+// createViewStmt generates the statement creating a materialized view of the
+// base table. Cassandra requires the primary key of the view to contain every
+// primary key column of the base table, and at most one other column
+func createViewStmt(createStmt, keySpace, view, base string, baseKeys, keys Keys, fields []string, order []ClusteringOrderColumn) (Statement, error) {
+	viewKeys := append(append([]string{}, keys.PartitionKeys...), keys.ClusteringColumns...)
+	for _, baseKey := range append(append([]string{}, baseKeys.PartitionKeys...), baseKeys.ClusteringColumns...) {
+		if !containsField(viewKeys, baseKey) {
+			return nil, fmt.Errorf("the primary key of view %s must include %s from the primary key of %s", view, baseKey, base)
+		}
+	}
+	if len(viewKeys) > len(baseKeys.PartitionKeys)+len(baseKeys.ClusteringColumns)+1 {
+		return nil, fmt.Errorf("the primary key of view %s can only include one column which isn't in the primary key of %s", view, base)
+	}
+
+	notNulls := make([]string, len(viewKeys))
+	for i, key := range viewKeys {
+		notNulls[i] = strings.ToLower(key) + " IS NOT NULL"
+	}
+
+	lines := []string{
+		fmt.Sprintf("%s %s.%s AS", createStmt, keySpace, view),
+		fmt.Sprintf("    SELECT %s FROM %s.%s", j(fields), keySpace, base),
+		fmt.Sprintf("    WHERE %s", strings.Join(notNulls, " AND ")),
+		"    " + primaryKeyCQL(keys.PartitionKeys, keys.ClusteringColumns, keys.Compound),
+	}
+
+	if len(order) > 0 {
+		orderStrs := make([]string, len(order))
+		for i, o := range order {
+			orderStrs[i] = fmt.Sprintf("%v %v", strings.ToLower(o.Column), o.Direction.String())
+		}
+		lines = append(lines, fmt.Sprintf("WITH CLUSTERING ORDER BY (%v)", strings.Join(orderStrs, ", ")))
+	}
+
+	return cqlStatement{query: strings.Join(lines, "\n")}, nil
+}
+
+// createIndexStmts generates a CREATE INDEX statement for each index, eg.
+//   - CREATE INDEX IF NOT EXISTS users_email_idx ON ks.users (email)
+//   - CREATE CUSTOM INDEX IF NOT EXISTS users_name_idx ON ks.users (name) USING 'org.apache.cassandra.index.sasi.SASIIndex'
+func createIndexStmts(keySpace, cf string, indexes []Index, fields []string) ([]Statement, error) {
+	stmts := make([]Statement, 0, len(indexes))
+	for _, index := range indexes {
+		if !containsField(fields, index.Column) {
+			return nil, fmt.Errorf("can't index %s as it is not a field of %s", index.Column, cf)
+		}
+
+		name := index.Name
+		if name == "" && index.Keys {
+			name = fmt.Sprintf("%s_%s_keys_idx", cf, index.Column)
+		} else if name == "" {
+			name = fmt.Sprintf("%s_%s_idx", cf, index.Column)
+		}
+
+		if index.Keys && index.SASI {
+			return nil, fmt.Errorf("can't index the keys of %s with a SASI index", index.Column)
+		}
+
+		if !index.SASI {
+			target := strings.ToLower(index.Column)
+			if index.Keys {
+				target = "KEYS(" + target + ")"
+			}
+			stmts = append(stmts, cqlStatement{query: fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s.%s (%s)",
+				strings.ToLower(name), keySpace, cf, target)})
+			continue
+		}
+
+		qry := fmt.Sprintf("CREATE CUSTOM INDEX IF NOT EXISTS %s ON %s.%s (%s) USING '%s'",
+			strings.ToLower(name), keySpace, cf, strings.ToLower(index.Column), sasiIndexClass)
+		if len(index.SASIOptions) > 0 {
+			qry += " WITH OPTIONS = " + cqlMap(index.SASIOptions)
+		}
+		stmts = append(stmts, cqlStatement{query: qry})
+	}
+	return stmts, nil
+}
+
+// createTypeStmts generates the statements creating the user-defined types
+// used by the fields of a table. Types nested in another type come before it,
+// as Cassandra requires them to exist first
+func createTypeStmts(keySpace string, fields []string, fieldInfo map[string]r.Field) ([]Statement, error) {
+	stmts := []Statement{}
+	created := map[string]reflect.Type{}
+
+	var createType func(name string, typ reflect.Type) error
+	createType = func(name string, typ reflect.Type) error {
+		typ = getNonPtrType(typ)
+		if prev, ok := created[name]; ok {
+			if prev != typ {
+				return fmt.Errorf("user-defined type %s can't be used for both %v and %v", name, prev, typ)
+			}
+			return nil
+		}
+		if typ.Kind() != reflect.Struct {
+			return fmt.Errorf("user-defined type %s must be a struct, not %v", name, typ)
+		}
+		created[name] = typ
+
+		elemInfo, err := r.StructFieldMap(typ, true)
+		if err != nil {
+			return err
+		}
+		elems := make([]string, 0, len(elemInfo))
+		for elem := range elemInfo {
+			elems = append(elems, elem)
+		}
+		sort.Strings(elems)
+
+		elemLines := make([]string, len(elems))
+		for i, elem := range elems {
+			field := elemInfo[elem]
+			if udt := field.UDT(); udt != "" {
+				if err := createType(udt, field.Type()); err != nil {
+					return err
+				}
+			}
+			typeStr, err := columnTypeOf(reflect.Zero(field.Type()).Interface(), field)
+			if err != nil {
+				return fmt.Errorf("user-defined type %s: %v", name, err)
+			}
+			elemLines[i] = "    " + elem + " " + typeStr
+		}
+
+		stmts = append(stmts, cqlStatement{query: strings.Join([]string{
+			fmt.Sprintf("CREATE TYPE IF NOT EXISTS %s.%s (", keySpace, name),
+			strings.Join(elemLines, ",\n"),
+			")",
+		}, "\n")})
+		return nil
+	}
+
+	for _, f := range fields {
+		field := fieldInfo[strings.ToLower(f)]
+		if udt := field.UDT(); udt != "" {
+			if err := createType(udt, field.Type()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return stmts, nil
+}
+
 func j(s []string) string {
 	s1 := []string{}
 	for _, v := range s {
@@ -109,39 +409,121 @@ type CQLTyper interface {
 	CQLType() gocql.Type
 }
 
+// validateCounterColumns checks that a table either has no counter columns or
+// has only counter columns outside of the primary key, as Cassandra requires
+func validateCounterColumns(partitionKeys, colKeys []string, fields []string, values []interface{}) error {
+	var counters, regular []string
+	for i, field := range fields {
+		if containsField(partitionKeys, field) || containsField(colKeys, field) {
+			continue
+		}
+		if _, ok := values[i].(Counter); ok {
+			counters = append(counters, field)
+		} else {
+			regular = append(regular, field)
+		}
+	}
+
+	if len(counters) > 0 && len(regular) > 0 {
+		return fmt.Errorf("counter columns %v can't be mixed with regular columns %v", counters, regular)
+	}
+	return nil
+}
+
+// validateStaticColumns checks static columns aren't part of the primary key,
+// and that there are rows in each partition for them to be shared by
+func validateStaticColumns(partitionKeys, colKeys, staticCols []string) error {
+	if len(staticCols) == 0 {
+		return nil
+	}
+	if len(colKeys) == 0 {
+		return fmt.Errorf("static columns are only allowed in tables with clustering columns")
+	}
+	for _, col := range staticCols {
+		if containsField(partitionKeys, col) || containsField(colKeys, col) {
+			return fmt.Errorf("primary key column %s can't be static", col)
+		}
+	}
+	return nil
+}
+
+// containsField returns whether the field is in the list, ignoring case as
+// Cassandra does for column names
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if strings.EqualFold(f, field) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	cqlTypesMtx sync.RWMutex
+	// cqlTypes maps Go types to the CQL type their values are stored as
+	cqlTypes = map[reflect.Type]gocql.Type{
+		reflect.TypeOf(int(0)):           gocql.TypeInt,
+		reflect.TypeOf(int8(0)):          gocql.TypeTinyInt,
+		reflect.TypeOf(int16(0)):         gocql.TypeSmallInt,
+		reflect.TypeOf(int32(0)):         gocql.TypeInt,
+		reflect.TypeOf(int64(0)):         gocql.TypeBigInt,
+		reflect.TypeOf(uint(0)):          gocql.TypeVarint,
+		reflect.TypeOf(uint8(0)):         gocql.TypeVarint,
+		reflect.TypeOf(uint16(0)):        gocql.TypeVarint,
+		reflect.TypeOf(uint32(0)):        gocql.TypeVarint,
+		reflect.TypeOf(uint64(0)):        gocql.TypeVarint,
+		reflect.TypeOf(big.Int{}):        gocql.TypeVarint,
+		reflect.TypeOf(&big.Int{}):       gocql.TypeVarint,
+		reflect.TypeOf(inf.Dec{}):        gocql.TypeDecimal,
+		reflect.TypeOf(&inf.Dec{}):       gocql.TypeDecimal,
+		reflect.TypeOf(""):               gocql.TypeVarchar,
+		reflect.TypeOf(float32(0)):       gocql.TypeFloat,
+		reflect.TypeOf(float64(0)):       gocql.TypeDouble,
+		reflect.TypeOf(false):            gocql.TypeBoolean,
+		reflect.TypeOf(time.Time{}):      gocql.TypeTimestamp,
+		reflect.TypeOf(gocql.UUID{}):     gocql.TypeUUID,
+		reflect.TypeOf(gocql.Duration{}): gocql.TypeDuration,
+		reflect.TypeOf(net.IP{}):         gocql.TypeInet,
+		reflect.TypeOf([]byte{}):         gocql.TypeBlob,
+		reflect.TypeOf(Counter(0)):       gocql.TypeCounter,
+	}
+)
+
+// RegisterCQLType sets the CQL type values of the same Go type as the given
+// value are stored as, overriding the default for the type. To set the type
+// of a single field instead, use the type option of its tag (eg.
+// `cql:"created,type=timeuuid"`)
+func RegisterCQLType(value interface{}, t gocql.Type) {
+	cqlTypesMtx.Lock()
+	defer cqlTypesMtx.Unlock()
+	cqlTypes[reflect.TypeOf(value)] = t
+}
+
 func cassaType(i interface{}) gocql.Type {
-	switch i.(type) {
-	case int, int32:
-		return gocql.TypeInt
-	case int64:
-		return gocql.TypeBigInt
-	case int8, int16, uint, uint8, uint16, uint32, uint64:
-		return gocql.TypeVarint
-	case string:
-		return gocql.TypeVarchar
-	case float32:
-		return gocql.TypeFloat
-	case float64:
-		return gocql.TypeDouble
-	case bool:
-		return gocql.TypeBoolean
-	case time.Time:
-		return gocql.TypeTimestamp
-	case gocql.UUID:
-		return gocql.TypeUUID
-	case []byte:
-		return gocql.TypeBlob
-	case Counter:
-		return gocql.TypeCounter
+	typ := reflect.TypeOf(i)
+	if typ == nil {
+		return gocql.TypeCustom
+	}
+
+	cqlTypesMtx.RLock()
+	t, ok := cqlTypes[typ]
+	cqlTypesMtx.RUnlock()
+	if ok {
+		return t
 	}
 
 	// Fallback to using reflection if type not recognised
-	typ := reflect.TypeOf(i)
 	switch typ.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+	case reflect.Int, reflect.Int32:
 		return gocql.TypeInt
+	case reflect.Int8:
+		return gocql.TypeTinyInt
+	case reflect.Int16:
+		return gocql.TypeSmallInt
 	case reflect.Int64:
 		return gocql.TypeBigInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return gocql.TypeVarint
 	case reflect.String:
 		return gocql.TypeVarchar
 	case reflect.Float32:
@@ -160,44 +542,102 @@ func cassaType(i interface{}) gocql.Type {
 	return gocql.TypeCustom
 }
 
-func stringTypeOf(i interface{}) (string, error) {
-	_, isByteSlice := i.([]byte)
-	if !isByteSlice {
-		// Check if we found a higher kinded type
-		switch reflect.ValueOf(i).Kind() {
-		case reflect.Slice:
-			elemVal := reflect.Indirect(reflect.New(reflect.TypeOf(i).Elem())).Interface()
-			ct := cassaType(elemVal)
-			if ct == gocql.TypeCustom {
-				return "", fmt.Errorf("Unsupported type %T", i)
-			}
-			return fmt.Sprintf("list<%v>", ct), nil
-		case reflect.Map:
-			keyVal := reflect.Indirect(reflect.New(reflect.TypeOf(i).Key())).Interface()
-			elemVal := reflect.Indirect(reflect.New(reflect.TypeOf(i).Elem())).Interface()
-			keyCt := cassaType(keyVal)
-			elemCt := cassaType(elemVal)
-			if keyCt == gocql.TypeCustom || elemCt == gocql.TypeCustom {
-				return "", fmt.Errorf("Unsupported map key or value type %T", i)
-			}
-			return fmt.Sprintf("map<%v, %v>", keyCt, elemCt), nil
+// columnTypeOf returns the CQL type of a field, which can be set with the type
+// option of the field's tag. User-defined types are frozen so that they can be
+// used anywhere in the table
+func columnTypeOf(i interface{}, field r.Field) (string, error) {
+	if typ := field.CQLType(); typ != "" {
+		if strings.Count(typ, "<") != strings.Count(typ, ">") {
+			return "", fmt.Errorf("type %s of %s is incomplete, separate the types it holds with ; rather than ,",
+				typ, field.Name())
 		}
+		return typ, nil
 	}
-	ct := cassaType(i)
-	if ct == gocql.TypeCustom {
+	if udt := field.UDT(); udt != "" {
+		return fmt.Sprintf("frozen<%s>", udt), nil
+	}
+	if i == nil {
 		return "", fmt.Errorf("Unsupported type %T", i)
 	}
-	return cassaTypeToString(ct)
+	return cqlTypeOf(reflect.TypeOf(i), field, false)
+}
+
+// cqlTypeOf maps a Go type to a CQL type, recursing into collections and
+// tuples. The set and frozen options of the field's tag apply to the
+// outermost collection, whereas collections and tuples nested in another are
+// always frozen as Cassandra requires
+func cqlTypeOf(typ reflect.Type, field r.Field, nested bool) (string, error) {
+	if typ.Kind() == reflect.Interface {
+		return "", fmt.Errorf("Unsupported type %v", typ)
+	}
+	if ct := cassaType(reflect.Zero(typ).Interface()); ct != gocql.TypeCustom {
+		return cassaTypeToString(ct)
+	}
+
+	var str string
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		elem, err := cqlTypeOf(typ.Elem(), field, true)
+		if err != nil {
+			return "", err
+		}
+		collection := "list"
+		if !nested && field.Set() {
+			collection = "set"
+		}
+		str = fmt.Sprintf("%s<%s>", collection, elem)
+	case reflect.Map:
+		key, err := cqlTypeOf(typ.Key(), field, true)
+		if err != nil {
+			return "", err
+		}
+		elem, err := cqlTypeOf(typ.Elem(), field, true)
+		if err != nil {
+			return "", err
+		}
+		str = fmt.Sprintf("map<%s, %s>", key, elem)
+	case reflect.Struct:
+		if !field.Tuple() {
+			return "", fmt.Errorf("Unsupported type %v", typ)
+		}
+		// Like gocql, tuple elements are mapped to the struct's fields in order
+		elems := make([]string, typ.NumField())
+		for i := range elems {
+			elem, err := cqlTypeOf(typ.Field(i).Type, field, true)
+			if err != nil {
+				return "", err
+			}
+			elems[i] = elem
+		}
+		str = fmt.Sprintf("tuple<%s>", strings.Join(elems, ", "))
+	default:
+		return "", fmt.Errorf("Unsupported type %v", typ)
+	}
+
+	if nested || field.Frozen() {
+		return fmt.Sprintf("frozen<%s>", str), nil
+	}
+	return str, nil
 }
 
 func cassaTypeToString(t gocql.Type) (string, error) {
 	switch t {
+	case gocql.TypeAscii:
+		return "ascii", nil
+	case gocql.TypeTinyInt:
+		return "tinyint", nil
+	case gocql.TypeSmallInt:
+		return "smallint", nil
 	case gocql.TypeInt:
 		return "int", nil
 	case gocql.TypeBigInt:
 		return "bigint", nil
 	case gocql.TypeVarint:
 		return "varint", nil
+	case gocql.TypeDecimal:
+		return "decimal", nil
+	case gocql.TypeText:
+		return "text", nil
 	case gocql.TypeVarchar:
 		return "varchar", nil
 	case gocql.TypeFloat:
@@ -208,8 +648,18 @@ func cassaTypeToString(t gocql.Type) (string, error) {
 		return "boolean", nil
 	case gocql.TypeTimestamp:
 		return "timestamp", nil
+	case gocql.TypeDate:
+		return "date", nil
+	case gocql.TypeTime:
+		return "time", nil
+	case gocql.TypeDuration:
+		return "duration", nil
 	case gocql.TypeUUID:
 		return "uuid", nil
+	case gocql.TypeTimeUUID:
+		return "timeuuid", nil
+	case gocql.TypeInet:
+		return "inet", nil
 	case gocql.TypeBlob:
 		return "blob", nil
 	case gocql.TypeCounter:
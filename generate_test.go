@@ -1,11 +1,16 @@
 package gocassa
 
 import (
+	"math/big"
+	"net"
+	goreflect "reflect"
 	"testing"
+	"time"
 
 	"github.com/gocql/gocql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/inf.v0"
 
 	"github.com/monzo/gocassa/reflect"
 )
@@ -31,3 +36,213 @@ func TestGoCassaType_CustomType(t *testing.T) {
 	typ := cassaType(m["Field"])
 	assert.Equal(t, gocql.TypeVarchar, typ)
 }
+
+type celsius float32
+
+type scalarTypes struct {
+	Id       gocql.UUID     `cql:"id,type=timeuuid"`
+	Tiny     int8           `cql:"tiny"`
+	Small    int16          `cql:"small"`
+	Int      int            `cql:"int"`
+	Unsigned uint32         `cql:"unsigned"`
+	Varint   *big.Int       `cql:"varint"`
+	Decimal  *inf.Dec       `cql:"decimal"`
+	IP       net.IP         `cql:"ip"`
+	Duration gocql.Duration `cql:"duration"`
+	Date     time.Time      `cql:"date,type=date"`
+	Time     time.Duration  `cql:"time,type=time"`
+	Ascii    string         `cql:"ascii,type=ascii"`
+	Temp     celsius        `cql:"temp"`
+}
+
+func TestScalarColumnTypes(t *testing.T) {
+	fieldInfo, err := reflect.StructFieldMap(goreflect.TypeOf(scalarTypes{}), true)
+	require.NoError(t, err)
+
+	columnType := func(field string) string {
+		typ, err := columnTypeOf(goreflect.Zero(fieldInfo[field].Type()).Interface(), fieldInfo[field])
+		assert.NoError(t, err, field)
+		return typ
+	}
+	for field, expected := range map[string]string{
+		"id":       "timeuuid",
+		"tiny":     "tinyint",
+		"small":    "smallint",
+		"int":      "int",
+		"unsigned": "varint",
+		"varint":   "varint",
+		"decimal":  "decimal",
+		"ip":       "inet",
+		"duration": "duration",
+		"date":     "date",
+		"time":     "time",
+		"ascii":    "ascii",
+		"temp":     "float",
+	} {
+		assert.Equal(t, expected, columnType(field), field)
+	}
+
+	// The default type of a Go type can be overridden
+	RegisterCQLType(celsius(0), gocql.TypeDecimal)
+	defer func() {
+		cqlTypesMtx.Lock()
+		defer cqlTypesMtx.Unlock()
+		delete(cqlTypes, goreflect.TypeOf(celsius(0)))
+	}()
+	assert.Equal(t, "decimal", columnType("temp"))
+
+	// Commas separate tag options, so the types of a collection are separated
+	// by semicolons, and a type cut short by a comma is rejected
+	var row struct {
+		Scores    map[string]int `cql:"scores,type=map<text;int>"`
+		Truncated map[string]int `cql:"truncated,type=map<text,int>"`
+	}
+	fieldInfo, err = reflect.StructFieldMap(goreflect.TypeOf(row), true)
+	require.NoError(t, err)
+	assert.Equal(t, "map<text, int>", columnType("scores"))
+	_, err = columnTypeOf(map[string]int{}, fieldInfo["truncated"])
+	assert.EqualError(t, err, "type map<text of truncated is incomplete, separate the types it holds with ; rather than ,")
+}
+
+func TestCollectionColumnTypes(t *testing.T) {
+	type point struct {
+		X, Y float64
+	}
+	var row struct {
+		Tags       []string            `cql:"tags,set"`
+		FrozenTags []string            `cql:"frozentags,set,frozen"`
+		Lists      map[string][]int    `cql:"lists"`
+		Sets       [][]string          `cql:"sets,set"`
+		Point      point               `cql:"point,tuple"`
+		Points     map[string]point    `cql:"points,tuple"`
+		Nested     map[int]map[int]int `cql:"nested,frozen"`
+		Invalid    point               `cql:"invalid"`
+		Interfaces []interface{}       `cql:"interfaces"`
+	}
+	fieldInfo, err := reflect.StructFieldMap(goreflect.TypeOf(row), true)
+	require.NoError(t, err)
+
+	for field, expected := range map[string]string{
+		"tags":       "set<varchar>",
+		"frozentags": "frozen<set<varchar>>",
+		"lists":      "map<varchar, frozen<list<int>>>",
+		"sets":       "set<frozen<list<varchar>>>",
+		"point":      "tuple<double, double>",
+		"points":     "map<varchar, frozen<tuple<double, double>>>",
+		"nested":     "frozen<map<int, frozen<map<int, int>>>>",
+	} {
+		typ, err := columnTypeOf(goreflect.Zero(fieldInfo[field].Type()).Interface(), fieldInfo[field])
+		assert.NoError(t, err, field)
+		assert.Equal(t, expected, typ, field)
+	}
+
+	for _, field := range []string{"invalid", "interfaces"} {
+		_, err := columnTypeOf(goreflect.Zero(fieldInfo[field].Type()).Interface(), fieldInfo[field])
+		assert.Error(t, err, field)
+	}
+}
+
+func TestCreateIndexStatements(t *testing.T) {
+	stmts, err := createIndexStmts("ks", "users", []Index{
+		{Column: "Email"},
+		{Column: "Name", Name: "users_by_name", SASI: true, SASIOptions: map[string]string{
+			"mode":           "CONTAINS",
+			"case_sensitive": "false",
+		}},
+	}, []string{"Id", "Email", "Name"})
+	require.NoError(t, err)
+	require.Len(t, stmts, 2)
+	assert.Equal(t, "CREATE INDEX IF NOT EXISTS users_email_idx ON ks.users (email)", stmts[0].Query())
+	assert.Equal(t, "CREATE CUSTOM INDEX IF NOT EXISTS users_by_name ON ks.users (name) "+
+		"USING 'org.apache.cassandra.index.sasi.SASIIndex' "+
+		"WITH OPTIONS = {'case_sensitive': 'false', 'mode': 'CONTAINS'}", stmts[1].Query())
+
+	// Quotes in the options are escaped
+	stmts, err = createIndexStmts("ks", "users", []Index{
+		{Column: "Name", SASI: true, SASIOptions: map[string]string{"analyzer_class": "it's"}},
+	}, []string{"Id", "Name"})
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE CUSTOM INDEX IF NOT EXISTS users_name_idx ON ks.users (name) "+
+		"USING 'org.apache.cassandra.index.sasi.SASIIndex' WITH OPTIONS = {'analyzer_class': 'it''s'}", stmts[0].Query())
+
+	_, err = createIndexStmts("ks", "users", []Index{{Column: "Missing"}}, []string{"Id"})
+	assert.Error(t, err)
+
+	stmts, err = createIndexStmts("ks", "users", []Index{{Column: "Labels", Keys: true}}, []string{"Id", "Labels"})
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE INDEX IF NOT EXISTS users_labels_keys_idx ON ks.users (KEYS(labels))", stmts[0].Query())
+	_, err = createIndexStmts("ks", "users", []Index{{Column: "Labels", Keys: true, SASI: true}}, []string{"Id", "Labels"})
+	assert.Error(t, err)
+}
+
+func TestCreateTableProperties(t *testing.T) {
+	gcGrace := 3 * time.Hour
+	stmt, err := createTable("ks", "events", []string{"Id"}, []string{"At"}, nil, []string{"At", "Id"},
+		[]interface{}{time.Time{}, ""}, nil, []ClusteringOrderColumn{{DESC, "At"}}, false, false, "SnappyCompressor",
+		TableProperties{
+			Compaction:          &CompactionStrategy{Class: "LeveledCompactionStrategy", Options: map[string]string{"sstable_size_in_mb": "160"}},
+			DefaultTTL:          24 * time.Hour,
+			GCGrace:             &gcGrace,
+			Caching:             &Caching{Keys: "ALL", RowsPerPartition: "10"},
+			BloomFilterFPChance: 0.01,
+			Comment:             "Alice's events",
+			Compression:         &Compression{Class: "LZ4Compressor", ChunkLengthKB: 64},
+		})
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE TABLE ks.events (
+    at timestamp,
+    id varchar,
+    PRIMARY KEY ((id), at)
+)
+WITH CLUSTERING ORDER BY (at DESC)
+AND compaction = {'class': 'LeveledCompactionStrategy', 'sstable_size_in_mb': '160'}
+AND default_time_to_live = 86400
+AND gc_grace_seconds = 10800
+AND caching = {'keys': 'ALL', 'rows_per_partition': '10'}
+AND bloom_filter_fp_chance = 0.01
+AND comment = 'Alice''s events'
+AND compression = {'class': 'LZ4Compressor', 'chunk_length_in_kb': '64'}
+;`, stmt.Query())
+
+	// Compression can be disabled, and the legacy compressor is used otherwise
+	stmt, err = createTable("ks", "events", []string{"Id"}, nil, nil, []string{"Id"}, []interface{}{""}, nil, nil,
+		false, true, "", TableProperties{Compression: &Compression{}})
+	require.NoError(t, err)
+	assert.Contains(t, stmt.Query(), "\nWITH COMPACT STORAGE\nAND compression = {'enabled': 'false'}\n")
+	stmt, err = createTable("ks", "events", []string{"Id"}, nil, nil, []string{"Id"}, []interface{}{""}, nil, nil,
+		false, false, "SnappyCompressor", TableProperties{})
+	require.NoError(t, err)
+	assert.Contains(t, stmt.Query(), "\nWITH compression = {'sstable_compression': 'SnappyCompressor'}\n")
+}
+
+func TestCreateTableStaticColumns(t *testing.T) {
+	stmt, err := createTable("ks", "posts", []string{"Group"}, []string{"Id"}, []string{"Owner"},
+		[]string{"Group", "Id", "Owner"}, []interface{}{"", "", ""}, nil, nil, false, false, "", TableProperties{})
+	require.NoError(t, err)
+	assert.Contains(t, stmt.Query(), "    owner varchar static,\n")
+
+	_, err = createTable("ks", "posts", []string{"Group"}, nil, []string{"Owner"},
+		[]string{"Group", "Owner"}, []interface{}{"", ""}, nil, nil, false, false, "", TableProperties{})
+	assert.EqualError(t, err, "static columns are only allowed in tables with clustering columns")
+	_, err = createTable("ks", "posts", []string{"Group"}, []string{"Id"}, []string{"Id"},
+		[]string{"Group", "Id"}, []interface{}{"", ""}, nil, nil, false, false, "", TableProperties{})
+	assert.EqualError(t, err, "primary key column Id can't be static")
+}
+
+func TestTimeWindowCompaction(t *testing.T) {
+	for window, options := range map[time.Duration][2]string{
+		24 * time.Hour:     {"DAYS", "1"},
+		7 * 24 * time.Hour: {"DAYS", "7"},
+		36 * time.Hour:     {"HOURS", "36"},
+		time.Hour:          {"HOURS", "1"},
+		90 * time.Minute:   {"MINUTES", "90"},
+		time.Second:        {"MINUTES", "1"},
+	} {
+		compaction := TimeWindowCompaction(window)
+		assert.Equal(t, "TimeWindowCompactionStrategy", compaction.Class)
+		assert.Equal(t, map[string]string{
+			"compaction_window_unit": options[0],
+			"compaction_window_size": options[1],
+		}, compaction.Options, window.String())
+	}
+}
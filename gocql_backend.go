@@ -1,18 +1,32 @@
 package gocassa
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/gocql/gocql"
 )
 
 type goCQLBackend struct {
 	session *gocql.Session
+	metrics MetricsCollector
+}
+
+// observe reports the metrics of a query to the collector, if there is one
+func (cb goCQLBackend) observe(m QueryMetrics) {
+	if cb.metrics != nil {
+		cb.metrics.ObserveQuery(m)
+	}
 }
 
 func (cb goCQLBackend) Query(stmt Statement, scanner Scanner) error {
 	return cb.QueryWithOptions(Options{}, stmt, scanner)
 }
 
-func (cb goCQLBackend) QueryWithOptions(opts Options, stmt Statement, scanner Scanner) error {
+func (cb goCQLBackend) QueryWithOptions(opts Options, stmt Statement, scanner Scanner) (err error) {
+	start, rows := time.Now(), 0
+	defer func() { cb.observe(newQueryMetrics(start, rows, err, stmt)) }()
+
 	qu := cb.session.Query(stmt.Query(), stmt.Values()...)
 	if opts.Consistency != nil {
 		qu = qu.Consistency(*opts.Consistency)
@@ -22,18 +36,94 @@ func (cb goCQLBackend) QueryWithOptions(opts Options, stmt Statement, scanner Sc
 	}
 
 	iter := qu.Iter()
-	if _, err := scanner.ScanIter(iter.Scanner()); err != nil {
+	if rows, err = scanner.ScanIter(iter.Scanner()); err != nil {
 		return err
 	}
 
 	return iter.Close()
 }
 
+func (cb goCQLBackend) QueryPageWithOptions(opts Options, stmt Statement, scanner Scanner, pageSize int, pageState []byte) (_ []byte, err error) {
+	start, rows := time.Now(), 0
+	defer func() { cb.observe(newQueryMetrics(start, rows, err, stmt)) }()
+
+	// Setting the page state disables auto paging, so we only fetch this page
+	qu := cb.session.Query(stmt.Query(), stmt.Values()...).
+		PageSize(pageSize).
+		PageState(pageState)
+	if opts.Consistency != nil {
+		qu = qu.Consistency(*opts.Consistency)
+	}
+	if opts.Context != nil {
+		qu = qu.WithContext(opts.Context)
+	}
+
+	iter := qu.Iter()
+	if rows, err = scanner.ScanIter(iter.Scanner()); err != nil {
+		return nil, err
+	}
+
+	nextPageState := iter.PageState()
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return nextPageState, nil
+}
+
+// QueryIterWithOptions reports the metrics of the query once the iterator's
+// Err is called, its duration includes the time taken to iterate
+func (cb goCQLBackend) QueryIterWithOptions(opts Options, stmt Statement) Scannable {
+	start := time.Now()
+	qu := cb.session.Query(stmt.Query(), stmt.Values()...)
+	if opts.Consistency != nil {
+		qu = qu.Consistency(*opts.Consistency)
+	}
+	if opts.Context != nil {
+		qu = qu.WithContext(opts.Context)
+	}
+	scannable := qu.Iter().Scanner()
+	if cb.metrics == nil {
+		return scannable
+	}
+	return &observedScannable{Scannable: scannable, observe: func(rows int, err error) {
+		cb.observe(newQueryMetrics(start, rows, err, stmt))
+	}}
+}
+
+// observedScannable counts the rows it iterates over, and calls observe
+// the first time Err is called
+type observedScannable struct {
+	Scannable
+	rows     int
+	observe  func(rows int, err error)
+	observed bool
+}
+
+func (s *observedScannable) Next() bool {
+	if !s.Scannable.Next() {
+		return false
+	}
+	s.rows++
+	return true
+}
+
+func (s *observedScannable) Err() error {
+	err := s.Scannable.Err()
+	if !s.observed {
+		s.observed = true
+		s.observe(s.rows, err)
+	}
+	return err
+}
+
 func (cb goCQLBackend) Execute(stmt Statement) error {
 	return cb.ExecuteWithOptions(Options{}, stmt)
 }
 
-func (cb goCQLBackend) ExecuteWithOptions(opts Options, stmt Statement) error {
+func (cb goCQLBackend) ExecuteWithOptions(opts Options, stmt Statement) (err error) {
+	start := time.Now()
+	defer func() { cb.observe(newQueryMetrics(start, 0, err, stmt)) }()
+
 	qu := cb.session.Query(stmt.Query(), stmt.Values()...)
 	if opts.Consistency != nil {
 		qu = qu.Consistency(*opts.Consistency)
@@ -44,15 +134,50 @@ func (cb goCQLBackend) ExecuteWithOptions(opts Options, stmt Statement) error {
 	return qu.Exec()
 }
 
+func (cb goCQLBackend) ExecuteCASWithOptions(opts Options, stmt Statement, current map[string]interface{}) (applied bool, err error) {
+	start := time.Now()
+	defer func() { cb.observe(newQueryMetrics(start, 0, casError(applied, err), stmt)) }()
+
+	qu := cb.session.Query(stmt.Query(), stmt.Values()...)
+	if opts.Consistency != nil {
+		qu = qu.Consistency(*opts.Consistency)
+	}
+	if opts.Context != nil {
+		qu = qu.WithContext(opts.Context)
+	}
+	return qu.MapScanCAS(current)
+}
+
 func (cb goCQLBackend) ExecuteAtomically(stmts []Statement) error {
 	return cb.ExecuteAtomicallyWithOptions(Options{}, stmts)
 }
 
 func (cb goCQLBackend) ExecuteAtomicallyWithOptions(opts Options, stmts []Statement) error {
+	return cb.ExecuteBatchWithOptions(opts, LoggedBatch, stmts)
+}
+
+func (cb goCQLBackend) ExecuteBatchWithOptions(opts Options, batchType BatchType, stmts []Statement) (err error) {
 	if len(stmts) == 0 {
 		return nil
 	}
-	batch := cb.session.NewBatch(gocql.LoggedBatch)
+	start := time.Now()
+	defer func() {
+		m := newQueryMetrics(start, 0, err, stmts...)
+		m.Type, m.BatchSize = BatchQuery, len(stmts)
+		cb.observe(m)
+	}()
+	var typ gocql.BatchType
+	switch batchType {
+	case LoggedBatch:
+		typ = gocql.LoggedBatch
+	case UnloggedBatch:
+		typ = gocql.UnloggedBatch
+	case CounterBatch:
+		typ = gocql.CounterBatch
+	default:
+		return fmt.Errorf("unknown batch type %s", batchType)
+	}
+	batch := cb.session.NewBatch(typ)
 	for i := range stmts {
 		stmt := stmts[i]
 		batch.Query(stmt.Query(), stmt.Values()...)
@@ -72,8 +197,15 @@ func (cb goCQLBackend) ExecuteAtomicallyWithOptions(opts Options, stmts []Statem
 // Then you can use NewConnection to mint your own thing
 // See #90 for more details
 func GoCQLSessionToQueryExecutor(sess *gocql.Session) QueryExecutor {
+	return GoCQLSessionToQueryExecutorWithMetrics(sess, nil)
+}
+
+// GoCQLSessionToQueryExecutorWithMetrics is GoCQLSessionToQueryExecutor, but
+// the metrics of every query are reported to the collector
+func GoCQLSessionToQueryExecutorWithMetrics(sess *gocql.Session, metrics MetricsCollector) QueryExecutor {
 	return goCQLBackend{
 		session: sess,
+		metrics: metrics,
 	}
 }
 
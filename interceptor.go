@@ -0,0 +1,192 @@
+package gocassa
+
+// QueryType is the type of a query seen by an Interceptor
+type QueryType string
+
+const (
+	ReadQuery   QueryType = "read"
+	InsertQuery QueryType = "insert"
+	UpdateQuery QueryType = "update"
+	DeleteQuery QueryType = "delete"
+	BatchQuery  QueryType = "batch"
+	// CQLQuery is any other statement, such as those creating tables
+	CQLQuery QueryType = "cql"
+)
+
+// Query describes a call to the QueryExecutor of a Connection, as seen by an Interceptor
+type Query struct {
+	Type QueryType
+	// Keyspace and Table are those the statements act on. Table is empty if the statements act on
+	// several tables, or if they're CQL which gocassa didn't generate
+	Keyspace string
+	Table    string
+	// Statements holds a single statement, except for batches. Interceptors may replace them, but the
+	// fields selected by a read must stay the same
+	Statements []Statement
+	// Options of the query, Options.Context holds the context it was run with
+	Options Options
+	// BatchType is the type of batch the statements of a batch query are executed in
+	BatchType BatchType
+	// Applied is whether a conditional write (lightweight transaction) was applied, once it has run.
+	// It is true until then, so a conditional write which an interceptor skips without returning an
+	// error is reported as applied. An interceptor skipping it can set Applied to false instead
+	Applied bool
+}
+
+// QueryInvoker executes a query, by calling the next Interceptor or the QueryExecutor itself
+type QueryInvoker func(q *Query) error
+
+// Interceptor is called around every query run through a Connection. It can observe or modify the
+// query before passing it on to next, and observe the error returned. If it doesn't call next the
+// query isn't executed and the error it returns is returned instead, so reads return no rows.
+type Interceptor func(q *Query, next QueryInvoker) error
+
+// ConnectionOption configures a Connection created with NewConnection
+type ConnectionOption func(c *connection)
+
+// WithInterceptors wraps the QueryExecutor of a Connection with the interceptors. The first
+// interceptor is the outermost, so it is called first and sees the outcome last.
+func WithInterceptors(interceptors ...Interceptor) ConnectionOption {
+	return func(c *connection) {
+		if len(interceptors) > 0 {
+			c.q = interceptingQueryExecutor{qe: c.q, interceptors: interceptors}
+		}
+	}
+}
+
+// interceptingQueryExecutor passes every query through its interceptors
+// before executing it with the QueryExecutor it wraps
+type interceptingQueryExecutor struct {
+	qe           QueryExecutor
+	interceptors []Interceptor
+}
+
+func newQuery(opts Options, stmts ...Statement) *Query {
+	q := &Query{Type: CQLQuery, Statements: stmts, Options: opts}
+	if len(stmts) > 1 {
+		q.Type = BatchQuery
+	} else if len(stmts) == 1 {
+		switch stmts[0].(type) {
+		case SelectStatement:
+			q.Type = ReadQuery
+		case InsertStatement:
+			q.Type = InsertQuery
+		case UpdateStatement:
+			q.Type = UpdateQuery
+		case DeleteStatement:
+			q.Type = DeleteQuery
+		}
+	}
+
+	for i, stmt := range stmts {
+		tableStmt, ok := stmt.(interface {
+			Keyspace() string
+			Table() string
+		})
+		if !ok {
+			q.Keyspace, q.Table = "", ""
+			break
+		}
+		if i == 0 {
+			q.Keyspace, q.Table = tableStmt.Keyspace(), tableStmt.Table()
+			continue
+		}
+		if tableStmt.Keyspace() != q.Keyspace {
+			q.Keyspace = ""
+		}
+		if tableStmt.Table() != q.Table {
+			q.Table = ""
+		}
+	}
+	return q
+}
+
+func (qe interceptingQueryExecutor) intercept(q *Query, invoke QueryInvoker) error {
+	for i := len(qe.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := qe.interceptors[i], invoke
+		invoke = func(q *Query) error {
+			return interceptor(q, next)
+		}
+	}
+	return invoke(q)
+}
+
+func (qe interceptingQueryExecutor) QueryWithOptions(opts Options, stmt Statement, scanner Scanner) error {
+	return qe.intercept(newQuery(opts, stmt), func(q *Query) error {
+		return qe.qe.QueryWithOptions(q.Options, q.Statements[0], scanner)
+	})
+}
+
+func (qe interceptingQueryExecutor) Query(stmt Statement, scanner Scanner) error {
+	return qe.QueryWithOptions(Options{}, stmt, scanner)
+}
+
+func (qe interceptingQueryExecutor) ExecuteWithOptions(opts Options, stmt Statement) error {
+	return qe.intercept(newQuery(opts, stmt), func(q *Query) error {
+		return qe.qe.ExecuteWithOptions(q.Options, q.Statements[0])
+	})
+}
+
+func (qe interceptingQueryExecutor) Execute(stmt Statement) error {
+	return qe.ExecuteWithOptions(Options{}, stmt)
+}
+
+func (qe interceptingQueryExecutor) ExecuteAtomically(stmts []Statement) error {
+	return qe.ExecuteAtomicallyWithOptions(Options{}, stmts)
+}
+
+func (qe interceptingQueryExecutor) ExecuteAtomicallyWithOptions(opts Options, stmts []Statement) error {
+	return qe.ExecuteBatchWithOptions(opts, LoggedBatch, stmts)
+}
+
+func (qe interceptingQueryExecutor) ExecuteBatchWithOptions(opts Options, batchType BatchType, stmts []Statement) error {
+	q := newQuery(opts, stmts...)
+	q.Type, q.BatchType = BatchQuery, batchType
+	return qe.intercept(q, func(q *Query) error {
+		return qe.qe.ExecuteBatchWithOptions(q.Options, q.BatchType, q.Statements)
+	})
+}
+
+func (qe interceptingQueryExecutor) QueryPageWithOptions(opts Options, stmt Statement, scanner Scanner, pageSize int, pageState []byte) ([]byte, error) {
+	var nextPageState []byte
+	err := qe.intercept(newQuery(opts, stmt), func(q *Query) error {
+		var err error
+		nextPageState, err = qe.qe.QueryPageWithOptions(q.Options, q.Statements[0], scanner, pageSize, pageState)
+		return err
+	})
+	return nextPageState, err
+}
+
+// QueryIterWithOptions passes the query through the interceptors when the
+// iterator is created, errors while iterating aren't seen by them
+func (qe interceptingQueryExecutor) QueryIterWithOptions(opts Options, stmt Statement) Scannable {
+	var iter Scannable
+	err := qe.intercept(newQuery(opts, stmt), func(q *Query) error {
+		iter = qe.qe.QueryIterWithOptions(q.Options, q.Statements[0])
+		return nil
+	})
+	if err != nil || iter == nil {
+		return errScannable{err: err}
+	}
+	return iter
+}
+
+func (qe interceptingQueryExecutor) ExecuteCASWithOptions(opts Options, stmt Statement, current map[string]interface{}) (bool, error) {
+	q := newQuery(opts, stmt)
+	q.Applied = true
+	err := qe.intercept(q, func(q *Query) error {
+		var err error
+		q.Applied, err = qe.qe.ExecuteCASWithOptions(q.Options, q.Statements[0], current)
+		return err
+	})
+	return q.Applied, err
+}
+
+// errScannable is a Scannable without any rows, which returns err
+type errScannable struct {
+	err error
+}
+
+func (s errScannable) Next() bool                     { return false }
+func (s errScannable) Scan(dest ...interface{}) error { return s.err }
+func (s errScannable) Err() error                     { return s.err }
@@ -0,0 +1,124 @@
+package gocassa
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type interceptedUser struct {
+	Id   string
+	Name string
+}
+
+func TestInterceptorObservesQueries(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	var seen []Query
+	observe := func(q *Query, next QueryInvoker) error {
+		err := next(q)
+		seen = append(seen, *q)
+		return err
+	}
+	ks := NewConnection(qe, WithInterceptors(observe)).KeySpace("app")
+	tbl := ks.MapTable("users", "Id", interceptedUser{})
+
+	ctx := context.Background()
+	require.NoError(t, tbl.Set(interceptedUser{Id: "1", Name: "Jane"}).RunWithContext(ctx))
+	require.NoError(t, tbl.Read("1", &interceptedUser{}).Run())
+	require.NoError(t, tbl.Delete("1").Run())
+	require.NoError(t, tbl.SetIfNotExists(interceptedUser{Id: "2"}).Run())
+	require.NoError(t, tbl.Set(interceptedUser{Id: "1"}).Add(tbl.Delete("2")).RunAtomically())
+	require.NoError(t, tbl.Create())
+
+	require.Len(t, seen, 6)
+	assert.Equal(t, UpdateQuery, seen[0].Type)
+	assert.Equal(t, "app", seen[0].Keyspace)
+	assert.Equal(t, "users_map_Id", seen[0].Table)
+	assert.Equal(t, ctx, seen[0].Options.Context)
+	assert.Equal(t, ReadQuery, seen[1].Type)
+	assert.Equal(t, DeleteQuery, seen[2].Type)
+	assert.Equal(t, InsertQuery, seen[3].Type)
+	assert.True(t, seen[3].Applied)
+	assert.Equal(t, BatchQuery, seen[4].Type)
+	assert.Len(t, seen[4].Statements, 2)
+	assert.Equal(t, "users_map_Id", seen[4].Table)
+	assert.Equal(t, CQLQuery, seen[5].Type)
+	assert.Equal(t, "", seen[5].Table)
+}
+
+func TestInterceptorModifiesQueries(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	quorum := func(q *Query, next QueryInvoker) error {
+		cons := gocql.Quorum
+		q.Options.Consistency = &cons
+		return next(q)
+	}
+	ks := NewConnection(qe, WithInterceptors(quorum)).KeySpace("app")
+	tbl := ks.MapTable("users", "Id", interceptedUser{})
+
+	require.NoError(t, tbl.Read("1", &interceptedUser{}).Run())
+	require.NotNil(t, qe.opts.Consistency)
+	assert.Equal(t, gocql.Quorum, *qe.opts.Consistency)
+}
+
+func TestInterceptorShortCircuits(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	errReadOnly := errors.New("read only")
+	readOnly := func(q *Query, next QueryInvoker) error {
+		if q.Type != ReadQuery {
+			return errReadOnly
+		}
+		return next(q)
+	}
+	ks := NewConnection(qe, WithInterceptors(readOnly)).KeySpace("app")
+	tbl := ks.MapTable("users", "Id", interceptedUser{})
+
+	assert.Equal(t, errReadOnly, tbl.Set(interceptedUser{Id: "1"}).Run())
+	assert.Equal(t, errReadOnly, tbl.Set(interceptedUser{Id: "1"}).Add(tbl.Delete("2")).RunAtomically())
+	assert.Nil(t, qe.stmt)
+
+	require.NoError(t, tbl.Read("1", &interceptedUser{}).Run())
+	assert.NotNil(t, qe.stmt)
+
+	skip := func(q *Query, next QueryInvoker) error { return nil }
+	qe.stmt = nil
+	ks = NewConnection(qe, WithInterceptors(skip)).KeySpace("app")
+	scannable := ks.(*k).qe.QueryIterWithOptions(Options{}, noOpStatement{})
+	assert.False(t, scannable.Next())
+	assert.NoError(t, scannable.Err())
+	assert.Nil(t, qe.stmt)
+
+	// Skipped conditional writes are applied, unless the interceptor says otherwise
+	tbl = ks.MapTable("users", "Id", interceptedUser{})
+	assert.NoError(t, tbl.SetIfNotExists(interceptedUser{Id: "1"}).Run())
+	notApplied := func(q *Query, next QueryInvoker) error {
+		q.Applied = false
+		return nil
+	}
+	tbl = NewConnection(qe, WithInterceptors(notApplied)).KeySpace("app").MapTable("users", "Id", interceptedUser{})
+	assert.IsType(t, NotAppliedError{}, tbl.SetIfNotExists(interceptedUser{Id: "1"}).Run())
+	assert.Nil(t, qe.stmt)
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	var calls []string
+	named := func(name string) Interceptor {
+		return func(q *Query, next QueryInvoker) error {
+			calls = append(calls, name+" before")
+			err := next(q)
+			calls = append(calls, name+" after")
+			return err
+		}
+	}
+	ks := NewConnection(qe, WithInterceptors(named("outer"), named("inner"))).KeySpace("app")
+	tbl := ks.MapTable("users", "Id", interceptedUser{})
+
+	require.NoError(t, tbl.Delete("1").Run())
+	assert.Equal(t, "outer before, inner before, inner after, outer after", strings.Join(calls, ", "))
+}
@@ -9,6 +9,12 @@ import (
 // Use ConnectToKeySpace to acquire an instance of KeySpace without getting a Connection.
 type Connection interface {
 	CreateKeySpace(name string) error
+	// CreateKeySpaceWithOptions creates a keyspace replicated as specified by the options, eg. with
+	// NetworkTopologyStrategy across several datacenters
+	CreateKeySpaceWithOptions(name string, opts KeySpaceOptions) error
+	// AlterKeySpace changes the replication of a keyspace. Remember to run a repair after adding
+	// replicas, so they hold existing data
+	AlterKeySpace(name string, opts KeySpaceOptions) error
 	DropKeySpace(name string) error
 	KeySpace(name string) KeySpace
 }
@@ -57,16 +63,49 @@ type KeySpace interface {
 	*/
 	FlakeSeriesTable(prefixForTableName, flakeIDField string, bucketSize time.Duration, rowDefinition interface{}) FlakeSeriesTable
 	MultiFlakeSeriesTable(prefixForTableName, partitionKey, flakeIDField string, bucketSize time.Duration, rowDefinition interface{}) MultiFlakeSeriesTable
+	/*
+		CounterTable holds counters, identified by the partitionKeys and clusteringKeys.
+		Cassandra doesn't allow counters to be mixed with regular columns, so every other field
+		of the rowDefinition must be a Counter. It panics if that isn't the case.
+	*/
+	CounterTable(prefixForTableName string, partitionKeys, clusteringKeys []string, rowDefinition interface{}) CounterTable
 	Table(prefixForTableName string, rowDefinition interface{}, keys Keys) Table
+	// MaterializedView returns a read-only table holding the rows of the base table, keyed by keys.
+	// Cassandra keeps the view up to date as the base table is written to. The primary key of the
+	// view must include the whole primary key of the base table, plus at most one other field.
+	// Writes to the view fail their Preflight check
+	MaterializedView(base Table, name string, keys Keys) Table
 	// DebugMode enables/disables debug mode depending on the value of the input boolean.
-	// When DebugMode is enabled, all built CQL statements are printe to stdout.
+	// When DebugMode is enabled, every query is logged to the logger of the connection (see WithLogger)
+	// regardless of its SlowQueryThreshold, or printed to stdout if it doesn't have one.
 	DebugMode(bool)
+	// SetLogger logs the ops run with the keyspace to logger, in place of the logger of its
+	// connection (see WithLogger)
+	SetLogger(logger Logger, opts LogOptions)
 	// Name returns the keyspace name as in C*
 	Name() string
 	// Tables returns the name of all configured column families in this keyspace
 	Tables() ([]string, error)
 	// Exists returns whether the specified column family exists within the keyspace
 	Exists(string) (bool, error)
+	// Replication returns how the keyspace is currently replicated, as read from C*
+	Replication() (KeySpaceOptions, error)
+	// QueryExecutor returns the QueryExecutor of the connection the keyspace was obtained from
+	QueryExecutor() QueryExecutor
+}
+
+// Migrator applies versioned migrations to a keyspace, recording the migrations which have been
+// applied in its gocassa_migrations table. Use NewMigrator to create one.
+type Migrator interface {
+	// Up applies every migration which hasn't been applied yet, in order of version. It holds a lock
+	// while doing so, and returns a MigrationLockedError if another process holds it already.
+	// Migrations which were applied before a failing one stay applied
+	Up(ctx context.Context) error
+	// Status returns whether each migration has been applied, in order of version. Migrations which
+	// have been applied but aren't known to the Migrator are included
+	Status(ctx context.Context) ([]MigrationStatus, error)
+	// DryRun returns the statements which Up would execute, without executing them
+	DryRun(ctx context.Context) ([]Statement, error)
 }
 
 //
@@ -82,6 +121,13 @@ type MapTable interface {
 	Delete(partitionKey interface{}) Op
 	Read(partitionKey, pointer interface{}) Op
 	MultiRead(partitionKeys []interface{}, pointerToASlice interface{}) Op
+	// SetIfNotExists inserts your row only if no row with the same key exists already.
+	// If it does, running the Op returns a NotAppliedError holding the stored values
+	SetIfNotExists(rowStruct interface{}) Op
+	// UpdateIf does a partial update which is only applied if all conditions hold
+	UpdateIf(partitionKey interface{}, conditions []Relation, valuesToUpdate map[string]interface{}) Op
+	// DeleteIfExists deletes the row, returning a NotAppliedError if it doesn't exist
+	DeleteIfExists(partitionKey interface{}) Op
 	WithOptions(Options) MapTable
 	Table() Table
 	TableChanger
@@ -101,7 +147,17 @@ type MultimapTable interface {
 	// List populates the provided pointer to a slice with the results matching the keys provided.
 	// To disable the limit, set limit to 0
 	List(partitionKey, clusteringKey interface{}, limit int, pointerToASlice interface{}) Op
+	// ListPage populates the provided pointer to a slice with a single page of the partition. See
+	// Filter.ReadPage for how to use pageState and nextPageState to paginate
+	ListPage(partitionKey interface{}, pageSize int, pageState []byte, pointerToASlice interface{}, nextPageState *[]byte) Op
 	Read(partitionKey, clusteringKey, pointer interface{}) Op
+	// SetIfNotExists inserts your row only if no row with the same keys exists already.
+	// If it does, running the Op returns a NotAppliedError holding the stored values
+	SetIfNotExists(rowStruct interface{}) Op
+	// UpdateIf does a partial update which is only applied if all conditions hold
+	UpdateIf(value, id interface{}, conditions []Relation, valuesToUpdate map[string]interface{}) Op
+	// DeleteIfExists deletes the row, returning a NotAppliedError if it doesn't exist
+	DeleteIfExists(value, id interface{}) Op
 	WithOptions(Options) MultimapTable
 	Table() Table
 	TableChanger
@@ -117,8 +173,18 @@ type MultimapMkTable interface {
 	// List populates the provided pointer to a slice with the results matching the keys provided.
 	// To disable the limit, set limit to 0
 	List(v, startId map[string]interface{}, limit int, pointerToASlice interface{}) Op
+	// ListPage populates the provided pointer to a slice with a single page of the partition. See
+	// Filter.ReadPage for how to use pageState and nextPageState to paginate
+	ListPage(v map[string]interface{}, pageSize int, pageState []byte, pointerToASlice interface{}, nextPageState *[]byte) Op
 	Read(v, id map[string]interface{}, pointer interface{}) Op
 	MultiRead(v, id map[string]interface{}, pointerToASlice interface{}) Op
+	// SetIfNotExists inserts your row only if no row with the same keys exists already.
+	// If it does, running the Op returns a NotAppliedError holding the stored values
+	SetIfNotExists(rowStruct interface{}) Op
+	// UpdateIf does a partial update which is only applied if all conditions hold
+	UpdateIf(v, id map[string]interface{}, conditions []Relation, valuesToUpdate map[string]interface{}) Op
+	// DeleteIfExists deletes the row, returning a NotAppliedError if it doesn't exist
+	DeleteIfExists(v, id map[string]interface{}) Op
 	WithOptions(Options) MultimapMkTable
 	Table() Table
 	TableChanger
@@ -138,7 +204,17 @@ type TimeSeriesTable interface {
 	Delete(timeStamp time.Time, id interface{}) Op
 	Read(timeStamp time.Time, id, pointer interface{}) Op
 	List(start, end time.Time, pointerToASlice interface{}) Op
+	// ListIter returns an iterator over the same results as List, decoding them one row at a time.
+	// Use WithOptions to pass in a context which stops the iteration
+	ListIter(start, end time.Time) Iterator
 	Buckets(start time.Time) Buckets
+	// SetIfNotExists inserts your row only if no row with the same keys exists already.
+	// If it does, running the Op returns a NotAppliedError holding the stored values
+	SetIfNotExists(rowStruct interface{}) Op
+	// UpdateIf does a partial update which is only applied if all conditions hold
+	UpdateIf(timeStamp time.Time, id interface{}, conditions []Relation, valuesToUpdate map[string]interface{}) Op
+	// DeleteIfExists deletes the row, returning a NotAppliedError if it doesn't exist
+	DeleteIfExists(timeStamp time.Time, id interface{}) Op
 	WithOptions(Options) TimeSeriesTable
 	Table() Table
 	TableChanger
@@ -158,7 +234,17 @@ type MultiTimeSeriesTable interface {
 	Delete(v interface{}, timeStamp time.Time, id interface{}) Op
 	Read(v interface{}, timeStamp time.Time, id, pointer interface{}) Op
 	List(v interface{}, start, end time.Time, pointerToASlice interface{}) Op
+	// ListIter returns an iterator over the same results as List, decoding them one row at a time.
+	// Use WithOptions to pass in a context which stops the iteration
+	ListIter(v interface{}, start, end time.Time) Iterator
 	Buckets(v interface{}, start time.Time) Buckets
+	// SetIfNotExists inserts your row only if no row with the same keys exists already.
+	// If it does, running the Op returns a NotAppliedError holding the stored values
+	SetIfNotExists(rowStruct interface{}) Op
+	// UpdateIf does a partial update which is only applied if all conditions hold
+	UpdateIf(v interface{}, timeStamp time.Time, id interface{}, conditions []Relation, valuesToUpdate map[string]interface{}) Op
+	// DeleteIfExists deletes the row, returning a NotAppliedError if it doesn't exist
+	DeleteIfExists(v interface{}, timeStamp time.Time, id interface{}) Op
 	WithOptions(Options) MultiTimeSeriesTable
 	Table() Table
 	TableChanger
@@ -175,12 +261,41 @@ type MultiKeyTimeSeriesTable interface {
 	Delete(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}) Op
 	Read(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}, pointer interface{}) Op
 	List(v map[string]interface{}, start, end time.Time, pointerToASlice interface{}) Op
+	// ListIter returns an iterator over the same results as List, decoding them one row at a time.
+	// Use WithOptions to pass in a context which stops the iteration
+	ListIter(v map[string]interface{}, start, end time.Time) Iterator
 	Buckets(v map[string]interface{}, start time.Time) Buckets
+	// SetIfNotExists inserts your row only if no row with the same keys exists already.
+	// If it does, running the Op returns a NotAppliedError holding the stored values
+	SetIfNotExists(rowStruct interface{}) Op
+	// UpdateIf does a partial update which is only applied if all conditions hold
+	UpdateIf(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}, conditions []Relation, valuesToUpdate map[string]interface{}) Op
+	// DeleteIfExists deletes the row, returning a NotAppliedError if it doesn't exist
+	DeleteIfExists(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}) Op
 	WithOptions(Options) MultiKeyTimeSeriesTable
 	Table() Table
 	TableChanger
 }
 
+// CounterTable is a recipe for tables of counters. Keys passed in must hold a value for every
+// partition and clustering key of the table.
+type CounterTable interface {
+	// Increment adds delta to the counter field of the row, creating the row if it doesn't exist
+	Increment(keys map[string]interface{}, field string, delta int) Op
+	// Decrement subtracts delta from the counter field of the row
+	Decrement(keys map[string]interface{}, field string, delta int) Op
+	// Get reads the counters of a single row
+	Get(keys map[string]interface{}, pointer interface{}) Op
+	// List reads the counters of every row in the partition. Only partition keys should be passed in
+	List(partitionKeys map[string]interface{}, pointerToASlice interface{}) Op
+	// Reset deletes the counters of the row. Be aware that Cassandra doesn't reliably support
+	// incrementing a counter again soon after it has been deleted
+	Reset(keys map[string]interface{}) Op
+	WithOptions(Options) CounterTable
+	Table() Table
+	TableChanger
+}
+
 type FlakeSeriesTable interface {
 	// Set Inserts, or Replaces your row with the supplied struct. Be aware that what is not in your struct
 	// will be deleted. To only overwrite some of the fields, Update()
@@ -189,10 +304,20 @@ type FlakeSeriesTable interface {
 	Delete(id string) Op
 	Read(id string, pointer interface{}) Op
 	List(start, end time.Time, pointerToASlice interface{}) Op
+	// ListIter returns an iterator over the same results as List, decoding them one row at a time.
+	// Use WithOptions to pass in a context which stops the iteration
+	ListIter(start, end time.Time) Iterator
 	Buckets(start time.Time) Buckets
 	// ListSince queries the flakeSeries for the items after the specified ID but within the time window,
 	// if the time window is zero then it lists up until 5 minutes in the future
 	ListSince(id string, window time.Duration, pointerToASlice interface{}) Op
+	// SetIfNotExists inserts your row only if no row with the same keys exists already.
+	// If it does, running the Op returns a NotAppliedError holding the stored values
+	SetIfNotExists(rowStruct interface{}) Op
+	// UpdateIf does a partial update which is only applied if all conditions hold
+	UpdateIf(id string, conditions []Relation, valuesToUpdate map[string]interface{}) Op
+	// DeleteIfExists deletes the row, returning a NotAppliedError if it doesn't exist
+	DeleteIfExists(id string) Op
 	WithOptions(Options) FlakeSeriesTable
 	Table() Table
 	TableChanger
@@ -206,10 +331,20 @@ type MultiFlakeSeriesTable interface {
 	Delete(v interface{}, id string) Op
 	Read(v interface{}, id string, pointer interface{}) Op
 	List(v interface{}, start, end time.Time, pointerToASlice interface{}) Op
+	// ListIter returns an iterator over the same results as List, decoding them one row at a time.
+	// Use WithOptions to pass in a context which stops the iteration
+	ListIter(v interface{}, start, end time.Time) Iterator
 	Buckets(v interface{}, start time.Time) Buckets
 	// ListSince queries the flakeSeries for the items after the specified ID but within the time window,
 	// if the time window is zero then it lists up until 5 minutes in the future
 	ListSince(v interface{}, id string, window time.Duration, pointerToASlice interface{}) Op
+	// SetIfNotExists inserts your row only if no row with the same keys exists already.
+	// If it does, running the Op returns a NotAppliedError holding the stored values
+	SetIfNotExists(rowStruct interface{}) Op
+	// UpdateIf does a partial update which is only applied if all conditions hold
+	UpdateIf(v interface{}, id string, conditions []Relation, valuesToUpdate map[string]interface{}) Op
+	// DeleteIfExists deletes the row, returning a NotAppliedError if it doesn't exist
+	DeleteIfExists(v interface{}, id string) Op
 	WithOptions(Options) MultiFlakeSeriesTable
 	Table() Table
 	TableChanger
@@ -226,10 +361,34 @@ type Filter interface {
 	Update(valuesToUpdate map[string]interface{}) Op // Probably this is danger zone (can't be implemented efficiently) on a selectuinb with more than 1 document
 	// Delete all rows matching the filter.
 	Delete() Op
+	// DeleteColumns deletes the given columns of the rows matching the filter, rather than the
+	// whole rows. Primary key columns can't be deleted.
+	DeleteColumns(fields ...string) Op
+	// DeleteElements deletes elements of collection columns of the rows matching the filter, given
+	// by MapDeleteKey and ListDeleteAtIndex modifiers keyed by the column.
+	DeleteElements(elements map[string]Modifier) Op
+	// UpdateIf does a partial update which is only applied if all conditions hold (a lightweight
+	// transaction). If they don't, running the Op returns a NotAppliedError holding the stored values.
+	// The filter must match a single row.
+	UpdateIf(conditions []Relation, valuesToUpdate map[string]interface{}) Op
+	// DeleteIf deletes the row matching the filter only if all conditions hold. If they don't,
+	// running the Op returns a NotAppliedError holding the stored values.
+	DeleteIf(conditions ...Relation) Op
+	// DeleteIfExists deletes the row matching the filter, returning a NotAppliedError if it doesn't exist.
+	DeleteIfExists() Op
 	// Reads all results. Make sure you pass in a pointer to a slice.
 	Read(pointerToASlice interface{}) Op
 	// ReadOne reads a single result. Make sure you pass in a pointer.
 	ReadOne(pointer interface{}) Op
+	// ReadPage reads a single page of at most pageSize results, starting at the position described by
+	// pageState (nil for the first page). Once run, nextPageState holds the opaque cursor to pass in to
+	// read the following page, it is set to nil when there are no more results.
+	ReadPage(pageSize int, pageState []byte, pointerToASlice interface{}, nextPageState *[]byte) Op
+	// Iter returns an iterator which decodes the results one row at a time, rather than holding
+	// them all in memory at once
+	Iter() Iterator
+	// IterWithContext is the same as Iter, but iteration stops once the context is done
+	IterWithContext(ctx context.Context) Iterator
 	// Table on which this filter operates.
 	Table() Table
 	// Relations which make up this filter. These should not be modified.
@@ -241,6 +400,9 @@ type Keys struct {
 	PartitionKeys     []string
 	ClusteringColumns []string
 	Compound          bool //indicates if the partitions keys are gereated as compound key when no clustering columns are set
+	// StaticColumns are shared by all the rows of a partition. Fields can also be made static with the
+	// static option of their tag, eg. `cql:"owner,static"`
+	StaticColumns []string
 }
 
 // Op is returned by both read and write methods, you have to run them explicitly to take effect.
@@ -260,10 +422,19 @@ type Op interface {
 	// This comes at a performance cost
 	RunLoggedBatchWithContext(context.Context) error
 
+	// RunBatchWithContext runs the operation in a batch of the given type. Unlogged batches must only write
+	// to a single partition
+	RunBatchWithContext(ctx context.Context, batchType BatchType) error
+
 	// Deprecated: The name "RunAtomically" is a misnomer, and "RunLoggedBatchWithContext" should be used instead
 	RunAtomically() error
 	// Deprecated: The name "RunAtomically" is a misnomer, and "RunLoggedBatchWithContext" should be used instead
 	RunAtomicallyWithContext(context.Context) error
+	// RunConcurrently runs the operations this Op is made of in parallel, with at most maxParallel of them
+	// running at once (or all of them if maxParallel is less than 1). They should be independent of each
+	// other, as they're run in no particular order. The first error cancels the context of the operations
+	// still running and the remaining ones aren't run, unless Options.CollectErrors is set.
+	RunConcurrently(ctx context.Context, maxParallel int) error
 	// Add an other Op to this one.
 	Add(...Op) Op
 	// WithOptions lets you specify `Op` level `Options`.
@@ -281,6 +452,11 @@ type Op interface {
 	// Preflight performs any pre-execution validation that confirms the op considers itself "valid".
 	// NOTE: Run() and RunLoggedBatch() should call this method before execution, and abort if any errors are returned.
 	Preflight() error
+	// PreflightBatch performs the validation of Preflight, and checks the op can be run in a batch of the
+	// given type. Unlogged batches should only write to a single partition, counter batches can only
+	// contain counter updates, and no batch can contain conditional writes. RunBatchWithContext calls
+	// this method before execution.
+	PreflightBatch(batchType BatchType) error
 	// GenerateStatement generates the statement to perform the operation
 	GenerateStatement() Statement
 	// QueryExecutor returns the QueryExecutor
@@ -313,8 +489,30 @@ type Table interface {
 	// Set Inserts, or Replaces your row with the supplied struct. Be aware that what is not in your struct
 	// will be deleted. To only overwrite some of the fields, use Query.Update.
 	Set(rowStruct interface{}) Op
+	// SetIfNotExists inserts your row only if no row with the same primary key exists already
+	// (a lightweight transaction). If it does, running the Op returns a NotAppliedError holding
+	// the stored values.
+	SetIfNotExists(rowStruct interface{}) Op
 	// Where accepts a bunch of realtions and returns a filter. See the documentation for Relation and Filter to understand what that means.
 	Where(relations ...Relation) Filter // Because we provide selections
+	// Scan reads every row of the table by splitting the token ring into ranges, and passes each row to
+	// the callback as a pointer to a new instance of the table's struct. With a Concurrency above 1 the
+	// callback is called from multiple goroutines at once. The first error returned by the callback or
+	// a query stops the scan and is returned.
+	Scan(ctx context.Context, opts ScanOptions, callback func(row interface{}) error) error
+	// CreateTypeStatements returns the CQL queries which create the user-defined types of the
+	// table's fields. Create and CreateIfNotExist run them before creating the table
+	CreateTypeStatements() ([]Statement, error)
+	// CreateIndexStatements returns the CQL queries which create the indexes set in the table's
+	// Options. Create and CreateIfNotExist run them after creating the table
+	CreateIndexStatements() ([]Statement, error)
+	// SchemaDiff compares the table in C* (as read from system_schema) with the schema CreateStatement
+	// generates for it, eg. to find fields which have been added to the row struct since it was created
+	SchemaDiff(ctx context.Context) (SchemaDiff, error)
+	// Migrate alters the table in C* to match the schema CreateStatement generates for it, creating
+	// the table if it doesn't exist. Changes to the type or key of a column can't be migrated and
+	// are returned as an error, before anything is altered
+	Migrate(ctx context.Context, opts MigrateOptions) error
 	// Name returns the underlying table name, as stored in C*
 	WithOptions(Options) Table
 	TableChanger
@@ -352,6 +550,29 @@ type Scannable interface {
 	Err() error
 }
 
+// Iterator decodes the results of a read one row at a time. Always Close an iterator once you are
+// done with it, it is closed automatically when Next returns false.
+//
+//	iter := table.Where(Eq("Id", id)).Iter()
+//	defer iter.Close()
+//	var row Row
+//	for iter.Next(&row) {
+//		...
+//	}
+//	if err := iter.Err(); err != nil {
+//		...
+//	}
+type Iterator interface {
+	// Next decodes the next row into the struct pointed to by pointer. It returns false when there
+	// are no more rows or an error occurred, in which case Err returns the error
+	Next(pointer interface{}) bool
+	// Err returns the error which stopped the iteration, if any
+	Err() error
+	// Close releases the resources held by the iterator and returns the error which stopped
+	// the iteration, if any
+	Close() error
+}
+
 // A Scanner scans row(s) from a GoCQL iterator into a result object.
 type Scanner interface {
 	// ScanIter takes in a Scannable iterator found in GoCQL and scans until
@@ -377,6 +598,19 @@ type QueryExecutor interface {
 	ExecuteAtomically(stmt []Statement) error
 	// ExecuteAtomically executes multiple DML queries with a logged batch, and takes options
 	ExecuteAtomicallyWithOptions(opts Options, stmts []Statement) error
+	// ExecuteBatchWithOptions executes multiple DML queries with a batch of the given type, and takes options
+	ExecuteBatchWithOptions(opts Options, batchType BatchType, stmts []Statement) error
+	// QueryPageWithOptions executes a query returning a single page of at most pageSize results,
+	// starting at pageState. It returns the page state to fetch the next page with, which is empty
+	// when there are no more results
+	QueryPageWithOptions(opts Options, stmt Statement, scanner Scanner, pageSize int, pageState []byte) ([]byte, error)
+	// QueryIterWithOptions executes a query and returns an iterator over the results, which are
+	// fetched as they are iterated over. Calling Err on the returned Scannable releases it
+	QueryIterWithOptions(opts Options, stmt Statement) Scannable
+	// ExecuteCASWithOptions executes a conditional DML query (lightweight transaction). It
+	// returns whether the query was applied and, if not, fills current with the values
+	// currently stored
+	ExecuteCASWithOptions(opts Options, stmt Statement, current map[string]interface{}) (bool, error)
 }
 
 type Counter int
@@ -0,0 +1,81 @@
+package gocassa
+
+import (
+	"context"
+)
+
+// iterator implements the Iterator interface, decoding rows from a Scannable
+// one at a time as they are requested
+type iterator struct {
+	ctx    context.Context
+	fields []string
+	iter   Scannable // nil once the underlying iterator is released
+	err    error
+	rows   int
+	// done is called once the underlying iterator is released, with the
+	// number of rows read and the error of the iterator
+	done func(rows int, err error)
+}
+
+func newIterator(ctx context.Context, stmt SelectStatement, iter Scannable) *iterator {
+	return &iterator{
+		ctx:    ctx,
+		fields: stmt.Fields(),
+		iter:   iter,
+	}
+}
+
+// errIterator returns an iterator which yields no rows and returns err
+func errIterator(err error) *iterator {
+	return &iterator{err: err}
+}
+
+func (i *iterator) Next(pointer interface{}) bool {
+	if i.iter == nil {
+		return false
+	}
+
+	if i.ctx != nil {
+		if err := i.ctx.Err(); err != nil {
+			i.release(err)
+			return false
+		}
+	}
+
+	if !i.iter.Next() {
+		i.release(nil)
+		return false
+	}
+
+	if err := scanRow(i.iter, i.fields, pointer); err != nil {
+		i.release(err)
+		return false
+	}
+	i.rows++
+	return true
+}
+
+func (i *iterator) Err() error {
+	return i.err
+}
+
+func (i *iterator) Close() error {
+	if i.iter != nil {
+		i.release(nil)
+	}
+	return i.err
+}
+
+// release releases the underlying iterator (which must only happen once) and
+// records err, falling back to the error of the underlying iterator
+func (i *iterator) release(err error) {
+	iterErr := i.iter.Err()
+	i.iter = nil
+	if err == nil {
+		err = iterErr
+	}
+	i.err = err
+	if i.done != nil {
+		i.done(i.rows, err)
+	}
+}
@@ -2,6 +2,7 @@ package gocassa
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -15,11 +16,14 @@ type k struct {
 	name         string
 	debugMode    bool
 	tableFactory tableFactory
+	tracer       Tracer
+	logger       Logger
+	logOptions   LogOptions
 }
 
 // Connect to a certain keyspace directly. Same as using Connect().KeySpace(keySpaceName)
-func ConnectToKeySpace(keySpace string, nodeIps []string, username, password string) (KeySpace, error) {
-	c, err := Connect(nodeIps, username, password)
+func ConnectToKeySpace(keySpace string, nodeIps []string, username, password string, opts ...ConnectionOption) (KeySpace, error) {
+	c, err := Connect(nodeIps, username, password, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -111,7 +115,7 @@ func (k *k) TimeSeriesTable(name, timeField, idField string, bucketSize time.Dur
 		t: k.NewTable(fmt.Sprintf("%s_timeSeries_%s_%s_%s", name, timeField, idField, bucketSize), row, m, Keys{
 			PartitionKeys:     []string{bucketFieldName},
 			ClusteringColumns: []string{timeField, idField},
-		}),
+		}).WithOptions(timeSeriesOptions(bucketSize)),
 		timeField:  timeField,
 		idField:    idField,
 		bucketSize: bucketSize,
@@ -128,7 +132,7 @@ func (k *k) MultiTimeSeriesTable(name, indexField, timeField, idField string, bu
 		t: k.NewTable(fmt.Sprintf("%s_multiTimeSeries_%s_%s_%s_%s", name, indexField, timeField, idField, bucketSize.String()), row, m, Keys{
 			PartitionKeys:     []string{indexField, bucketFieldName},
 			ClusteringColumns: []string{timeField, idField},
-		}),
+		}).WithOptions(timeSeriesOptions(bucketSize)),
 		indexField: indexField,
 		timeField:  timeField,
 		idField:    idField,
@@ -152,7 +156,7 @@ func (k *k) MultiKeyTimeSeriesTable(name string, indexFields []string, timeField
 		t: k.NewTable(fmt.Sprintf("%s_multiKeyTimeSeries_%s_%s", name, timeField, bucketSize.String()), row, m, Keys{
 			PartitionKeys:     partitionKeys,
 			ClusteringColumns: clusteringColumns,
-		}),
+		}).WithOptions(timeSeriesOptions(bucketSize)),
 		indexFields: indexFields,
 		timeField:   timeField,
 		idFields:    idFields,
@@ -171,7 +175,7 @@ func (k *k) FlakeSeriesTable(name, idField string, bucketSize time.Duration, row
 		t: k.NewTable(fmt.Sprintf("%s_flakeSeries_%s_%s", name, idField, bucketSize.String()), row, m, Keys{
 			PartitionKeys:     []string{bucketFieldName},
 			ClusteringColumns: []string{flakeTimestampFieldName, idField},
-		}),
+		}).WithOptions(timeSeriesOptions(bucketSize)),
 		idField:    idField,
 		bucketSize: bucketSize,
 	}
@@ -188,13 +192,79 @@ func (k *k) MultiFlakeSeriesTable(name, indexField, idField string, bucketSize t
 		t: k.NewTable(fmt.Sprintf("%s_multiflakeSeries_%s_%s_%s", name, indexField, idField, bucketSize.String()), row, m, Keys{
 			PartitionKeys:     []string{indexField, bucketFieldName},
 			ClusteringColumns: []string{flakeTimestampFieldName, idField},
-		}),
+		}).WithOptions(timeSeriesOptions(bucketSize)),
 		idField:    idField,
 		bucketSize: bucketSize,
 		indexField: indexField,
 	}
 }
 
+// timeSeriesOptions are the default options of time series tables. Rows are
+// written in time order, one bucket at a time, so each bucket is compacted
+// into its own SSTables
+func timeSeriesOptions(bucketSize time.Duration) Options {
+	compaction := TimeWindowCompaction(bucketSize)
+	return Options{Properties: TableProperties{Compaction: &compaction}}
+}
+
+func (k *k) CounterTable(name string, partitionKeys, clusteringKeys []string, row interface{}) CounterTable {
+	m, ok := toMap(row)
+	if !ok {
+		panic("Unrecognized row type")
+	}
+
+	keys := map[string]struct{}{}
+	for _, key := range append(append([]string{}, partitionKeys...), clusteringKeys...) {
+		if _, ok := m[key]; !ok {
+			panic(fmt.Sprintf("Key %s is not a field of the row", key))
+		}
+		keys[key] = struct{}{}
+	}
+
+	counterFields := map[string]struct{}{}
+	for field, value := range m {
+		if _, ok := keys[field]; ok {
+			continue
+		}
+		if _, ok := value.(Counter); !ok {
+			panic(fmt.Sprintf("Field %s of a counter table must be a Counter, not %T", field, value))
+		}
+		counterFields[field] = struct{}{}
+	}
+	if len(counterFields) == 0 {
+		panic("A counter table must have at least one Counter field")
+	}
+
+	return &counterT{
+		t: k.NewTable(fmt.Sprintf("%s_counter", name), row, m, Keys{
+			PartitionKeys:     partitionKeys,
+			ClusteringColumns: clusteringKeys,
+		}),
+		partitionKeys:  partitionKeys,
+		clusteringKeys: clusteringKeys,
+		counterFields:  counterFields,
+	}
+}
+
+func (k *k) MaterializedView(base Table, name string, keys Keys) Table {
+	var baseTable t
+	switch b := base.(type) {
+	case t:
+		baseTable = b
+	case *t:
+		baseTable = *b
+	default:
+		panic("Unrecognized base table")
+	}
+
+	return &t{
+		keySpace: k,
+		info:     newTableInfo(k.name, name, keys, baseTable.info.marshalSource, baseTable.info.fieldSource),
+		options:  Options{},
+		base:     &baseTable,
+	}
+}
+
 type tableInfoMarshal struct {
 	TableName string `cql:"table_name"`
 }
@@ -237,6 +307,57 @@ func (k *k) Exists(cf string) (bool, error) {
 	return false, nil
 }
 
+type keySpaceInfoMarshal struct {
+	DurableWrites bool              `cql:"durable_writes"`
+	Replication   map[string]string `cql:"replication"`
+}
+
+// Replication returns the replication of the keyspace
+func (k *k) Replication() (KeySpaceOptions, error) {
+	if k.qe == nil {
+		return KeySpaceOptions{}, fmt.Errorf("no query executor configured")
+	}
+
+	res := []keySpaceInfoMarshal{}
+	stmt := SelectStatement{
+		keyspace: "system_schema",
+		table:    "keyspaces",
+		fields:   []string{"durable_writes", "replication"},
+		where:    []Relation{Eq("keyspace_name", k.name)},
+	}
+	if err := k.qe.Query(stmt, NewScanner(stmt, &res)); err != nil {
+		return KeySpaceOptions{}, err
+	}
+	if len(res) == 0 {
+		return KeySpaceOptions{}, fmt.Errorf("keyspace %s does not exist", k.name)
+	}
+
+	// The class is fully qualified, eg. org.apache.cassandra.locator.SimpleStrategy
+	class := res[0].Replication["class"]
+	opts := KeySpaceOptions{
+		Strategy:      ReplicationStrategy(class[strings.LastIndex(class, ".")+1:]),
+		DurableWrites: &res[0].DurableWrites,
+	}
+	for key, value := range res[0].Replication {
+		if key == "class" {
+			continue
+		}
+		rf, err := strconv.Atoi(value)
+		if err != nil {
+			return KeySpaceOptions{}, fmt.Errorf("invalid replication factor %s of %s: %v", value, key, err)
+		}
+		if opts.Strategy == SimpleStrategy && key == "replication_factor" {
+			opts.ReplicationFactor = rf
+			continue
+		}
+		if opts.DatacenterRF == nil {
+			opts.DatacenterRF = map[string]int{}
+		}
+		opts.DatacenterRF[key] = rf
+	}
+	return opts, nil
+}
+
 func (k *k) DropTable(cf string) error {
 	query := fmt.Sprintf("DROP TABLE IF EXISTS %s.%s", k.name, cf)
 	stmt := cqlStatement{query: query}
@@ -246,3 +367,7 @@ func (k *k) DropTable(cf string) error {
 func (k *k) Name() string {
 	return k.name
 }
+
+func (k *k) QueryExecutor() QueryExecutor {
+	return k.qe
+}
@@ -0,0 +1,267 @@
+package gocassa
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel is the level of a log entry, its values match those of log/slog
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = -4
+	LogLevelInfo  LogLevel = 0
+	LogLevelWarn  LogLevel = 4
+	LogLevelError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// LogAttr is a key-value pair of a log entry
+type LogAttr struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger logs the queries run through a Connection. It mirrors the LogAttrs
+// method of a log/slog Logger, so it can be implemented by converting the
+// level and attributes and calling it
+type Logger interface {
+	LogAttrs(ctx context.Context, level LogLevel, msg string, attrs ...LogAttr)
+}
+
+// LogOptions configures which queries are logged, and how
+type LogOptions struct {
+	// SlowQueryThreshold only logs queries taking at least this long, at warn
+	// level. If it's zero every query is logged at debug level. Every query
+	// of a keyspace in DebugMode is logged regardless
+	SlowQueryThreshold time.Duration
+	// LogSensitive logs the values of fields tagged sensitive
+	// (eg. `cql:"email,sensitive"`), which are redacted otherwise
+	LogSensitive bool
+}
+
+// redactedValue replaces the values of sensitive fields in logs
+const redactedValue = "[redacted]"
+
+// WithLogger logs the ops run with the Connection, with the statement they
+// execute and its values, how long they took and the number of rows read.
+// Iterators and scans are logged once they're done. KeySpace.SetLogger sets
+// the logger of a single keyspace instead
+func WithLogger(logger Logger, opts LogOptions) ConnectionOption {
+	return func(c *connection) {
+		c.logger = logger
+		c.logOptions = opts
+	}
+}
+
+func (k *k) SetLogger(logger Logger, opts LogOptions) {
+	k.logger = logger
+	k.logOptions = opts
+}
+
+// NewWriterLogger returns a Logger which writes each entry to w as a line of
+// text, such as `DEBUG gocassa query keyspace=app table=users ...`
+func NewWriterLogger(w io.Writer) Logger {
+	return &writerLogger{w: w}
+}
+
+type writerLogger struct {
+	mtx sync.Mutex
+	w   io.Writer
+}
+
+func (l *writerLogger) LogAttrs(ctx context.Context, level LogLevel, msg string, attrs ...LogAttr) {
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for _, attr := range attrs {
+		fmt.Fprintf(&b, " %s=%v", attr.Key, attr.Value)
+	}
+	b.WriteString("\n")
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	io.WriteString(l.w, b.String())
+}
+
+// queryLogger returns the logger queries are logged to, DebugMode logs to
+// stdout if the keyspace doesn't have a logger
+func (k *k) queryLogger() Logger {
+	if k.logger == nil && k.debugMode {
+		return stdoutLogger
+	}
+	return k.logger
+}
+
+// logQuery logs a query which took duration to run, if it should be logged
+func (k *k) logQuery(ctx context.Context, duration time.Duration, err error, attrs ...LogAttr) {
+	logger := k.queryLogger()
+	if logger == nil {
+		return
+	}
+
+	level, msg := LogLevelDebug, "gocassa query"
+	if threshold := k.logOptions.SlowQueryThreshold; threshold > 0 && duration >= threshold {
+		level, msg = LogLevelWarn, "gocassa slow query"
+	} else if threshold > 0 && !k.debugMode {
+		return
+	}
+
+	attrs = append(attrs, LogAttr{Key: "duration", Value: duration})
+	if err != nil {
+		attrs = append(attrs, LogAttr{Key: "error", Value: err})
+	}
+	logger.LogAttrs(spanContext(ctx), level, msg, attrs...)
+}
+
+// runLogged runs the op, logging it if its keyspace has a logger
+func (o *singleOp) runLogged() (int, error) {
+	ks := o.f.t.keySpace
+	if ks.queryLogger() == nil {
+		return o.run()
+	}
+
+	start := time.Now()
+	rows, err := o.run()
+	attrs := []LogAttr{
+		{Key: "keyspace", Value: ks.name},
+		{Key: "table", Value: o.f.t.Name()},
+		{Key: "op", Value: string(o.queryType())},
+		{Key: "statement", Value: o.GenerateStatement().Query()},
+		{Key: "values", Value: o.logValues()},
+	}
+	if !o.isWrite() {
+		attrs = append(attrs, LogAttr{Key: "rows", Value: rows})
+	}
+	ks.logQuery(o.options.Context, time.Since(start), err, attrs...)
+	return rows, err
+}
+
+// logIter returns a callback logging the read of an iterator once it's done,
+// or nil if the keyspace doesn't have a logger. The duration logged is the
+// time taken to read every row, not only the time taken to run the query
+func (o *singleOp) logIter(stmt Statement) func(rows int, err error) {
+	ks := o.f.t.keySpace
+	if ks.queryLogger() == nil {
+		return nil
+	}
+
+	start := time.Now()
+	return func(rows int, err error) {
+		ks.logQuery(o.options.Context, time.Since(start), err,
+			LogAttr{Key: "keyspace", Value: ks.name},
+			LogAttr{Key: "table", Value: o.f.t.Name()},
+			LogAttr{Key: "op", Value: "iter"},
+			LogAttr{Key: "statement", Value: stmt.Query()},
+			LogAttr{Key: "values", Value: o.logValues()},
+			LogAttr{Key: "rows", Value: rows},
+		)
+	}
+}
+
+// logValues returns the values the op writes and filters by, keyed by field
+// name, with the values of sensitive fields redacted
+func (o *singleOp) logValues() map[string]interface{} {
+	values := make(map[string]interface{}, len(o.m)+len(o.f.rs))
+	for field, value := range o.m {
+		values[field] = value
+	}
+	for _, rel := range o.f.rs {
+		if rel.isTuple() {
+			// Log the terms of each field of the tuple, so they're redacted
+			// like any other
+			for i, field := range rel.fields {
+				fieldTerms := make([]interface{}, 0, len(rel.terms))
+				for _, tuple := range rel.terms {
+					if tuple, ok := tuple.([]interface{}); ok && i < len(tuple) {
+						fieldTerms = append(fieldTerms, tuple[i])
+					}
+				}
+				if rel.Comparator() != CmpIn && len(fieldTerms) == 1 {
+					values[field] = fieldTerms[0]
+				} else {
+					values[field] = fieldTerms
+				}
+			}
+			continue
+		}
+		if terms := rel.Terms(); rel.Comparator() == CmpIn || len(terms) != 1 {
+			values[rel.Field()] = terms
+		} else {
+			values[rel.Field()] = terms[0]
+		}
+	}
+
+	if !o.f.t.keySpace.logOptions.LogSensitive {
+		for field := range values {
+			if o.f.t.info.fieldInfo[strings.ToLower(field)].Sensitive() {
+				values[field] = redactedValue
+			}
+		}
+	}
+	return values
+}
+
+// logScan logs a scan of the table which read rows in total
+func (t t) logScan(ctx context.Context, start time.Time, rows int64, err error) {
+	ks := t.keySpace
+	if ks.queryLogger() == nil {
+		return
+	}
+	ks.logQuery(ctx, time.Since(start), err,
+		LogAttr{Key: "keyspace", Value: ks.name},
+		LogAttr{Key: "table", Value: t.Name()},
+		LogAttr{Key: "op", Value: "scan"},
+		LogAttr{Key: "rows", Value: rows},
+	)
+}
+
+// logBatch logs a batch of statements
+func (mo multiOp) logBatch(ctx context.Context, start time.Time, batchType BatchType, stmts []Statement, err error) {
+	ks := mo.keySpace()
+	if ks == nil || ks.queryLogger() == nil {
+		return
+	}
+
+	tables := map[string]struct{}{}
+	for _, op := range mo {
+		if o, ok := op.(*singleOp); ok {
+			tables[o.f.t.Name()] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ks.logQuery(ctx, time.Since(start), err,
+		LogAttr{Key: "keyspace", Value: ks.name},
+		LogAttr{Key: "tables", Value: names},
+		LogAttr{Key: "op", Value: string(BatchQuery)},
+		LogAttr{Key: "batch", Value: batchType.String()},
+		LogAttr{Key: "statements", Value: len(stmts)},
+	)
+}
+
+var stdoutLogger = NewWriterLogger(os.Stdout)
@@ -0,0 +1,158 @@
+package gocassa
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loggedAccount struct {
+	Id    string
+	Email string `cql:"email,sensitive"`
+}
+
+type logEntry struct {
+	level LogLevel
+	msg   string
+	attrs map[string]interface{}
+}
+
+type recordingLogger struct {
+	entries []logEntry
+}
+
+func (l *recordingLogger) LogAttrs(ctx context.Context, level LogLevel, msg string, attrs ...LogAttr) {
+	entry := logEntry{level: level, msg: msg, attrs: map[string]interface{}{}}
+	for _, attr := range attrs {
+		entry.attrs[attr.Key] = attr.Value
+	}
+	l.entries = append(l.entries, entry)
+}
+
+func TestLoggerLogsQueries(t *testing.T) {
+	qe := &tracingQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	logger := &recordingLogger{}
+	tbl := NewConnection(qe, WithLogger(logger, LogOptions{})).KeySpace("app").
+		MapTable("accounts", "Id", loggedAccount{})
+
+	require.NoError(t, tbl.Set(loggedAccount{Id: "1", Email: "jane@example.com"}).Run())
+	qe.rows = []map[string]interface{}{{"Id": "1", "email": "jane@example.com"}}
+	require.NoError(t, tbl.Read("1", &loggedAccount{}).Run())
+	require.NoError(t, tbl.Set(loggedAccount{Id: "1"}).Add(tbl.Delete("2")).RunAtomically())
+
+	require.Len(t, logger.entries, 3)
+	entry := logger.entries[0]
+	assert.Equal(t, LogLevelDebug, entry.level)
+	assert.Equal(t, "gocassa query", entry.msg)
+	assert.Equal(t, "app", entry.attrs["keyspace"])
+	assert.Equal(t, "accounts_map_Id", entry.attrs["table"])
+	assert.Equal(t, "update", entry.attrs["op"])
+	assert.Equal(t, "UPDATE app.accounts_map_Id SET email = ? WHERE id = ?", entry.attrs["statement"])
+	assert.Equal(t, map[string]interface{}{"Id": "1", "email": redactedValue}, entry.attrs["values"])
+	assert.NotContains(t, entry.attrs, "rows")
+
+	entry = logger.entries[1]
+	assert.Equal(t, "read", entry.attrs["op"])
+	assert.Equal(t, 1, entry.attrs["rows"])
+
+	entry = logger.entries[2]
+	assert.Equal(t, "batch", entry.attrs["op"])
+	assert.Equal(t, 2, entry.attrs["statements"])
+	assert.Equal(t, []string{"accounts_map_Id"}, entry.attrs["tables"])
+}
+
+func TestLoggerLogsSensitiveValues(t *testing.T) {
+	qe := &tracingQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	logger := &recordingLogger{}
+	tbl := NewConnection(qe, WithLogger(logger, LogOptions{LogSensitive: true})).KeySpace("app").
+		MapTable("accounts", "Id", loggedAccount{})
+
+	require.NoError(t, tbl.Set(loggedAccount{Id: "1", Email: "jane@example.com"}).Run())
+	require.Len(t, logger.entries, 1)
+	assert.Equal(t, map[string]interface{}{"Id": "1", "email": "jane@example.com"}, logger.entries[0].attrs["values"])
+}
+
+func TestLoggerSlowQueries(t *testing.T) {
+	qe := &tracingQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	qe.rows = []map[string]interface{}{{"Id": "1"}}
+	logger := &recordingLogger{}
+	ks := NewConnection(qe, WithLogger(logger, LogOptions{SlowQueryThreshold: time.Hour})).KeySpace("app")
+	tbl := ks.MapTable("accounts", "Id", loggedAccount{})
+
+	require.NoError(t, tbl.Read("1", &loggedAccount{}).Run())
+	assert.Empty(t, logger.entries)
+
+	ks.DebugMode(true)
+	require.NoError(t, tbl.Read("1", &loggedAccount{}).Run())
+	require.Len(t, logger.entries, 1)
+	assert.Equal(t, LogLevelDebug, logger.entries[0].level)
+
+	ks = NewConnection(qe, WithLogger(logger, LogOptions{SlowQueryThreshold: time.Nanosecond})).KeySpace("app")
+	tbl = ks.MapTable("accounts", "Id", loggedAccount{})
+	require.NoError(t, tbl.Read("1", &loggedAccount{}).Run())
+	require.Len(t, logger.entries, 2)
+	assert.Equal(t, LogLevelWarn, logger.entries[1].level)
+	assert.Equal(t, "gocassa slow query", logger.entries[1].msg)
+	assert.Equal(t, "SELECT id, email FROM app.accounts_map_Id WHERE id = ?", logger.entries[1].attrs["statement"])
+	assert.Equal(t, 1, logger.entries[1].attrs["rows"])
+}
+
+// iterQE returns its canned rows for iterators too
+type iterQE struct {
+	*tracingQE
+}
+
+func (qe iterQE) QueryIterWithOptions(opts Options, stmt Statement) Scannable {
+	qe.stmt = stmt
+	return newMockIterator(qe.rows, stmt.(SelectStatement).Fields())
+}
+
+func TestLoggerLogsIterAndScan(t *testing.T) {
+	qe := iterQE{&tracingQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}}
+	qe.rows = []map[string]interface{}{{"id": "1"}, {"id": "2"}}
+	logger := &recordingLogger{}
+	ks := NewConnection(qe).KeySpace("app")
+	ks.SetLogger(logger, LogOptions{})
+	tbl := ks.MapTable("accounts", "Id", loggedAccount{})
+
+	// Iterators are logged once they're done, with the number of rows read
+	iter := tbl.Table().Where(Eq("Id", "1")).Iter()
+	for iter.Next(&loggedAccount{}) {
+	}
+	require.NoError(t, iter.Err())
+	require.Len(t, logger.entries, 1)
+	entry := logger.entries[0]
+	assert.Equal(t, "iter", entry.attrs["op"])
+	assert.Equal(t, "accounts_map_Id", entry.attrs["table"])
+	assert.Equal(t, "SELECT id, email FROM app.accounts_map_Id WHERE id = ?", entry.attrs["statement"])
+	assert.Equal(t, 2, entry.attrs["rows"])
+
+	iter = tbl.Table().Where(Eq("Id", "1")).Iter()
+	require.NoError(t, iter.Close())
+	require.Len(t, logger.entries, 2)
+	assert.Equal(t, 0, logger.entries[1].attrs["rows"])
+
+	// Scans log each token range they read, then the whole scan
+	logger.entries = nil
+	err := tbl.Table().Scan(context.Background(), ScanOptions{Splits: 2}, func(row interface{}) error {
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, logger.entries, 3)
+	entry = logger.entries[2]
+	assert.Equal(t, "scan", entry.attrs["op"])
+	assert.Equal(t, "app", entry.attrs["keyspace"])
+	assert.Equal(t, int64(4), entry.attrs["rows"])
+	assert.NotContains(t, entry.attrs, "error")
+}
+
+func TestWriterLogger(t *testing.T) {
+	var b strings.Builder
+	NewWriterLogger(&b).LogAttrs(context.Background(), LogLevelWarn, "gocassa slow query",
+		LogAttr{Key: "table", Value: "accounts"}, LogAttr{Key: "rows", Value: 3})
+	assert.Equal(t, "WARN gocassa slow query table=accounts rows=3\n", b.String())
+}
@@ -32,6 +32,23 @@ func (m *mapT) Delete(id interface{}) Op {
 		Delete()
 }
 
+func (m *mapT) SetIfNotExists(v interface{}) Op {
+	return m.Table().
+		SetIfNotExists(v)
+}
+
+func (m *mapT) UpdateIf(id interface{}, conditions []Relation, ma map[string]interface{}) Op {
+	return m.Table().
+		Where(Eq(m.idField, id)).
+		UpdateIf(conditions, ma)
+}
+
+func (m *mapT) DeleteIfExists(id interface{}) Op {
+	return m.Table().
+		Where(Eq(m.idField, id)).
+		DeleteIfExists()
+}
+
 func (m *mapT) Read(id, pointer interface{}) Op {
 	return m.Table().
 		Where(Eq(m.idField, id)).
@@ -0,0 +1,265 @@
+package gocassa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// QueryMetrics describes a query which has been executed
+type QueryMetrics struct {
+	Keyspace string
+	// Table is empty for batches spanning several tables, and for CQL which
+	// gocassa didn't generate
+	Table    string
+	Type     QueryType
+	Duration time.Duration
+	// Rows is the number of rows scanned by a read
+	Rows int
+	// BatchSize is the number of statements in a batch, and 0 for other queries
+	BatchSize int
+	// ErrorClass is empty if the query succeeded
+	ErrorClass ErrorClass
+}
+
+// MetricsCollector is called with the metrics of every query executed by the
+// QueryExecutor or mock keyspace it is given to
+type MetricsCollector interface {
+	ObserveQuery(m QueryMetrics)
+}
+
+// ErrorClass groups the errors returned by queries, so they can be counted
+type ErrorClass string
+
+const (
+	ErrorClassTimeout     ErrorClass = "timeout"
+	ErrorClassUnavailable ErrorClass = "unavailable"
+	ErrorClassCanceled    ErrorClass = "canceled"
+	ErrorClassNotApplied  ErrorClass = "not_applied"
+	ErrorClassNotFound    ErrorClass = "not_found"
+	ErrorClassOther       ErrorClass = "other"
+)
+
+// ErrorClassOf returns the class of an error returned by a query, or an empty
+// class if err is nil
+func ErrorClassOf(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	var (
+		readTimeout  *gocql.RequestErrReadTimeout
+		writeTimeout *gocql.RequestErrWriteTimeout
+		unavailable  *gocql.RequestErrUnavailable
+		notApplied   NotAppliedError
+		notFound     RowNotFoundError
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, gocql.ErrTimeoutNoResponse),
+		errors.As(err, &readTimeout), errors.As(err, &writeTimeout):
+		return ErrorClassTimeout
+	case errors.Is(err, gocql.ErrUnavailable), errors.Is(err, gocql.ErrNoConnections), errors.As(err, &unavailable):
+		return ErrorClassUnavailable
+	case errors.As(err, &notApplied):
+		return ErrorClassNotApplied
+	case errors.As(err, &notFound):
+		return ErrorClassNotFound
+	}
+	return ErrorClassOther
+}
+
+// casError returns the error a conditional write is classified by, which is a
+// NotAppliedError if it ran but wasn't applied, as the mock keyspace reports it
+func casError(applied bool, err error) error {
+	if err == nil && !applied {
+		return NotAppliedError{}
+	}
+	return err
+}
+
+// newQueryMetrics returns the metrics of executing stmts, which started at start
+func newQueryMetrics(start time.Time, rows int, err error, stmts ...Statement) QueryMetrics {
+	q := newQuery(Options{}, stmts...)
+	return QueryMetrics{
+		Keyspace:   q.Keyspace,
+		Table:      q.Table,
+		Type:       q.Type,
+		Duration:   time.Since(start),
+		Rows:       rows,
+		ErrorClass: ErrorClassOf(err),
+	}
+}
+
+// DefaultLatencyBuckets are the upper bounds of the buckets of a
+// HistogramCollector created without any
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// HistogramCollector is a MetricsCollector keeping Prometheus-style
+// histograms of the latency of queries, along with the number of rows they
+// read, for each keyspace, table, query type and error class
+type HistogramCollector struct {
+	buckets []time.Duration
+
+	mtx    sync.Mutex
+	series map[histogramLabels]*histogram
+}
+
+type histogramLabels struct {
+	keyspace   string
+	table      string
+	queryType  QueryType
+	errorClass ErrorClass
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    time.Duration
+	rows   uint64
+}
+
+// HistogramSnapshot is the state of one of the histograms of a
+// HistogramCollector
+type HistogramSnapshot struct {
+	Keyspace   string
+	Table      string
+	Type       QueryType
+	ErrorClass ErrorClass
+	// Buckets are the upper bounds of the buckets, and Counts the cumulative
+	// number of queries which took at most as long
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+	Rows    uint64
+}
+
+// NewHistogramCollector returns a HistogramCollector with the given bucket
+// upper bounds, or DefaultLatencyBuckets if there are none
+func NewHistogramCollector(buckets ...time.Duration) *HistogramCollector {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]time.Duration{}, buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+	return &HistogramCollector{
+		buckets: buckets,
+		series:  map[histogramLabels]*histogram{},
+	}
+}
+
+func (c *HistogramCollector) ObserveQuery(m QueryMetrics) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	labels := histogramLabels{keyspace: m.Keyspace, table: m.Table, queryType: m.Type, errorClass: m.ErrorClass}
+	h := c.series[labels]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.series[labels] = h
+	}
+	if i := sort.Search(len(c.buckets), func(i int) bool { return m.Duration <= c.buckets[i] }); i < len(c.buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += m.Duration
+	h.rows += uint64(m.Rows)
+}
+
+// Snapshot returns the current state of the histograms, sorted by their labels
+func (c *HistogramCollector) Snapshot() []HistogramSnapshot {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	snapshots := make([]HistogramSnapshot, 0, len(c.series))
+	for labels, h := range c.series {
+		counts := make([]uint64, len(h.counts))
+		var cumulative uint64
+		for i, count := range h.counts {
+			cumulative += count
+			counts[i] = cumulative
+		}
+		snapshots = append(snapshots, HistogramSnapshot{
+			Keyspace:   labels.keyspace,
+			Table:      labels.table,
+			Type:       labels.queryType,
+			ErrorClass: labels.errorClass,
+			Buckets:    c.buckets,
+			Counts:     counts,
+			Count:      h.count,
+			Sum:        h.sum,
+			Rows:       h.rows,
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		a, b := snapshots[i], snapshots[j]
+		if a.Keyspace != b.Keyspace {
+			return a.Keyspace < b.Keyspace
+		}
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.ErrorClass < b.ErrorClass
+	})
+	return snapshots
+}
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the histograms in the Prometheus text exposition
+// format, for serving from the metrics endpoint of the caller
+func (c *HistogramCollector) WritePrometheus(w io.Writer) error {
+	snapshots := c.Snapshot()
+	labels := make([]string, len(snapshots))
+	for i, s := range snapshots {
+		labels[i] = fmt.Sprintf(`keyspace="%s",table="%s",type="%s",error="%s"`,
+			prometheusLabelEscaper.Replace(s.Keyspace), prometheusLabelEscaper.Replace(s.Table),
+			s.Type, s.ErrorClass)
+	}
+
+	var b strings.Builder
+	b.WriteString("# HELP gocassa_query_duration_seconds Latency of the queries executed by gocassa.\n")
+	b.WriteString("# TYPE gocassa_query_duration_seconds histogram\n")
+	for i, s := range snapshots {
+		for j, bucket := range s.Buckets {
+			fmt.Fprintf(&b, "gocassa_query_duration_seconds_bucket{%s,le=\"%g\"} %d\n", labels[i], bucket.Seconds(), s.Counts[j])
+		}
+		fmt.Fprintf(&b, "gocassa_query_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels[i], s.Count)
+		fmt.Fprintf(&b, "gocassa_query_duration_seconds_sum{%s} %g\n", labels[i], s.Sum.Seconds())
+		fmt.Fprintf(&b, "gocassa_query_duration_seconds_count{%s} %d\n", labels[i], s.Count)
+	}
+	b.WriteString("# HELP gocassa_query_rows_total Rows read by the queries executed by gocassa.\n")
+	b.WriteString("# TYPE gocassa_query_rows_total counter\n")
+	for i, s := range snapshots {
+		fmt.Fprintf(&b, "gocassa_query_rows_total{%s} %d\n", labels[i], s.Rows)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,91 @@
+package gocassa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorClassOf(t *testing.T) {
+	assert.Equal(t, ErrorClass(""), ErrorClassOf(nil))
+	assert.Equal(t, ErrorClassCanceled, ErrorClassOf(context.Canceled))
+	assert.Equal(t, ErrorClassTimeout, ErrorClassOf(context.DeadlineExceeded))
+	assert.Equal(t, ErrorClassTimeout, ErrorClassOf(gocql.ErrTimeoutNoResponse))
+	assert.Equal(t, ErrorClassTimeout, ErrorClassOf(&gocql.RequestErrWriteTimeout{}))
+	assert.Equal(t, ErrorClassTimeout, ErrorClassOf(fmt.Errorf("reading: %w", &gocql.RequestErrReadTimeout{})))
+	assert.Equal(t, ErrorClassUnavailable, ErrorClassOf(&gocql.RequestErrUnavailable{}))
+	assert.Equal(t, ErrorClassUnavailable, ErrorClassOf(gocql.ErrNoConnections))
+	assert.Equal(t, ErrorClassNotApplied, ErrorClassOf(NotAppliedError{}))
+	assert.Equal(t, ErrorClassNotApplied, ErrorClassOf(casError(false, nil)))
+	assert.Equal(t, ErrorClass(""), ErrorClassOf(casError(true, nil)))
+	assert.Equal(t, ErrorClassCanceled, ErrorClassOf(casError(false, context.Canceled)))
+	assert.Equal(t, ErrorClassNotFound, ErrorClassOf(RowNotFoundError{}))
+	assert.Equal(t, ErrorClassOther, ErrorClassOf(errors.New("boom")))
+}
+
+func TestHistogramCollector(t *testing.T) {
+	c := NewHistogramCollector(10*time.Millisecond, time.Millisecond)
+	c.ObserveQuery(QueryMetrics{Keyspace: "app", Table: "users", Type: ReadQuery, Duration: 500 * time.Microsecond, Rows: 2})
+	c.ObserveQuery(QueryMetrics{Keyspace: "app", Table: "users", Type: ReadQuery, Duration: 5 * time.Millisecond, Rows: 1})
+	c.ObserveQuery(QueryMetrics{Keyspace: "app", Table: "users", Type: ReadQuery, Duration: time.Second, ErrorClass: ErrorClassTimeout})
+	c.ObserveQuery(QueryMetrics{Keyspace: "app", Table: "orders", Type: InsertQuery, Duration: time.Millisecond})
+
+	snapshots := c.Snapshot()
+	require.Len(t, snapshots, 3)
+	assert.Equal(t, HistogramSnapshot{
+		Keyspace: "app",
+		Table:    "orders",
+		Type:     InsertQuery,
+		Buckets:  []time.Duration{time.Millisecond, 10 * time.Millisecond},
+		Counts:   []uint64{1, 1},
+		Count:    1,
+		Sum:      time.Millisecond,
+	}, snapshots[0])
+	assert.Equal(t, []uint64{1, 2}, snapshots[1].Counts)
+	assert.Equal(t, uint64(2), snapshots[1].Count)
+	assert.Equal(t, uint64(3), snapshots[1].Rows)
+	assert.Equal(t, ErrorClassTimeout, snapshots[2].ErrorClass)
+	assert.Equal(t, []uint64{0, 0}, snapshots[2].Counts)
+
+	var b strings.Builder
+	require.NoError(t, c.WritePrometheus(&b))
+	assert.Contains(t, b.String(), "# TYPE gocassa_query_duration_seconds histogram\n")
+	assert.Contains(t, b.String(), `gocassa_query_duration_seconds_bucket{keyspace="app",table="users",type="read",error="",le="0.001"} 1`+"\n")
+	assert.Contains(t, b.String(), `gocassa_query_duration_seconds_bucket{keyspace="app",table="users",type="read",error="",le="+Inf"} 2`+"\n")
+	assert.Contains(t, b.String(), `gocassa_query_duration_seconds_sum{keyspace="app",table="users",type="read",error="timeout"} 1`+"\n")
+	assert.Contains(t, b.String(), `gocassa_query_rows_total{keyspace="app",table="users",type="read",error=""} 3`+"\n")
+}
+
+type recordingCollector struct {
+	queries []QueryMetrics
+}
+
+func (c *recordingCollector) ObserveQuery(m QueryMetrics) {
+	c.queries = append(c.queries, m)
+}
+
+func TestMockKeySpaceMetrics(t *testing.T) {
+	collector := &recordingCollector{}
+	tbl := NewMockKeySpaceWithMetrics(collector).MapTable("users", "Id", interceptedUser{})
+
+	require.NoError(t, tbl.Set(interceptedUser{Id: "1", Name: "Jane"}).Run())
+	require.NoError(t, tbl.Read("1", &interceptedUser{}).Run())
+	assert.IsType(t, RowNotFoundError{}, tbl.Read("2", &interceptedUser{}).Run())
+	require.NoError(t, tbl.Delete("1").Run())
+
+	require.Len(t, collector.queries, 4)
+	assert.Equal(t, "users_map_Id", collector.queries[0].Table)
+	assert.Equal(t, InsertQuery, collector.queries[0].Type)
+	assert.Equal(t, ReadQuery, collector.queries[1].Type)
+	assert.Equal(t, 1, collector.queries[1].Rows)
+	assert.Equal(t, ErrorClassNotFound, collector.queries[2].ErrorClass)
+	assert.Equal(t, DeleteQuery, collector.queries[3].Type)
+	assert.Empty(t, collector.queries[3].ErrorClass)
+}
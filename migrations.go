@@ -0,0 +1,376 @@
+package gocassa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	migrationsTableName     = "gocassa_migrations"
+	migrationsLockTableName = "gocassa_migrations_lock"
+	// migrationsPartition is the partition holding every migration of the
+	// keyspace, so they can be read in one go, and its lock
+	migrationsPartition = "migrations"
+
+	// migrationLockTTL is how long the lock is held without being refreshed,
+	// so a process which dies while migrating doesn't hold it forever. It's
+	// refreshed before each migration is applied
+	migrationLockTTL = 10 * time.Minute
+)
+
+// Migration is a versioned change to a keyspace, such as creating a table or
+// backfilling a column. Migrations are applied in order of their Version
+type Migration struct {
+	Version     int
+	Description string
+	// Tables are created if they don't exist, before the Statements are executed
+	Tables []TableChanger
+	// Statements are executed in order, before Func is called. They aren't
+	// executed against a mock keyspace, as it can't execute CQL
+	Statements []Statement
+	// Func applies changes which can't be expressed as statements. During a dry
+	// run the keyspace it is passed only records statements, and reads from it
+	// return no rows
+	Func func(ctx context.Context, ks KeySpace) error
+}
+
+// MigrationStatus describes whether a migration has been applied to a keyspace
+type MigrationStatus struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+}
+
+// MigrationLockedError is returned by Up if another process is applying
+// migrations to the keyspace
+type MigrationLockedError struct {
+	Owner    string
+	LockedAt time.Time
+}
+
+func (e MigrationLockedError) Error() string {
+	return fmt.Sprintf("migrations are locked by %s since %s", e.Owner, e.LockedAt.Format(time.RFC3339))
+}
+
+// migrationRecord is a row of the migrations table
+type migrationRecord struct {
+	Partition   string
+	Version     int
+	Description string
+	AppliedAt   time.Time
+}
+
+type migrationLock struct {
+	Partition string
+	Owner     string
+	LockedAt  time.Time
+}
+
+type migrator struct {
+	ks         KeySpace
+	migrations []Migration
+	records    Table
+	lock       Table
+}
+
+// NewMigrator returns a Migrator which applies the migrations to the keyspace
+func NewMigrator(ks KeySpace, migrations ...Migration) Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	return &migrator{
+		ks:         ks,
+		migrations: sorted,
+		records: ks.Table(migrationsTableName, &migrationRecord{}, Keys{
+			PartitionKeys:     []string{"Partition"},
+			ClusteringColumns: []string{"Version"},
+		}).WithOptions(Options{TableName: migrationsTableName}),
+		lock: ks.Table(migrationsLockTableName, &migrationLock{}, Keys{
+			PartitionKeys: []string{"Partition"},
+		}).WithOptions(Options{TableName: migrationsLockTableName}),
+	}
+}
+
+func (m *migrator) validate() error {
+	for i, migration := range m.migrations {
+		if migration.Version <= 0 {
+			return fmt.Errorf("migration %q has version %d, versions must be positive", migration.Description, migration.Version)
+		}
+		if i > 0 && m.migrations[i-1].Version == migration.Version {
+			return fmt.Errorf("migrations %q and %q have the same version %d", m.migrations[i-1].Description,
+				migration.Description, migration.Version)
+		}
+	}
+	return nil
+}
+
+// applied returns the migrations which have been applied, keyed by version
+func (m *migrator) applied(ctx context.Context) (map[int]migrationRecord, error) {
+	applied := map[int]migrationRecord{}
+	if exists, err := m.ks.Exists(migrationsTableName); err != nil || !exists {
+		return applied, err
+	}
+
+	records := []migrationRecord{}
+	if err := m.records.Where(Eq("Partition", migrationsPartition)).Read(&records).RunWithContext(ctx); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func (m *migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	// Migrations may have been applied by a newer version of the code
+	for _, record := range applied {
+		statuses = append(statuses, MigrationStatus{
+			Version:     record.Version,
+			Description: record.Description,
+			Applied:     true,
+			AppliedAt:   record.AppliedAt,
+		})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+func (m *migrator) Up(ctx context.Context) (err error) {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	if err := m.records.CreateIfNotExist(); err != nil {
+		return err
+	}
+	if err := m.lock.CreateIfNotExist(); err != nil {
+		return err
+	}
+
+	owner := gocql.TimeUUID().String()
+	if err := m.acquireLock(ctx, owner); err != nil {
+		return err
+	}
+	defer func() {
+		// The lock is released even if the context is done, otherwise it'd be
+		// held until it expires
+		releaseErr := m.lock.Where(Eq("Partition", migrationsPartition)).DeleteIf(Eq("Owner", owner)).Run()
+		if _, ok := releaseErr.(NotAppliedError); !ok && releaseErr != nil && err == nil {
+			err = releaseErr
+		}
+	}()
+
+	// Only read what's been applied once the lock is held, so another process
+	// can't apply the same migrations in the meantime
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.refreshLock(ctx, owner); err != nil {
+			return err
+		}
+		if err := m.apply(ctx, migration, nil); err != nil {
+			return fmt.Errorf("failed to apply migration %d (%s): %v", migration.Version, migration.Description, err)
+		}
+
+		record := migrationRecord{
+			Partition:   migrationsPartition,
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now().UTC(),
+		}
+		if err := m.records.Set(record).RunWithContext(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *migrator) DryRun(ctx context.Context) ([]Statement, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	qe := &recordingQueryExecutor{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.apply(ctx, migration, qe); err != nil {
+			return nil, fmt.Errorf("failed to apply migration %d (%s): %v", migration.Version, migration.Description, err)
+		}
+	}
+	return qe.stmts, nil
+}
+
+// apply applies a migration to the keyspace. During a dry run its statements
+// are recorded by dryRun rather than executed
+func (m *migrator) apply(ctx context.Context, migration Migration, dryRun *recordingQueryExecutor) error {
+	ks := m.ks
+	if dryRun != nil {
+		ks = NewConnection(dryRun).KeySpace(m.ks.Name())
+	}
+
+	for _, tbl := range migration.Tables {
+		if dryRun == nil {
+			if err := tbl.CreateIfNotExist(); err != nil {
+				return err
+			}
+			continue
+		}
+		stmts, err := createIfNotExistStatements(tbl)
+		if err != nil {
+			return err
+		}
+		dryRun.stmts = append(dryRun.stmts, stmts...)
+	}
+	for _, stmt := range migration.Statements {
+		if dryRun != nil {
+			dryRun.stmts = append(dryRun.stmts, stmt)
+		} else if err := m.execute(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if migration.Func != nil {
+		return migration.Func(ctx, ks)
+	}
+	return nil
+}
+
+// execute executes a statement against the migrator's keyspace
+func (m *migrator) execute(ctx context.Context, stmt Statement) error {
+	return m.ks.QueryExecutor().ExecuteWithOptions(Options{Context: ctx}, stmt)
+}
+
+func (m *migrator) acquireLock(ctx context.Context, owner string) error {
+	lock := migrationLock{
+		Partition: migrationsPartition,
+		Owner:     owner,
+		LockedAt:  time.Now().UTC(),
+	}
+	err := m.lock.SetIfNotExists(lock).WithOptions(Options{TTL: migrationLockTTL}).RunWithContext(ctx)
+	if notApplied, ok := err.(NotAppliedError); ok {
+		lockedErr := MigrationLockedError{}
+		lockedErr.Owner, _ = notApplied.Current["owner"].(string)
+		lockedErr.LockedAt, _ = notApplied.Current["lockedat"].(time.Time)
+		return lockedErr
+	}
+	return err
+}
+
+// refreshLock extends the lock by another migrationLockTTL, returning an
+// error if it has been lost. Every column of the lock is rewritten, as the TTL
+// only applies to the columns written, and the lock must not outlive its owner
+func (m *migrator) refreshLock(ctx context.Context, owner string) error {
+	err := m.lock.Where(Eq("Partition", migrationsPartition)).
+		UpdateIf([]Relation{Eq("Owner", owner)}, map[string]interface{}{
+			"Owner":    owner,
+			"LockedAt": time.Now().UTC(),
+		}).
+		WithOptions(Options{TTL: migrationLockTTL}).
+		RunWithContext(ctx)
+	if _, ok := err.(NotAppliedError); ok {
+		return fmt.Errorf("lost the migrations lock, it has expired or been taken by another process")
+	}
+	return err
+}
+
+// createIfNotExistStatements returns the statements which CreateIfNotExist
+// executes for the table
+func createIfNotExistStatements(tbl TableChanger) ([]Statement, error) {
+	if recipe, ok := tbl.(interface{ Table() Table }); ok {
+		tbl = recipe.Table()
+	}
+	if t, ok := tbl.(t); ok {
+		return t.createStatements()
+	}
+	stmt, err := tbl.CreateIfNotExistStatement()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := stmt.(noOpStatement); ok {
+		return nil, nil
+	}
+	return []Statement{stmt}, nil
+}
+
+// recordingQueryExecutor records the statements executed against it, rather
+// than executing them. Queries return no rows
+type recordingQueryExecutor struct {
+	stmts []Statement
+}
+
+func (qe *recordingQueryExecutor) QueryWithOptions(opts Options, stmt Statement, scanner Scanner) error {
+	_, err := scanner.ScanIter(newMockIterator(nil, nil))
+	return err
+}
+
+func (qe *recordingQueryExecutor) Query(stmt Statement, scanner Scanner) error {
+	return qe.QueryWithOptions(Options{}, stmt, scanner)
+}
+
+func (qe *recordingQueryExecutor) ExecuteWithOptions(opts Options, stmt Statement) error {
+	qe.stmts = append(qe.stmts, stmt)
+	return nil
+}
+
+func (qe *recordingQueryExecutor) Execute(stmt Statement) error {
+	return qe.ExecuteWithOptions(Options{}, stmt)
+}
+
+func (qe *recordingQueryExecutor) ExecuteAtomically(stmts []Statement) error {
+	return qe.ExecuteAtomicallyWithOptions(Options{}, stmts)
+}
+
+func (qe *recordingQueryExecutor) ExecuteAtomicallyWithOptions(opts Options, stmts []Statement) error {
+	qe.stmts = append(qe.stmts, stmts...)
+	return nil
+}
+
+func (qe *recordingQueryExecutor) ExecuteBatchWithOptions(opts Options, batchType BatchType, stmts []Statement) error {
+	return qe.ExecuteAtomicallyWithOptions(opts, stmts)
+}
+
+func (qe *recordingQueryExecutor) QueryPageWithOptions(opts Options, stmt Statement, scanner Scanner, pageSize int, pageState []byte) ([]byte, error) {
+	return nil, qe.QueryWithOptions(opts, stmt, scanner)
+}
+
+func (qe *recordingQueryExecutor) QueryIterWithOptions(opts Options, stmt Statement) Scannable {
+	return newMockIterator(nil, nil)
+}
+
+func (qe *recordingQueryExecutor) ExecuteCASWithOptions(opts Options, stmt Statement, current map[string]interface{}) (bool, error) {
+	qe.stmts = append(qe.stmts, stmt)
+	return true, nil
+}
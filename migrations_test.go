@@ -0,0 +1,187 @@
+package gocassa
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMigrations(ks KeySpace, calls *int) []Migration {
+	return []Migration{
+		{
+			Version:     2,
+			Description: "add jane",
+			Func: func(ctx context.Context, ks KeySpace) error {
+				*calls++
+				return ks.MapTable("users", "Pk1", user{}).Set(user{Pk1: 1, Name: "Jane"}).RunWithContext(ctx)
+			},
+		},
+		{
+			Version:     1,
+			Description: "create users",
+			Tables:      []TableChanger{ks.MapTable("users", "Pk1", user{})},
+			Statements:  []Statement{cqlStatement{query: "ALTER TABLE users_map_pk1 WITH comment = 'users'"}},
+		},
+	}
+}
+
+func TestMigratorUp(t *testing.T) {
+	ks := NewMockKeySpace()
+	calls := 0
+	m := NewMigrator(ks, testMigrations(ks, &calls)...)
+
+	statuses, err := m.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []MigrationStatus{
+		{Version: 1, Description: "create users"},
+		{Version: 2, Description: "add jane"},
+	}, statuses)
+
+	require.NoError(t, m.Up(context.Background()))
+	assert.Equal(t, 1, calls)
+	exists, err := ks.Exists("users_map_pk1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	statuses, err = m.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	for _, status := range statuses {
+		assert.True(t, status.Applied)
+		assert.WithinDuration(t, time.Now(), status.AppliedAt, time.Minute)
+	}
+
+	// Applied migrations aren't applied again, and the lock has been released
+	require.NoError(t, m.Up(context.Background()))
+	assert.Equal(t, 1, calls)
+	stmts, err := m.DryRun(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, stmts)
+}
+
+func TestMigratorFailure(t *testing.T) {
+	ks := NewMockKeySpace()
+	calls := 0
+	migrations := append(testMigrations(ks, &calls), Migration{
+		Version:     3,
+		Description: "fail",
+		Func: func(ctx context.Context, ks KeySpace) error {
+			return errors.New("oops")
+		},
+	})
+	m := NewMigrator(ks, migrations...)
+
+	err := m.Up(context.Background())
+	assert.EqualError(t, err, "failed to apply migration 3 (fail): oops")
+
+	// The migrations before the failing one stay applied
+	statuses, err := m.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.True(t, statuses[0].Applied)
+	assert.True(t, statuses[1].Applied)
+	assert.False(t, statuses[2].Applied)
+
+	// Migrations applied by another version of the code are listed too
+	// (the mock doesn't share rows between tables, so the records are too)
+	older := NewMigrator(ks, migrations[1]).(*migrator)
+	older.records = m.(*migrator).records
+	statuses, err = older.Status(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "add jane", statuses[1].Description)
+}
+
+func TestMigratorLocked(t *testing.T) {
+	ks := NewMockKeySpace()
+	calls := 0
+	m := NewMigrator(ks, testMigrations(ks, &calls)...).(*migrator)
+
+	lockedAt := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, m.lock.CreateIfNotExist())
+	require.NoError(t, m.lock.Set(migrationLock{Partition: migrationsPartition, Owner: "someone", LockedAt: lockedAt}).Run())
+
+	err := m.Up(context.Background())
+	assert.Equal(t, MigrationLockedError{Owner: "someone", LockedAt: lockedAt}, err)
+	assert.Equal(t, 0, calls)
+
+	require.NoError(t, m.lock.Where(Eq("Partition", migrationsPartition)).Delete().Run())
+	assert.NoError(t, m.Up(context.Background()))
+	assert.Equal(t, 1, calls)
+}
+
+func TestMigratorExecutesStatements(t *testing.T) {
+	qe := &recordingQueryExecutor{}
+	stmt := cqlStatement{query: "ALTER TABLE users_map_pk1 WITH comment = 'users'"}
+	m := NewMigrator(NewConnection(qe).KeySpace("test"), Migration{
+		Version:     1,
+		Description: "comment users",
+		Statements:  []Statement{stmt},
+	})
+
+	// The statements are executed by the query executor of the connection
+	require.NoError(t, m.Up(context.Background()))
+	assert.Contains(t, qe.stmts, stmt)
+}
+
+func TestMigratorRefreshLock(t *testing.T) {
+	ks := NewMockKeySpace()
+	m := NewMigrator(ks).(*migrator)
+	require.NoError(t, m.lock.CreateIfNotExist())
+	require.NoError(t, m.lock.SetIfNotExists(migrationLock{Partition: migrationsPartition, Owner: "me"}).
+		WithOptions(Options{TTL: time.Minute}).Run())
+
+	require.NoError(t, m.refreshLock(context.Background(), "me"))
+	// Every column of the lock is extended, so none of them expire early
+	mock := m.lock.(*MockTable)
+	require.Len(t, mock.rows, 1)
+	for _, row := range mock.rows {
+		row.Ascend(func(item btree.Item) bool {
+			expiries := item.(*superColumn).Expiries
+			for _, column := range []string{"owner", "lockedat"} {
+				assert.WithinDuration(t, time.Now().Add(migrationLockTTL), expiries[column], time.Minute, column)
+			}
+			return true
+		})
+	}
+
+	assert.EqualError(t, m.refreshLock(context.Background(), "someone"),
+		"lost the migrations lock, it has expired or been taken by another process")
+}
+
+func TestMigratorInvalidVersions(t *testing.T) {
+	ks := NewMockKeySpace()
+	m := NewMigrator(ks, Migration{Version: 1, Description: "a"}, Migration{Version: 1, Description: "b"})
+	assert.EqualError(t, m.Up(context.Background()), `migrations "a" and "b" have the same version 1`)
+
+	m = NewMigrator(ks, Migration{Version: 0, Description: "a"})
+	_, err := m.Status(context.Background())
+	assert.EqualError(t, err, `migration "a" has version 0, versions must be positive`)
+}
+
+func TestMigratorDryRun(t *testing.T) {
+	qe := &recordingQueryExecutor{}
+	ks := NewConnection(qe).KeySpace("app")
+	calls := 0
+	m := NewMigrator(ks, testMigrations(ks, &calls)...)
+
+	stmts, err := m.DryRun(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	// Nothing is executed against the keyspace itself
+	assert.Empty(t, qe.stmts)
+
+	queries := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		queries[i] = stmt.Query()
+	}
+	require.Len(t, queries, 3)
+	assert.Contains(t, queries[0], "CREATE TABLE IF NOT EXISTS app.users_map_Pk1 (")
+	assert.Equal(t, "ALTER TABLE users_map_pk1 WITH comment = 'users'", queries[1])
+	assert.Equal(t, "UPDATE app.users_map_Pk1 SET ck1 = ?, ck2 = ?, name = ?, pk2 = ? WHERE pk1 = ?", queries[2])
+}
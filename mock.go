@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"context"
 
@@ -17,12 +20,23 @@ import (
 // MockKeySpace implements the KeySpace interface and constructs in-memory tables.
 type mockKeySpace struct {
 	k
+
+	// tables holds the names of the tables which have been created, so
+	// they're listed by Tables
+	tablesMtx *sync.RWMutex
+	tables    map[string]struct{}
+
+	metrics MetricsCollector
 }
 
 type mockOp struct {
 	options      Options
 	funcs        []func(mockOp) error
 	preflightErr error
+	// write describes what the op writes, it's nil unless the op is a write
+	write *batchWrite
+	// conditional is set for lightweight transactions
+	conditional bool
 }
 
 func newOp(f func(mockOp) error) mockOp {
@@ -36,6 +50,9 @@ func (m mockOp) Add(ops ...Op) Op {
 }
 
 func (m mockOp) Run() error {
+	if err := m.Preflight(); err != nil {
+		return err
+	}
 	for _, f := range m.funcs {
 		err := f(m)
 		if err != nil {
@@ -55,8 +72,11 @@ func (m mockOp) Options() Options {
 
 func (m mockOp) WithOptions(opt Options) Op {
 	return mockOp{
-		options: m.options.Merge(opt),
-		funcs:   m.funcs,
+		options:      m.options.Merge(opt),
+		funcs:        m.funcs,
+		preflightErr: m.preflightErr,
+		write:        m.write,
+		conditional:  m.conditional,
 	}
 }
 
@@ -72,6 +92,17 @@ func (m mockOp) RunAtomicallyWithContext(ctx context.Context) error {
 	return m.RunLoggedBatchWithContext(ctx)
 }
 
+func (m mockOp) RunBatchWithContext(ctx context.Context, batchType BatchType) error {
+	if err := m.PreflightBatch(batchType); err != nil {
+		return err
+	}
+	return m.RunWithContext(ctx)
+}
+
+func (m mockOp) RunConcurrently(ctx context.Context, maxParallel int) error {
+	return m.RunWithContext(ctx)
+}
+
 func (m mockOp) GenerateStatement() Statement {
 	return noOpStatement{}
 }
@@ -81,7 +112,29 @@ func (m mockOp) QueryExecutor() QueryExecutor {
 }
 
 func (m mockOp) Preflight() error {
-	return m.preflightErr
+	if m.preflightErr != nil {
+		return m.preflightErr
+	}
+	if m.conditional && !m.options.Timestamp.IsZero() {
+		return errConditionalTimestamp
+	}
+	return nil
+}
+
+func (m mockOp) PreflightBatch(batchType BatchType) error {
+	if err := m.Preflight(); err != nil {
+		return err
+	}
+	return checkBatch(batchType, []Op{m})
+}
+
+func (m mockOp) batchWrite() (batchWrite, bool) {
+	if m.write == nil {
+		return batchWrite{}, false
+	}
+	w := *m.write
+	w.conditional = m.conditional
+	return w, true
 }
 
 type mockMultiOp []Op
@@ -107,17 +160,52 @@ func (mo mockMultiOp) RunWithContext(ctx context.Context) error {
 }
 
 func (mo mockMultiOp) RunAtomically() error {
+	if err := mo.PreflightBatch(LoggedBatch); err != nil {
+		return err
+	}
 	return mo.Run()
 }
 
 func (mo mockMultiOp) RunLoggedBatchWithContext(ctx context.Context) error {
-	return mo.WithOptions(Options{Context: ctx}).Run()
+	return mo.RunBatchWithContext(ctx, LoggedBatch)
 }
 
 func (mo mockMultiOp) RunAtomicallyWithContext(ctx context.Context) error {
 	return mo.RunLoggedBatchWithContext(ctx)
 }
 
+// RunBatchWithContext runs the ops one after another, once they've been
+// checked they can be run in a batch of the given type
+func (mo mockMultiOp) RunBatchWithContext(ctx context.Context, batchType BatchType) error {
+	if err := mo.PreflightBatch(batchType); err != nil {
+		return err
+	}
+	return mo.WithOptions(Options{Context: ctx}).Run()
+}
+
+// RunConcurrently consults the ErrorInjector of ctx for each op in turn until
+// it injects an error, which the op then fails with rather than being run
+func (mo mockMultiOp) RunConcurrently(ctx context.Context, maxParallel int) error {
+	if err := mo.Preflight(); err != nil {
+		return err
+	}
+
+	errorInjector := getErrorInjector(ctx)
+	injected := make([]error, len(mo))
+	for i, op := range mo {
+		if injected[i] = errorInjector.shouldReturnErr(op, i, len(mo)); injected[i] != nil {
+			break
+		}
+	}
+
+	return runConcurrently(ctx, mo, maxParallel, mo.Options().CollectErrors, func(ctx context.Context, i int, op Op) error {
+		if injected[i] != nil {
+			return injected[i]
+		}
+		return op.RunWithContext(ctx)
+	})
+}
+
 func (mo mockMultiOp) GenerateStatement() Statement {
 	return noOpStatement{}
 }
@@ -172,15 +260,24 @@ func (mo mockMultiOp) Preflight() error {
 	return nil
 }
 
+func (mo mockMultiOp) PreflightBatch(batchType BatchType) error {
+	if err := mo.Preflight(); err != nil {
+		return err
+	}
+	return checkBatch(batchType, mo)
+}
+
 func (ks *mockKeySpace) NewTable(name string, entity interface{}, fieldSource map[string]interface{}, keys Keys) Table {
 	mt := &MockTable{
 		RWMutex:     &sync.RWMutex{},
+		keySpace:    ks,
 		ksName:      ks.Name(),
 		tableName:   name,
 		entity:      entity,
 		keys:        keys,
 		fieldSource: fieldSource,
 		rows:        map[rowKey]*btree.BTree{},
+		statics:     map[rowKey]*superColumn{},
 		mtx:         &sync.RWMutex{},
 	}
 
@@ -188,23 +285,99 @@ func (ks *mockKeySpace) NewTable(name string, entity interface{}, fieldSource ma
 	for _, k := range sortedKeys(fieldSource) {
 		fields = append(fields, k)
 	}
-	mt.fields = fields
+	mt.fields = append(fields, metadataFields(entity)...)
+	fieldInfo := entityFieldInfo(entity)
+	mt.staticColumns = staticColumns(keys, fields, fieldInfo)
+	for _, field := range fields {
+		if fieldInfo[strings.ToLower(field)].Set() {
+			mt.setColumns = append(mt.setColumns, field)
+		}
+	}
 
 	return mt
 }
 
+// MaterializedView returns a read-only view of the base table, which is
+// brought up to date with the base table whenever it is read
+func (ks *mockKeySpace) MaterializedView(base Table, name string, keys Keys) Table {
+	baseTable, ok := base.(*MockTable)
+	if !ok {
+		panic("Unrecognized base table")
+	}
+
+	view := ks.NewTable(name, baseTable.entity, baseTable.fieldSource, keys).(*MockTable)
+	view.base = baseTable
+	return view
+}
+
 func NewMockKeySpace() KeySpace {
-	ks := &mockKeySpace{}
+	return NewMockKeySpaceWithMetrics(nil)
+}
+
+// NewMockKeySpaceWithMetrics is NewMockKeySpace, but the metrics of every
+// read and write of its tables are reported to the collector
+func NewMockKeySpaceWithMetrics(metrics MetricsCollector) KeySpace {
+	ks := &mockKeySpace{
+		tablesMtx: &sync.RWMutex{},
+		tables:    map[string]struct{}{},
+		metrics:   metrics,
+	}
 	ks.tableFactory = ks
 	return ks
 }
 
+// Tables returns the names of the tables which have been created with
+// Create, CreateIfNotExist or Recreate
+func (ks *mockKeySpace) Tables() ([]string, error) {
+	ks.tablesMtx.RLock()
+	defer ks.tablesMtx.RUnlock()
+
+	names := make([]string, 0, len(ks.tables))
+	for name := range ks.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (ks *mockKeySpace) Exists(cf string) (bool, error) {
+	ks.tablesMtx.RLock()
+	defer ks.tablesMtx.RUnlock()
+
+	_, ok := ks.tables[strings.ToLower(cf)]
+	return ok, nil
+}
+
+// QueryExecutor returns a QueryExecutor which records the statements executed
+// against it rather than executing them, as the mock keyspace can't execute CQL
+func (ks *mockKeySpace) QueryExecutor() QueryExecutor {
+	return &recordingQueryExecutor{}
+}
+
+// Replication returns the replication of a single node cluster, as the mock
+// keyspace only exists in memory
+func (ks *mockKeySpace) Replication() (KeySpaceOptions, error) {
+	durableWrites := true
+	return KeySpaceOptions{
+		Strategy:          SimpleStrategy,
+		ReplicationFactor: 1,
+		DurableWrites:     &durableWrites,
+	}, nil
+}
+
+func (ks *mockKeySpace) addTable(name string) {
+	ks.tablesMtx.Lock()
+	defer ks.tablesMtx.Unlock()
+	ks.tables[strings.ToLower(name)] = struct{}{}
+}
+
 // MockTable implements the Table interface and stores rows in-memory.
 type MockTable struct {
 	*sync.RWMutex
 
 	// rows is mapping from row key to column group key to column map
 	mtx         *sync.RWMutex
+	keySpace    *mockKeySpace
 	ksName      string
 	tableName   string
 	rows        map[rowKey]*btree.BTree
@@ -213,12 +386,187 @@ type MockTable struct {
 	fields      []string
 	keys        Keys
 	options     Options
+
+	// statics holds the static columns of each partition, along with its
+	// partition key, and staticColumns the names of the static columns
+	statics       map[rowKey]*superColumn
+	staticColumns []string
+
+	// setColumns holds the names of the fields stored as sets (tagged
+	// `cql:",set"`), which are kept sorted and without duplicates
+	setColumns []string
+
+	// base is set for materialized views, which are read-only and kept up to
+	// date with the base table
+	base *MockTable
 }
 
 type rowKey string
 type superColumn struct {
 	Key     key
 	Columns map[string]interface{}
+
+	// Timestamps holds the write timestamp of each column, and Expiries when
+	// the columns written with a TTL expire, both keyed by lowercase column name
+	Timestamps map[string]int64
+	Expiries   map[string]time.Time
+}
+
+// write assigns the columns in m, except those which were last written with a
+// later timestamp as Cassandra resolves conflicting writes by their timestamp
+func (c *superColumn) write(m map[string]interface{}, options Options) error {
+	now := time.Now()
+	timestamp := timestampMicros(now)
+	if !options.Timestamp.IsZero() {
+		timestamp = timestampMicros(options.Timestamp)
+	}
+
+	written := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if ts, ok := c.Timestamps[strings.ToLower(k)]; ok && ts > timestamp {
+			continue
+		}
+		written[k] = v
+	}
+	if err := assignRecords(written, c.Columns); err != nil {
+		return err
+	}
+
+	if c.Timestamps == nil {
+		c.Timestamps, c.Expiries = map[string]int64{}, map[string]time.Time{}
+	}
+	for k := range written {
+		k = strings.ToLower(k)
+		c.Timestamps[k] = timestamp
+		if options.TTL > 0 {
+			c.Expiries[k] = now.Add(options.TTL)
+		} else {
+			delete(c.Expiries, k)
+		}
+	}
+	return nil
+}
+
+// delete removes the given columns, keeping the time they were deleted at so
+// that earlier writes don't resurrect them, and the collection elements given
+// by MapDeleteKey and ListDeleteAtIndex modifiers
+func (c *superColumn) delete(columns []string, elements map[string]Modifier) error {
+	now := timestampMicros(time.Now())
+	for _, column := range columns {
+		column = columnKey(c.Columns, column)
+		delete(c.Columns, column)
+
+		if c.Timestamps == nil {
+			c.Timestamps, c.Expiries = map[string]int64{}, map[string]time.Time{}
+		}
+		c.Timestamps[strings.ToLower(column)] = now
+		delete(c.Expiries, strings.ToLower(column))
+	}
+
+	for column, modifier := range elements {
+		column = columnKey(c.Columns, column)
+		switch modifier.op {
+		case ModifierMapDeleteKey:
+			if err := assignRecords(map[string]interface{}{column: MapRemoveKeys(modifier.args[0])}, c.Columns); err != nil {
+				return err
+			}
+		case ModifierListDeleteAtIndex:
+			list, err := deleteAtIndex(c.Columns[column], modifier.args[0].(int))
+			if err != nil {
+				return err
+			}
+			c.Columns[column] = list
+		default:
+			return fmt.Errorf("Modifer %v not supported by mock keyspace", modifier.op)
+		}
+	}
+	return nil
+}
+
+// columnKey returns the key a column is stored under, which may differ in case
+// from the column name given
+func columnKey(columns map[string]interface{}, column string) string {
+	if _, ok := columns[column]; ok {
+		return column
+	}
+	for k := range columns {
+		if strings.EqualFold(k, column) {
+			return k
+		}
+	}
+	return column
+}
+
+// deleteAtIndex removes the element at index i of a list, which the mock
+// stores as a slice
+func deleteAtIndex(list interface{}, i int) (interface{}, error) {
+	rv := reflect.ValueOf(list)
+	if list == nil || (rv.Kind() == reflect.Slice && rv.Len() == 0) {
+		return nil, fmt.Errorf("Attempted to delete an element from a list which is null")
+	}
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("Can't use ListDeleteAtIndex modifier on field that isn't a slice: %T", list)
+	}
+	if i < 0 || i >= rv.Len() {
+		return nil, fmt.Errorf("List index %d out of bound, list has size %d", i, rv.Len())
+	}
+
+	out := reflect.MakeSlice(rv.Type(), 0, rv.Len()-1)
+	out = reflect.AppendSlice(out, rv.Slice(0, i))
+	out = reflect.AppendSlice(out, rv.Slice(i+1, rv.Len()))
+	return out.Interface(), nil
+}
+
+// row returns the columns as they are read, along with the write timestamp
+// and TTL of each column which can be selected with writetime(column) and
+// ttl(column). The mock doesn't expire columns once their TTL has passed
+func (c *superColumn) row() map[string]interface{} {
+	if len(c.Timestamps) == 0 {
+		return c.Columns
+	}
+
+	row := make(map[string]interface{}, len(c.Columns)+len(c.Timestamps)+len(c.Expiries))
+	for k, v := range c.Columns {
+		row[k] = v
+		// Deleted columns keep their timestamp, but have no write time
+		if ts, ok := c.Timestamps[strings.ToLower(k)]; ok {
+			row["writetime("+strings.ToLower(k)+")"] = ts
+		}
+	}
+	for k, expiry := range c.Expiries {
+		ttl := int(time.Until(expiry).Seconds())
+		if ttl < 0 {
+			ttl = 0
+		}
+		row["ttl("+k+")"] = ttl
+	}
+	return row
+}
+
+// copyWith returns a copy of the columns along with the given static columns
+// of their partition, which may be nil
+func (c *superColumn) copyWith(static *superColumn) *superColumn {
+	cp := &superColumn{
+		Key:        c.Key,
+		Columns:    map[string]interface{}{},
+		Timestamps: map[string]int64{},
+		Expiries:   map[string]time.Time{},
+	}
+	for _, src := range []*superColumn{c, static} {
+		if src == nil {
+			continue
+		}
+		for k, v := range src.Columns {
+			cp.Columns[k] = v
+		}
+		for k, v := range src.Timestamps {
+			cp.Timestamps[k] = v
+		}
+		for k, v := range src.Expiries {
+			cp.Expiries[k] = v
+		}
+	}
+	return cp
 }
 
 func (c *superColumn) Less(item btree.Item) bool {
@@ -296,6 +644,24 @@ func (k key) RowKey() rowKey {
 	return rowKey(buf.String())
 }
 
+// Token returns the Murmur3 token Cassandra would assign to this partition
+// key. Composite partition keys are serialized as Cassandra does, with each
+// component prefixed by its length and followed by a zero byte
+func (k key) Token() int64 {
+	if len(k) == 1 {
+		return murmur3Token(k[0].Bytes())
+	}
+
+	buf := bytes.Buffer{}
+	for _, part := range k {
+		b := part.Bytes()
+		buf.Write([]byte{byte(len(b) >> 8), byte(len(b))})
+		buf.Write(b)
+		buf.WriteByte(0)
+	}
+	return murmur3Token(buf.Bytes())
+}
+
 func (k key) ToSuperColumn() *superColumn {
 	return &superColumn{Key: k}
 }
@@ -369,8 +735,210 @@ func (t *MockTable) getOrCreateRow(rowKey key) *btree.BTree {
 	return row
 }
 
-func (t *MockTable) getOrCreateColumnGroup(rowKey, superColumnKey key) map[string]interface{} {
-	row := t.getOrCreateRow(rowKey)
+// getOrCreateStaticColumnGroup returns the static columns of a partition,
+// which hold its partition key
+func (t *MockTable) getOrCreateStaticColumnGroup(rowKey key) *superColumn {
+	// The partition is created too, so it's read even if it has no rows
+	t.getOrCreateRow(rowKey)
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	static := t.statics[rowKey.RowKey()]
+	if static == nil {
+		static = &superColumn{Key: rowKey, Columns: map[string]interface{}{}}
+		for _, keyPart := range rowKey {
+			static.Columns[keyPart.Key] = keyPart.Value
+		}
+		t.statics[rowKey.RowKey()] = static
+	}
+	return static
+}
+
+// splitStatic splits the columns into the static columns and the rest
+// normaliseSets returns the columns with the values of set columns sorted and
+// without duplicates, as Cassandra stores them
+func (t *MockTable) normaliseSets(columns map[string]interface{}) (map[string]interface{}, error) {
+	if len(t.setColumns) == 0 {
+		return columns, nil
+	}
+	normalised := make(map[string]interface{}, len(columns))
+	for k, v := range columns {
+		normalised[k] = v
+		if !containsField(t.setColumns, k) {
+			continue
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice || rv.IsNil() {
+			continue
+		}
+		values := make([]interface{}, rv.Len())
+		for i := range values {
+			values[i] = rv.Index(i).Interface()
+		}
+		set, err := updateSet(reflect.MakeSlice(rv.Type(), 0, 0).Interface(), values, true)
+		if err != nil {
+			return nil, err
+		}
+		normalised[k] = set
+	}
+	return normalised, nil
+}
+
+func (t *MockTable) splitStatic(columns map[string]interface{}) (static, regular map[string]interface{}) {
+	if len(t.staticColumns) == 0 {
+		return nil, columns
+	}
+	static, regular = map[string]interface{}{}, map[string]interface{}{}
+	for k, v := range columns {
+		if containsField(t.staticColumns, k) {
+			static[k] = v
+		} else {
+			regular[k] = v
+		}
+	}
+	return static, regular
+}
+
+// partitionRows returns the rows of a partition as they are read, each with
+// the static columns of the partition. As in Cassandra, a partition with only
+// static columns is read as a single row without clustering columns
+func (t *MockTable) partitionRows(rowKey rowKey, row *btree.BTree) []map[string]interface{} {
+	static := t.statics[rowKey]
+	var rows []map[string]interface{}
+	row.Ascend(func(item btree.Item) bool {
+		columns := item.(*superColumn).row()
+		if static != nil {
+			merged := map[string]interface{}{}
+			for k, v := range columns {
+				merged[k] = v
+			}
+			for k, v := range static.row() {
+				merged[k] = v
+			}
+			columns = merged
+		}
+		rows = append(rows, columns)
+		return true
+	})
+	if len(rows) == 0 && static != nil && len(static.Columns) > len(static.Key) {
+		rows = append(rows, static.row())
+	}
+	return rows
+}
+
+// observe reports the metrics of a read or write to the collector of the
+// keyspace, if it has one
+func (t *MockTable) observe(typ QueryType, start time.Time, rows int, err error) {
+	if t.keySpace == nil || t.keySpace.metrics == nil {
+		return
+	}
+	t.keySpace.metrics.ObserveQuery(QueryMetrics{
+		Keyspace:   t.ksName,
+		Table:      t.Name(),
+		Type:       typ,
+		Duration:   time.Since(start),
+		Rows:       rows,
+		ErrorClass: ErrorClassOf(err),
+	})
+}
+
+// writeOp returns an op running f, unless the table is a materialized view in
+// which case the op fails its preflight check
+func (t *MockTable) writeOp(typ QueryType, write batchWrite, f func(mockOp) error) mockOp {
+	op := newOp(func(m mockOp) error {
+		start := time.Now()
+		err := f(m)
+		t.observe(typ, start, 0, err)
+		return err
+	})
+	op.write = &write
+	if t.base != nil {
+		op.preflightErr = viewWriteError(t.Name())
+	}
+	return op
+}
+
+// conditionalWriteOp is writeOp for lightweight transactions, which can't be
+// given a custom timestamp
+func (t *MockTable) conditionalWriteOp(typ QueryType, write batchWrite, f func(mockOp) error) Op {
+	op := t.writeOp(typ, write, f)
+	op.conditional = true
+	if op.preflightErr == nil && !t.options.Timestamp.IsZero() {
+		op.preflightErr = errConditionalTimestamp
+	}
+	return op
+}
+
+// refreshView rebuilds the rows of a materialized view from its base table,
+// skipping rows with a null primary key column as Cassandra does. The base
+// rows are copied under its lock, so later writes to the base don't race with
+// reads of the view
+func (t *MockTable) refreshView() error {
+	if t.base == nil {
+		return nil
+	}
+
+	t.base.mtx.RLock()
+	var baseRows []*superColumn
+	for partition, row := range t.base.rows {
+		static := t.base.statics[partition]
+		row.Ascend(func(item btree.Item) bool {
+			baseRows = append(baseRows, item.(*superColumn).copyWith(static))
+			return true
+		})
+	}
+	t.base.mtx.RUnlock()
+
+	rows := map[rowKey]*btree.BTree{}
+	for _, base := range baseRows {
+		if !hasPrimaryKey(base.Columns, t.keys) {
+			continue
+		}
+
+		partitionKey, err := t.partitionKeyFromColumnValues(base.Columns, t.keys.PartitionKeys)
+		if err != nil {
+			continue
+		}
+		superColumnKey, err := t.clusteringKeyFromColumnValues(base.Columns, t.keys.ClusteringColumns)
+		if err != nil {
+			continue
+		}
+
+		row := rows[partitionKey.RowKey()]
+		if row == nil {
+			row = btree.New(2)
+			rows[partitionKey.RowKey()] = row
+		}
+		view := t.orderedSuperColumn(superColumnKey)
+		view.Columns, view.Timestamps, view.Expiries = base.Columns, base.Timestamps, base.Expiries
+		row.ReplaceOrInsert(view)
+	}
+
+	// The rows map is shared with the copies made by WithOptions, so it's
+	// refilled rather than replaced
+	t.mtx.Lock()
+	for k := range t.rows {
+		delete(t.rows, k)
+	}
+	for k, row := range rows {
+		t.rows[k] = row
+	}
+	t.mtx.Unlock()
+	return nil
+}
+
+func hasPrimaryKey(columns map[string]interface{}, keys Keys) bool {
+	for _, keyName := range append(append([]string{}, keys.PartitionKeys...), keys.ClusteringColumns...) {
+		if v, ok := columns[keyName]; !ok || v == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// orderedSuperColumn builds the super column for a clustering key, with the
+// key parts carrying the table's clustering order so it sorts correctly
+func (t *MockTable) orderedSuperColumn(superColumnKey key) *superColumn {
 	scol := superColumnKey.ToSuperColumn()
 
 	// Retrieve the clustering order from the table options
@@ -382,47 +950,132 @@ func (t *MockTable) getOrCreateColumnGroup(rowKey, superColumnKey key) map[strin
 	for i, kp := range scol.Key {
 		scol.Key[i].ClusteringOrder = keyOrder[kp.Key]
 	}
+	return scol
+}
+
+func (t *MockTable) getOrCreateColumnGroup(rowKey, superColumnKey key) *superColumn {
+	row := t.getOrCreateRow(rowKey)
+	scol := t.orderedSuperColumn(superColumnKey)
 
 	if row.Has(scol) {
-		return row.Get(scol).(*superColumn).Columns
+		return row.Get(scol).(*superColumn)
 	}
 	row.ReplaceOrInsert(scol)
 	scol.Columns = map[string]interface{}{}
 
-	return scol.Columns
+	return scol
+}
+
+// getColumnGroup returns the row stored for the given keys, or nil if no such
+// row exists
+func (t *MockTable) getColumnGroup(rowKey, superColumnKey key) *superColumn {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	row := t.rows[rowKey.RowKey()]
+	if row == nil {
+		return nil
+	}
+	item := row.Get(t.orderedSuperColumn(superColumnKey))
+	if item == nil {
+		return nil
+	}
+	return item.(*superColumn)
+}
+
+// deleteColumnGroup removes the row stored for the given keys, if any
+func (t *MockTable) deleteColumnGroup(rowKey, superColumnKey key) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if row := t.rows[rowKey.RowKey()]; row != nil {
+		row.Delete(t.orderedSuperColumn(superColumnKey))
+	}
+}
+
+// setWrite describes the write of a row for batches
+func (t *MockTable) setWrite(i interface{}) batchWrite {
+	columns, _ := toMap(i)
+	return newBatchWrite(t.ksName, t.Name(), t.keys, columns, nil, false)
+}
+
+// filterWrite describes an update or delete of the rows matching the filter
+// for batches
+func (f *MockFilter) filterWrite(m map[string]interface{}) batchWrite {
+	return newBatchWrite(f.table.ksName, f.table.Name(), f.table.keys, m, f.relations, m != nil)
 }
 
 func (t *MockTable) SetWithOptions(i interface{}, options Options) Op {
-	return newOp(func(m mockOp) error {
-		t.Lock()
-		defer t.Unlock()
+	return t.writeOp(InsertQuery, t.setWrite(i), func(m mockOp) error {
+		return t.set(i, false, t.options.Merge(options).Merge(m.options))
+	})
+}
 
-		columns, ok := toMap(i)
-		if !ok {
-			return errors.New("Can't create: value not understood")
-		}
+func (t *MockTable) Set(i interface{}) Op {
+	return t.SetWithOptions(i, t.options)
+}
 
-		rowKey, err := t.partitionKeyFromColumnValues(columns, t.keys.PartitionKeys)
-		if err != nil {
-			return err
+func (t *MockTable) SetIfNotExists(i interface{}) Op {
+	return t.conditionalWriteOp(InsertQuery, t.setWrite(i), func(m mockOp) error {
+		return t.set(i, true, t.options.Merge(m.options))
+	})
+}
+
+func (t *MockTable) set(i interface{}, ifNotExists bool, options Options) error {
+	t.Lock()
+	defer t.Unlock()
+
+	columns, ok := toMap(i)
+	if !ok {
+		return errors.New("Can't create: value not understood")
+	}
+	columns, err := t.normaliseSets(columns)
+	if err != nil {
+		return err
+	}
+
+	rowKey, err := t.partitionKeyFromColumnValues(columns, t.keys.PartitionKeys)
+	if err != nil {
+		return err
+	}
+
+	// Only static columns can be written without the clustering columns
+	static, regular := t.splitStatic(columns)
+	if len(static) > 0 && !hasClusteringColumn(regular, t.keys) {
+		return t.getOrCreateStaticColumnGroup(rowKey).write(static, options)
+	}
+
+	superColumnKey, err := t.clusteringKeyFromColumnValues(columns, t.keys.ClusteringColumns)
+	if err != nil {
+		return err
+	}
+
+	if ifNotExists {
+		if existing := t.getColumnGroup(rowKey, superColumnKey); existing != nil {
+			return NotAppliedError{Current: currentValues(existing.Columns)}
 		}
+	}
 
-		superColumnKey, err := t.clusteringKeyFromColumnValues(columns, t.keys.ClusteringColumns)
-		if err != nil {
+	if len(static) > 0 {
+		if err := t.getOrCreateStaticColumnGroup(rowKey).write(static, options); err != nil {
 			return err
 		}
+	}
 
-		superColumn := t.getOrCreateColumnGroup(rowKey, superColumnKey)
+	superColumn := t.getOrCreateColumnGroup(rowKey, superColumnKey)
 
-		if err := assignRecords(columns, superColumn); err != nil {
-			return err
-		}
-		return nil
-	})
+	if err := superColumn.write(regular, options); err != nil {
+		return err
+	}
+	return nil
 }
 
-func (t *MockTable) Set(i interface{}) Op {
-	return t.SetWithOptions(i, t.options)
+// hasClusteringColumn returns whether any of the clustering columns is set
+func hasClusteringColumn(columns map[string]interface{}, keys Keys) bool {
+	for _, column := range keys.ClusteringColumns {
+		if _, ok := columns[column]; ok {
+			return true
+		}
+	}
+	return false
 }
 
 func (t *MockTable) Where(relations ...Relation) Filter {
@@ -433,14 +1086,40 @@ func (t *MockTable) Where(relations ...Relation) Filter {
 }
 
 func (t *MockTable) Create() error {
+	t.created()
 	return nil
 }
 
+// created records that the table has been created, so the keyspace lists it.
+// Views aren't listed with the tables of a keyspace
+func (t *MockTable) created() {
+	if t.keySpace != nil && t.base == nil {
+		t.keySpace.addTable(t.Name())
+	}
+}
+
 func (t *MockTable) CreateStatement() (Statement, error) {
 	return noOpStatement{}, nil
 }
 
+func (t *MockTable) CreateTypeStatements() ([]Statement, error) {
+	return []Statement{}, nil
+}
+
+func (t *MockTable) CreateIndexStatements() ([]Statement, error) {
+	return []Statement{}, nil
+}
+
+func (t *MockTable) SchemaDiff(ctx context.Context) (SchemaDiff, error) {
+	return SchemaDiff{Keyspace: t.ksName, Table: t.Name(), Exists: true}, nil
+}
+
+func (t *MockTable) Migrate(ctx context.Context, opts MigrateOptions) error {
+	return nil
+}
+
 func (t *MockTable) CreateIfNotExist() error {
+	t.created()
 	return nil
 }
 
@@ -449,22 +1128,92 @@ func (t *MockTable) CreateIfNotExistStatement() (Statement, error) {
 }
 
 func (t *MockTable) Recreate() error {
+	t.created()
 	return nil
 }
 
-func (t *MockTable) WithOptions(o Options) Table {
-	return &MockTable{
-		RWMutex:     t.RWMutex,
-		ksName:      t.ksName,
-		tableName:   t.tableName,
-		rows:        t.rows,
-		entity:      t.entity,
-		keys:        t.keys,
-		fieldSource: t.fieldSource,
-		fields:      t.fields,
-		options:     t.options.Merge(o),
-		mtx:         t.mtx,
+func (t *MockTable) WithOptions(o Options) Table {
+	return &MockTable{
+		RWMutex:     t.RWMutex,
+		keySpace:    t.keySpace,
+		ksName:      t.ksName,
+		tableName:   t.tableName,
+		rows:        t.rows,
+		entity:      t.entity,
+		keys:        t.keys,
+		fieldSource: t.fieldSource,
+		fields:      t.fields,
+		options:     t.options.Merge(o),
+		mtx:         t.mtx,
+		base:        t.base,
+
+		statics:       t.statics,
+		staticColumns: t.staticColumns,
+		setColumns:    t.setColumns,
+	}
+}
+
+// Scan reads the partitions in token order, as Cassandra does. Partitions
+// are read up front, so writes made during the scan are not seen
+func (t *MockTable) Scan(ctx context.Context, opts ScanOptions, callback func(row interface{}) error) error {
+	rowType, err := scanRowType(t.entity)
+	if err != nil {
+		return err
+	}
+	if err := t.refreshView(); err != nil {
+		return err
+	}
+
+	partitions, err := t.partitionsByToken()
+	if err != nil {
+		return err
+	}
+
+	fieldNames := t.options.Select
+	if len(fieldNames) == 0 {
+		fieldNames = t.fields
+	}
+	stmt := SelectStatement{keyspace: t.ksName, table: t.Name(), fields: fieldNames}
+
+	return runScan(ctx, opts, func(ctx context.Context, r tokenRange) error {
+		var result []map[string]interface{}
+		for _, p := range partitions {
+			if r.contains(p.token) {
+				result = append(result, p.rows...)
+			}
+		}
+		return scanRows(newIterator(ctx, stmt, newMockIterator(result, stmt.fields)), rowType, callback)
+	})
+}
+
+type mockPartition struct {
+	token int64
+	rows  []map[string]interface{}
+}
+
+func (t *MockTable) partitionsByToken() ([]mockPartition, error) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	partitions := make([]mockPartition, 0, len(t.rows))
+	for rk, row := range t.rows {
+		p := mockPartition{rows: t.partitionRows(rk, row)}
+		if len(p.rows) == 0 {
+			continue
+		}
+
+		partitionKey, err := t.partitionKeyFromColumnValues(p.rows[0], t.keys.PartitionKeys)
+		if err != nil {
+			return nil, err
+		}
+		p.token = partitionKey.Token()
+		partitions = append(partitions, p)
 	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].token < partitions[j].token
+	})
+	return partitions, nil
 }
 
 // MockFilter implements the Filter interface and works with MockTable.
@@ -484,6 +1233,13 @@ func (f *MockFilter) Relations() []Relation {
 func (f *MockFilter) rowMatch(row map[string]interface{}) bool {
 	for _, relation := range f.relations {
 		value := row[relation.Field()]
+		if relation.isTuple() {
+			values := make([]interface{}, len(relation.fields))
+			for i, field := range relation.fields {
+				values[i] = row[field]
+			}
+			value = values
+		}
 		if !relation.accept(value) {
 			return false
 		}
@@ -491,6 +1247,52 @@ func (f *MockFilter) rowMatch(row map[string]interface{}) bool {
 	return true
 }
 
+// restrictsClusteringColumn returns whether any clustering column is
+// restricted by a relation of the filter
+func (f *MockFilter) restrictsClusteringColumn() bool {
+	fieldRelationMap := f.fieldRelationMap()
+	for _, keyName := range f.table.keys.ClusteringColumns {
+		if _, ok := fieldRelationMap[keyName]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictsPartitionKey returns whether every partition key is restricted by
+// a relation of the filter
+func (f *MockFilter) restrictsPartitionKey() bool {
+	fieldRelationMap := f.fieldRelationMap()
+	for _, keyName := range f.table.keys.PartitionKeys {
+		if _, ok := fieldRelationMap[keyName]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// usesIndex returns whether the filter has an equality or CONTAINS relation
+// on one of the indexed columns, or a CONTAINS KEY relation on one of the
+// columns with indexed keys
+func (f *MockFilter) usesIndex(indexes []Index) bool {
+	for _, relation := range f.relations {
+		var keys bool
+		switch relation.Comparator() {
+		case CmpEquality, CmpContains:
+		case CmpContainsKey:
+			keys = true
+		default:
+			continue
+		}
+		for _, index := range indexes {
+			if strings.EqualFold(index.Column, relation.Field()) && index.Keys == keys {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (f *MockFilter) fieldRelationMap() map[string]Relation {
 	result := map[string]Relation{}
 
@@ -535,31 +1337,50 @@ func (f *MockFilter) fieldsFromRelations(fields []string) ([]key, error) {
 }
 
 func (f *MockFilter) UpdateWithOptions(m map[string]interface{}, options Options) Op {
-	return newOp(func(mock mockOp) error {
+	if err := validateUpdate(m); err != nil {
+		return errOp{err: err}
+	}
+	return f.table.writeOp(UpdateQuery, f.filterWrite(m), func(mock mockOp) error {
 		f.table.Lock()
 		defer f.table.Unlock()
+		opts := f.table.options.Merge(options).Merge(mock.options)
 
 		rowKeys, err := f.fieldsFromRelations(f.table.keys.PartitionKeys)
 		if err != nil {
 			return err
 		}
+		columns, err := f.table.normaliseSets(m)
+		if err != nil {
+			return err
+		}
 
-		for _, rowKey := range rowKeys {
-			superColumnKeys, err := f.fieldsFromRelations(f.table.keys.ClusteringColumns)
+		// Updates of only static columns don't need the clustering columns
+		static, regular := f.table.splitStatic(columns)
+		var superColumnKeys []key
+		if len(regular) > 0 || len(static) == 0 {
+			superColumnKeys, err = f.fieldsFromRelations(f.table.keys.ClusteringColumns)
 			if err != nil {
 				return err
 			}
+		}
+
+		for _, rowKey := range rowKeys {
+			if len(static) > 0 {
+				if err := f.table.getOrCreateStaticColumnGroup(rowKey).write(static, opts); err != nil {
+					return err
+				}
+			}
 
 			for _, superColumnKey := range superColumnKeys {
 				superColumn := f.table.getOrCreateColumnGroup(rowKey, superColumnKey)
 
 				for _, key := range []key{rowKey, superColumnKey} {
 					for _, keyPart := range key {
-						superColumn[keyPart.Key] = keyPart.Value
+						superColumn.Columns[keyPart.Key] = keyPart.Value
 					}
 				}
 
-				if err := assignRecords(m, superColumn); err != nil {
+				if err := superColumn.write(regular, opts); err != nil {
 					return err
 				}
 			}
@@ -574,7 +1395,7 @@ func (f *MockFilter) Update(m map[string]interface{}) Op {
 }
 
 func (f *MockFilter) Delete() Op {
-	return newOp(func(m mockOp) error {
+	return f.table.writeOp(DeleteQuery, f.filterWrite(nil), func(m mockOp) error {
 		f.table.Lock()
 		defer f.table.Unlock()
 
@@ -586,6 +1407,11 @@ func (f *MockFilter) Delete() Op {
 		f.table.mtx.Lock()
 		defer f.table.mtx.Unlock()
 		for _, rowKey := range rowKeys {
+			// Deleting a whole partition deletes its static columns too
+			if !f.restrictsClusteringColumn() {
+				delete(f.table.statics, rowKey.RowKey())
+			}
+
 			row := f.table.rows[rowKey.RowKey()]
 			if row == nil {
 				return nil
@@ -605,43 +1431,318 @@ func (f *MockFilter) Delete() Op {
 	})
 }
 
+func (f *MockFilter) DeleteColumns(fields ...string) Op {
+	if err := validateDeletions(f.table.keys, fields, nil); err != nil {
+		return errOp{err: err}
+	}
+	return f.deleteSelectors(fields, nil)
+}
+
+func (f *MockFilter) DeleteElements(elements map[string]Modifier) Op {
+	if err := validateDeletions(f.table.keys, nil, elements); err != nil {
+		return errOp{err: err}
+	}
+	return f.deleteSelectors(nil, elements)
+}
+
+// deleteSelectors deletes columns or collection elements of the rows matching
+// the filter, leaving the rows themselves in place
+func (f *MockFilter) deleteSelectors(columns []string, elements map[string]Modifier) Op {
+	return f.table.writeOp(DeleteQuery, f.filterWrite(nil), func(m mockOp) error {
+		f.table.Lock()
+		defer f.table.Unlock()
+
+		rowKeys, err := f.fieldsFromRelations(f.table.keys.PartitionKeys)
+		if err != nil {
+			return err
+		}
+
+		staticColumns, regularColumns := f.table.splitStaticDeletions(columns, elements)
+
+		f.table.mtx.Lock()
+		defer f.table.mtx.Unlock()
+		for _, rowKey := range rowKeys {
+			if static := f.table.statics[rowKey.RowKey()]; static != nil && !staticColumns.empty() {
+				if err := static.delete(staticColumns.columns, staticColumns.elements); err != nil {
+					return err
+				}
+			}
+
+			row := f.table.rows[rowKey.RowKey()]
+			if row == nil || regularColumns.empty() {
+				continue
+			}
+
+			row.Ascend(func(item btree.Item) bool {
+				scol := item.(*superColumn)
+				if f.rowMatch(scol.Columns) {
+					err = scol.delete(regularColumns.columns, regularColumns.elements)
+				}
+				return err == nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deletions are the columns and collection elements deleted by a delete
+type deletions struct {
+	columns  []string
+	elements map[string]Modifier
+}
+
+func (d deletions) empty() bool {
+	return len(d.columns) == 0 && len(d.elements) == 0
+}
+
+// splitStaticDeletions splits the deletions into those of static columns and
+// the rest
+func (t *MockTable) splitStaticDeletions(columns []string, elements map[string]Modifier) (static, regular deletions) {
+	for _, column := range columns {
+		if containsField(t.staticColumns, column) {
+			static.columns = append(static.columns, column)
+		} else {
+			regular.columns = append(regular.columns, column)
+		}
+	}
+	for column, modifier := range elements {
+		d := &regular
+		if containsField(t.staticColumns, column) {
+			d = &static
+		}
+		if d.elements == nil {
+			d.elements = map[string]Modifier{}
+		}
+		d.elements[column] = modifier
+	}
+	return static, regular
+}
+
+// singleRowKeys returns the keys of the single row matched by the filter, as
+// lightweight transactions can't span more than one row
+func (f *MockFilter) singleRowKeys() (key, key, error) {
+	rowKeys, err := f.fieldsFromRelations(f.table.keys.PartitionKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	superColumnKeys, err := f.fieldsFromRelations(f.table.keys.ClusteringColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rowKeys) != 1 || len(superColumnKeys) != 1 {
+		return nil, nil, fmt.Errorf("IN on the primary key is not supported with conditional writes")
+	}
+	return rowKeys[0], superColumnKeys[0], nil
+}
+
+func (f *MockFilter) UpdateIf(conditions []Relation, m map[string]interface{}) Op {
+	if err := validateUpdate(m); err != nil {
+		return errOp{err: err}
+	}
+	return f.table.conditionalWriteOp(UpdateQuery, f.filterWrite(m), func(mock mockOp) error {
+		f.table.Lock()
+		defer f.table.Unlock()
+
+		rowKey, superColumnKey, err := f.singleRowKeys()
+		if err != nil {
+			return err
+		}
+
+		existing := f.table.getColumnGroup(rowKey, superColumnKey)
+		if existing == nil || !conditionsHold(existing.Columns, conditions) {
+			return NotAppliedError{Current: currentValues(existingColumns(existing))}
+		}
+		columns, err := f.table.normaliseSets(m)
+		if err != nil {
+			return err
+		}
+		return existing.write(columns, f.table.options.Merge(mock.options))
+	})
+}
+
+func (f *MockFilter) DeleteIf(conditions ...Relation) Op {
+	return f.deleteIf(conditions)
+}
+
+func (f *MockFilter) DeleteIfExists() Op {
+	return f.deleteIf(nil)
+}
+
+func (f *MockFilter) deleteIf(conditions []Relation) Op {
+	return f.table.conditionalWriteOp(DeleteQuery, f.filterWrite(nil), func(m mockOp) error {
+		f.table.Lock()
+		defer f.table.Unlock()
+
+		rowKey, superColumnKey, err := f.singleRowKeys()
+		if err != nil {
+			return err
+		}
+
+		existing := f.table.getColumnGroup(rowKey, superColumnKey)
+		if existing == nil || !conditionsHold(existing.Columns, conditions) {
+			return NotAppliedError{Current: currentValues(existingColumns(existing))}
+		}
+		f.table.deleteColumnGroup(rowKey, superColumnKey)
+		return nil
+	})
+}
+
+// conditionsHold checks whether the stored columns satisfy all the conditions
+// of a lightweight transaction
+func conditionsHold(columns map[string]interface{}, conditions []Relation) bool {
+	for _, condition := range conditions {
+		if !condition.accept(columns[condition.Field()]) {
+			return false
+		}
+	}
+	return true
+}
+
+// existingColumns returns the columns of a row, or nil if it doesn't exist
+func existingColumns(existing *superColumn) map[string]interface{} {
+	if existing == nil {
+		return nil
+	}
+	return existing.Columns
+}
+
+// currentValues copies the stored columns of a row keyed by lowercase column
+// name, which is how Cassandra reports them when a conditional write fails
+func currentValues(columns map[string]interface{}) map[string]interface{} {
+	current := make(map[string]interface{}, len(columns))
+	for k, v := range columns {
+		current[strings.ToLower(k)] = v
+	}
+	return current
+}
+
 func (q *MockFilter) Read(out interface{}) Op {
-	return newOp(func(m mockOp) error {
+	return newOp(func(m mockOp) (err error) {
+		start, rows := time.Now(), 0
+		defer func() { q.table.observe(ReadQuery, start, rows, err) }()
+
 		q.table.Lock()
 		defer q.table.Unlock()
 
-		var (
-			result []map[string]interface{}
-			err    error
-		)
+		result, stmt, err := q.read(m.options)
+		if err != nil {
+			return err
+		}
+
+		iter := newMockIterator(result, stmt.fields)
+		rows, err = NewScanner(stmt, out).ScanIter(iter)
+		return err
+	})
+}
 
-		switch {
-		case len(q.Relations()) == 0:
-			result = q.readAllRows()
-		default:
-			result, err = q.readSomeRows()
+// ReadPage emulates paging by using the offset of the first row of the next page as the page state
+func (q *MockFilter) ReadPage(pageSize int, pageState []byte, out interface{}, nextPageState *[]byte) Op {
+	if pageSize < 1 {
+		return errOp{err: fmt.Errorf("page size must be positive, got %d", pageSize)}
+	}
+	if nextPageState == nil {
+		return errOp{err: fmt.Errorf("nextPageState must not be nil, the state of the next page is stored in it")}
+	}
+
+	return newOp(func(m mockOp) (err error) {
+		start, rows := time.Now(), 0
+		defer func() { q.table.observe(ReadQuery, start, rows, err) }()
+
+		q.table.Lock()
+		defer q.table.Unlock()
+
+		offset := 0
+		if len(pageState) > 0 {
+			var err error
+			if offset, err = strconv.Atoi(string(pageState)); err != nil || offset < 0 {
+				return fmt.Errorf("invalid page state %q", pageState)
+			}
 		}
+
+		result, stmt, err := q.read(m.options)
 		if err != nil {
 			return err
 		}
 
-		opt := q.table.options.Merge(m.options)
-		if opt.Limit > 0 && opt.Limit < len(result) {
-			result = result[:opt.Limit]
+		*nextPageState = nil
+		if offset > len(result) {
+			offset = len(result)
 		}
-
-		fieldNames := opt.Select
-		if len(opt.Select) == 0 {
-			fieldNames = q.table.fields
+		result = result[offset:]
+		if len(result) > pageSize {
+			result = result[:pageSize]
+			*nextPageState = []byte(strconv.Itoa(offset + pageSize))
 		}
 
-		stmt := SelectStatement{keyspace: q.table.ksName, table: q.table.Name(), fields: fieldNames}
 		iter := newMockIterator(result, stmt.fields)
-		_, err = NewScanner(stmt, out).ScanIter(iter)
+		rows, err = NewScanner(stmt, out).ScanIter(iter)
 		return err
 	})
 }
 
+// Iter reads all the matching rows up front, then decodes them one at a time
+func (q *MockFilter) Iter() Iterator {
+	return q.iter(Options{})
+}
+
+func (q *MockFilter) IterWithContext(ctx context.Context) Iterator {
+	return q.iter(Options{Context: ctx})
+}
+
+func (q *MockFilter) iter(opts Options) Iterator {
+	q.table.Lock()
+	defer q.table.Unlock()
+
+	opts = q.table.options.Merge(opts)
+	result, stmt, err := q.read(opts)
+	if err != nil {
+		return errIterator(err)
+	}
+	return newIterator(opts.Context, stmt, newMockIterator(result, stmt.fields))
+}
+
+// read returns the rows matched by the filter, along with the statement which describes the selected fields
+func (q *MockFilter) read(options Options) ([]map[string]interface{}, SelectStatement, error) {
+	if err := q.table.refreshView(); err != nil {
+		return nil, SelectStatement{}, err
+	}
+
+	var (
+		result []map[string]interface{}
+		err    error
+	)
+
+	opt := q.table.options.Merge(options)
+	switch {
+	case len(q.Relations()) == 0:
+		result, err = q.readAllRows()
+	case !q.restrictsPartitionKey() && (opt.AllowFiltering || q.usesIndex(opt.Indexes)):
+		// Without the partition key every row needs to be checked, which
+		// Cassandra only allows with an index or when filtering is allowed
+		result, err = q.readAllRows()
+	default:
+		result, err = q.readSomeRows()
+	}
+	if err != nil {
+		return nil, SelectStatement{}, err
+	}
+
+	if opt.Limit > 0 && opt.Limit < len(result) {
+		result = result[:opt.Limit]
+	}
+
+	fieldNames := opt.Select
+	if len(opt.Select) == 0 {
+		fieldNames = q.table.fields
+	}
+
+	stmt := SelectStatement{keyspace: q.table.ksName, table: q.table.Name(), fields: fieldNames}
+	return result, stmt, nil
+}
+
 func (q *MockFilter) readSomeRows() ([]map[string]interface{}, error) {
 	q.table.mtx.RLock()
 	defer q.table.mtx.RUnlock()
@@ -658,34 +1759,32 @@ func (q *MockFilter) readSomeRows() ([]map[string]interface{}, error) {
 			continue
 		}
 
-		row.Ascend(func(item btree.Item) bool {
-			columns := item.(*superColumn).Columns
-			if q.rowMatch(columns) {
-				result = append(result, columns)
+		for _, r := range q.table.partitionRows(rowKey.RowKey(), row) {
+			if q.rowMatch(r) {
+				result = append(result, r)
 			}
-
-			return true
-		})
+		}
 	}
 
 	return result, nil
 }
 
-func (q *MockFilter) readAllRows() []map[string]interface{} {
-	q.table.mtx.RLock()
-	defer q.table.mtx.RUnlock()
+// readAllRows returns the matching rows of every partition. As in Cassandra,
+// partitions are read in token order, so paging through them is deterministic
+func (q *MockFilter) readAllRows() ([]map[string]interface{}, error) {
+	partitions, err := q.table.partitionsByToken()
+	if err != nil {
+		return nil, err
+	}
 	var result []map[string]interface{}
-	for _, row := range q.table.rows {
-		row.Ascend(func(item btree.Item) bool {
-			columns := item.(*superColumn).Columns
-			if q.rowMatch(columns) {
-				result = append(result, columns)
+	for _, p := range partitions {
+		for _, r := range p.rows {
+			if q.rowMatch(r) {
+				result = append(result, r)
 			}
-
-			return true
-		})
+		}
 	}
-	return result
+	return result, nil
 }
 
 func (q *MockFilter) ReadOne(out interface{}) Op {
@@ -772,6 +1871,10 @@ func (iter *mockIterator) Scan(dest ...interface{}) error {
 
 		// If it's a field to ignore, then ignore it ;)
 		rv := reflect.ValueOf(dest[i])
+		if udt, ok := dest[i].(*udtValue); ok {
+			// User-defined types are stored as the struct itself
+			rv = udt.v.Addr()
+		}
 		if rv.Elem().Type() == reflect.TypeOf((*IgnoreFieldType)(nil)).Elem() {
 			continue
 		}
@@ -917,6 +2020,35 @@ func assignRecords(m map[string]interface{}, record map[string]interface{}) erro
 					targetMap.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(v))
 				}
 				record[k] = targetMap.Interface()
+			case ModifierSetAdd, ModifierSetRemove:
+				values, ok := v.args[0].([]interface{})
+				if !ok {
+					return fmt.Errorf("Argument for set modifiers is not a slice")
+				}
+				set, err := updateSet(record[k], values, v.op == ModifierSetAdd)
+				if err != nil {
+					return err
+				}
+				record[k] = set
+			case ModifierMapRemoveKeys:
+				keys, ok := v.args[0].([]interface{})
+				if !ok {
+					return fmt.Errorf("Argument for MapRemoveKeys is not a slice")
+				}
+				if record[k] == nil {
+					continue
+				}
+				rv := reflect.ValueOf(record[k])
+				if rv.Kind() != reflect.Map {
+					return fmt.Errorf("Can't use MapRemoveKeys modifier on field that isn't a map: %T", record[k])
+				}
+				for _, key := range keys {
+					kv := reflect.ValueOf(key)
+					if !kv.IsValid() || !convertibleValue(kv, rv.Type().Key()) {
+						return fmt.Errorf("Can't remove key of type %T from %T", key, record[k])
+					}
+					rv.SetMapIndex(kv.Convert(rv.Type().Key()), reflect.Value{})
+				}
 			case ModifierCounterIncrement:
 				oldV, _ := record[k].(int64)
 				delta := int64(v.args[0].(int))
@@ -933,6 +2065,86 @@ func assignRecords(m map[string]interface{}, record map[string]interface{}) erro
 	return nil
 }
 
+// updateSet adds or removes values from a set, which the mock stores as a
+// slice. Like Cassandra the set is kept sorted and without duplicates
+func updateSet(set interface{}, values []interface{}, add bool) (interface{}, error) {
+	var rv reflect.Value
+	switch {
+	case set != nil:
+		rv = reflect.ValueOf(set)
+		if rv.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("Can't use set modifiers on field that isn't a slice: %T", set)
+		}
+	case add && len(values) > 0:
+		// We don't know the type, so we go by the values being added
+		rv = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(values[0])), 0, 0)
+	default:
+		return set, nil
+	}
+
+	elemType := rv.Type().Elem()
+	contains := func(elems []reflect.Value, v reflect.Value) bool {
+		for _, elem := range elems {
+			if reflect.DeepEqual(elem.Interface(), v.Interface()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	updates := make([]reflect.Value, 0, len(values))
+	for _, value := range values {
+		v := reflect.ValueOf(value)
+		if !v.IsValid() || !convertibleValue(v, elemType) {
+			return nil, fmt.Errorf("Can't use value of type %T with set of %v", value, elemType)
+		}
+		updates = append(updates, v.Convert(elemType))
+	}
+
+	elems := make([]reflect.Value, 0, rv.Len()+len(updates))
+	for i := 0; i < rv.Len(); i++ {
+		if elem := rv.Index(i); !contains(elems, elem) && (add || !contains(updates, elem)) {
+			elems = append(elems, elem)
+		}
+	}
+	if add {
+		for _, update := range updates {
+			if !contains(elems, update) {
+				elems = append(elems, update)
+			}
+		}
+	}
+	sort.SliceStable(elems, func(i, j int) bool {
+		return setElemLess(elems[i], elems[j])
+	})
+
+	result := reflect.MakeSlice(rv.Type(), len(elems), len(elems))
+	for i, elem := range elems {
+		result.Index(i).Set(elem)
+	}
+	return result.Interface(), nil
+}
+
+// convertibleValue returns whether the value can be converted to the type
+// without changing its meaning, unlike Go which converts integers to strings
+func convertibleValue(v reflect.Value, typ reflect.Type) bool {
+	return v.Type().ConvertibleTo(typ) && (v.Kind() == reflect.String) == (typ.Kind() == reflect.String)
+}
+
+func setElemLess(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.String:
+		return a.String() < b.String()
+	}
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
+
 type mockContextKey string
 
 var errorInjectorContextKey mockContextKey = "error_injector_context_key"
@@ -2,14 +2,21 @@ package gocassa
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"net"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gocql/gocql"
+	"github.com/google/btree"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"gopkg.in/inf.v0"
 )
 
 type user struct {
@@ -174,6 +181,157 @@ func (s *MockSuite) TestTableRead() {
 	s.NoError(op1.Add(op2).RunLoggedBatchWithContext(context.Background()))
 }
 
+func (s *MockSuite) TestTableReadPage() {
+	u1, _, u3, u4 := s.insertUsers()
+	filter := s.tbl.Where(Eq("Pk1", 1), Eq("Pk2", 1))
+
+	var users []user
+	var pageState []byte
+	s.NoError(filter.ReadPage(2, nil, &users, &pageState).Run())
+	s.Equal([]user{u1, u4}, users)
+	s.NotNil(pageState)
+
+	s.NoError(filter.ReadPage(2, pageState, &users, &pageState).Run())
+	s.Equal([]user{u3}, users)
+	s.Nil(pageState)
+
+	// Page size matching the number of results leaves no further pages
+	s.NoError(filter.ReadPage(3, nil, &users, &pageState).Run())
+	s.Equal([]user{u1, u4, u3}, users)
+	s.Nil(pageState)
+
+	s.Error(filter.ReadPage(0, nil, &users, &pageState).Run())
+	s.Error(filter.ReadPage(2, nil, &users, nil).Run())
+	s.Error(filter.ReadPage(2, []byte("garbage"), &users, &pageState).Run())
+}
+
+func (s *MockSuite) TestTableReadPageAcrossPartitions() {
+	for i := 0; i < 50; i++ {
+		s.NoError(s.mapTbl.Set(user{Pk1: i, Name: fmt.Sprint(i)}).Run())
+	}
+
+	// Every row is read once, whichever partitions the pages span
+	seen := map[int]bool{}
+	var pageState []byte
+	for pages := 0; pages == 0 || pageState != nil; pages++ {
+		var users []user
+		s.NoError(s.mapTbl.Table().Where().ReadPage(7, pageState, &users, &pageState).Run())
+		for _, u := range users {
+			s.False(seen[u.Pk1], "user %d read twice", u.Pk1)
+			seen[u.Pk1] = true
+		}
+	}
+	s.Len(seen, 50)
+}
+
+func (s *MockSuite) TestTableIter() {
+	u1, _, u3, u4 := s.insertUsers()
+
+	iter := s.tbl.Where(Eq("Pk1", 1), Eq("Pk2", 1)).Iter()
+	var users []user
+	var u user
+	for iter.Next(&u) {
+		users = append(users, u)
+	}
+	s.NoError(iter.Err())
+	s.NoError(iter.Close())
+	s.Equal([]user{u1, u4, u3}, users)
+
+	// Closing early stops the iteration
+	iter = s.tbl.Where(Eq("Pk1", 1), Eq("Pk2", 1)).Iter()
+	s.True(iter.Next(&u))
+	s.Equal(u1, u)
+	s.NoError(iter.Close())
+	s.False(iter.Next(&u))
+
+	// A cancelled context stops the iteration
+	ctx, cancel := context.WithCancel(context.Background())
+	iter = s.tbl.Where(Eq("Pk1", 1), Eq("Pk2", 1)).IterWithContext(ctx)
+	s.True(iter.Next(&u))
+	cancel()
+	s.False(iter.Next(&u))
+	s.Equal(context.Canceled, iter.Err())
+
+	// Decoding requires a pointer to a struct
+	iter = s.tbl.Where(Eq("Pk1", 1), Eq("Pk2", 1)).Iter()
+	s.False(iter.Next(u))
+	s.Error(iter.Close())
+}
+
+func (s *MockSuite) TestTableScan() {
+	s.insertUsers()
+	var allUsers []user
+	s.NoError(s.tbl.Where().Read(&allUsers).Run())
+
+	var mtx sync.Mutex
+	var users []user
+	err := s.tbl.Scan(context.Background(), ScanOptions{Splits: 16, Concurrency: 4}, func(row interface{}) error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		users = append(users, *row.(*user))
+		return nil
+	})
+	s.NoError(err)
+	s.ElementsMatch(allUsers, users)
+
+	// Errors from the callback stop the scan
+	scanErr := errors.New("scan error")
+	err = s.tbl.Scan(context.Background(), ScanOptions{}, func(row interface{}) error {
+		return scanErr
+	})
+	s.Equal(scanErr, err)
+}
+
+func (s *MockSuite) TestTableReadByIndex() {
+	u1, u2, _, _ := s.insertUsers()
+
+	// Without an index the partition key is required
+	var users []user
+	s.Error(s.tbl.Where(Eq("Name", "John")).Read(&users).Run())
+
+	indexed := s.tbl.WithOptions(Options{Indexes: []Index{{Column: "Name"}}})
+	s.NoError(indexed.Where(Eq("Name", "John")).Read(&users).Run())
+	s.Equal([]user{u1}, users)
+
+	s.NoError(s.tbl.Where(Eq("Name", "Joe")).Read(&users).WithOptions(Options{AllowFiltering: true}).Run())
+	s.Equal([]user{u2}, users)
+}
+
+func (s *MockSuite) TestContainsRelations() {
+	type article struct {
+		Id     string
+		Tags   []string
+		Labels map[string]string
+	}
+	tbl := s.ks.Table("articles", article{}, Keys{PartitionKeys: []string{"Id"}})
+	a1 := article{Id: "1", Tags: []string{"go", "cql"}, Labels: map[string]string{"lang": "en"}}
+	a2 := article{Id: "2", Tags: []string{"rust"}, Labels: map[string]string{"draft": "yes"}}
+	s.NoError(tbl.Set(a1).Add(tbl.Set(a2)).Run())
+
+	// Without an index or filtering the partition key is required
+	var articles []article
+	s.Error(tbl.Where(Contains("Tags", "go")).Read(&articles).Run())
+	s.NoError(tbl.Where(Eq("Id", "1"), Contains("Tags", "go")).Read(&articles).Run())
+	s.Equal([]article{a1}, articles)
+	s.NoError(tbl.Where(Eq("Id", "2"), Contains("Tags", "go")).Read(&articles).Run())
+	s.Empty(articles)
+
+	indexed := tbl.WithOptions(Options{Indexes: []Index{{Column: "Tags"}, {Column: "Labels", Keys: true}}})
+	s.NoError(indexed.Where(Contains("Tags", "rust")).Read(&articles).Run())
+	s.Equal([]article{a2}, articles)
+	s.NoError(indexed.Where(ContainsKey("Labels", "draft")).Read(&articles).Run())
+	s.Equal([]article{a2}, articles)
+	// The values of Labels aren't indexed, only its keys
+	s.Error(indexed.Where(Contains("Labels", "en")).Read(&articles).Run())
+
+	s.NoError(tbl.Where(Contains("Labels", "en")).Read(&articles).
+		WithOptions(Options{AllowFiltering: true}).Run())
+	s.Equal([]article{a1}, articles)
+	s.NoError(tbl.Where(ContainsKey("Labels", "en")).Read(&articles).
+		WithOptions(Options{AllowFiltering: true}).Run())
+	s.Empty(articles)
+}
+
 func (s *MockSuite) TestTableUpdate() {
 	s.insertUsers()
 
@@ -257,6 +415,273 @@ func (s *MockSuite) TestMapTableDelete() {
 	s.Equal(RowNotFoundError{}, s.mapTbl.Read(1, &user).Run())
 }
 
+func (s *MockSuite) TestWriteTimestamps() {
+	type timestampedUser struct {
+		Pk1         int
+		Name        string
+		NameWritten int64 `cql:"name,writetime"`
+		NameTTL     int   `cql:"name,ttl"`
+	}
+	tbl := s.ks.MapTable("timestamped_users", "Pk1", timestampedUser{})
+	written := time.Unix(1600000000, 0)
+
+	s.NoError(tbl.Set(timestampedUser{Pk1: 1, Name: "new"}).WithOptions(Options{Timestamp: written}).Run())
+	// An earlier write doesn't overwrite a later one, whichever runs last
+	s.NoError(tbl.Set(timestampedUser{Pk1: 1, Name: "old"}).WithOptions(Options{Timestamp: written.Add(-time.Second)}).Run())
+	s.NoError(tbl.Update(1, map[string]interface{}{"Name": "old"}).WithOptions(Options{Timestamp: written.Add(-time.Second)}).Run())
+
+	var u timestampedUser
+	s.NoError(tbl.Read(1, &u).Run())
+	s.Equal("new", u.Name)
+	s.Equal(written.UnixNano()/1000, u.NameWritten)
+	s.Equal(0, u.NameTTL)
+
+	s.NoError(tbl.WithOptions(Options{TTL: time.Hour}).Update(1, map[string]interface{}{"Name": "newer"}).Run())
+	s.NoError(tbl.Read(1, &u).Run())
+	s.Equal("newer", u.Name)
+	s.True(u.NameWritten > written.UnixNano()/1000)
+	s.InDelta(3600, u.NameTTL, 1)
+
+	// Conditional writes can't be given a custom timestamp
+	timestamped := Options{Timestamp: written}
+	s.Equal(errConditionalTimestamp, tbl.SetIfNotExists(timestampedUser{Pk1: 2}).WithOptions(timestamped).Run())
+	s.Equal(errConditionalTimestamp, tbl.UpdateIf(1, nil, map[string]interface{}{"Name": "old"}).WithOptions(timestamped).Run())
+	s.Equal(errConditionalTimestamp, tbl.WithOptions(timestamped).DeleteIfExists(1).Run())
+	s.NoError(tbl.Read(1, &u).Run())
+	s.Equal("newer", u.Name)
+}
+
+func (s *MockSuite) TestDeleteColumns() {
+	type profile struct {
+		Id     string
+		Name   string
+		Tags   []string
+		Scores map[string]int
+	}
+	tbl := s.ks.MapTable("profiles", "Id", profile{})
+	s.NoError(tbl.Set(profile{
+		Id:     "1",
+		Name:   "jane",
+		Tags:   []string{"a", "b", "c"},
+		Scores: map[string]int{"x": 1, "y": 2},
+	}).Run())
+
+	filter := tbl.Table().Where(Eq("Id", "1"))
+	s.NoError(filter.DeleteElements(map[string]Modifier{
+		"Tags":   ListDeleteAtIndex(1),
+		"Scores": MapDeleteKey("x"),
+	}).Run())
+	var p profile
+	s.NoError(tbl.Read("1", &p).Run())
+	s.Equal([]string{"a", "c"}, p.Tags)
+	s.Equal(map[string]int{"y": 2}, p.Scores)
+
+	s.EqualError(filter.DeleteElements(map[string]Modifier{"Tags": ListDeleteAtIndex(5)}).Run(),
+		"List index 5 out of bound, list has size 2")
+
+	s.NoError(filter.DeleteColumns("name", "Tags").Run())
+	p = profile{}
+	s.NoError(tbl.Read("1", &p).Run())
+	s.Empty(p.Name)
+	s.Empty(p.Tags)
+	s.Equal(map[string]int{"y": 2}, p.Scores)
+
+	// The deletion shadows writes with an earlier timestamp
+	s.NoError(tbl.Update("1", map[string]interface{}{"Name": "old"}).
+		WithOptions(Options{Timestamp: time.Now().Add(-time.Hour)}).Run())
+	s.NoError(tbl.Read("1", &p).Run())
+	s.Empty(p.Name)
+
+	s.Error(filter.DeleteColumns("Id").Run())
+	s.EqualError(filter.Update(map[string]interface{}{"Tags": ListDeleteAtIndex(0)}).Run(),
+		"modifier of column Tags deletes an element, use DeleteElements instead")
+}
+
+func (s *MockSuite) TestStaticColumns() {
+	type member struct {
+		Group string
+		Id    string
+		Owner string `cql:"owner,static"`
+		Name  string
+	}
+	tbl := s.ks.Table("members", member{}, Keys{
+		PartitionKeys:     []string{"Group"},
+		ClusteringColumns: []string{"Id"},
+	})
+	s.NoError(tbl.Set(member{Group: "g1", Id: "1", Owner: "jane", Name: "a"}).Run())
+	s.NoError(tbl.Set(member{Group: "g1", Id: "2", Owner: "john", Name: "b"}).Run())
+
+	// The static column is shared by every row of the partition
+	var members []member
+	s.NoError(tbl.Where(Eq("Group", "g1")).Read(&members).Run())
+	s.Equal([]member{
+		{Group: "g1", Id: "1", Owner: "john", Name: "a"},
+		{Group: "g1", Id: "2", Owner: "john", Name: "b"},
+	}, members)
+
+	// and can be updated without the clustering columns
+	s.NoError(tbl.Where(Eq("Group", "g1")).Update(map[string]interface{}{"owner": "jim"}).Run())
+	s.NoError(tbl.Where(Eq("Group", "g1"), Eq("Id", "2")).Read(&members).Run())
+	s.Equal([]member{{Group: "g1", Id: "2", Owner: "jim", Name: "b"}}, members)
+
+	// A partition with only static columns is read as a single row
+	s.NoError(tbl.Where(Eq("Group", "g2")).Update(map[string]interface{}{"owner": "jill"}).Run())
+	s.NoError(tbl.Where(Eq("Group", "g2")).Read(&members).Run())
+	s.Equal([]member{{Group: "g2", Owner: "jill"}}, members)
+
+	// Deleting a row leaves the static columns, deleting the partition doesn't
+	s.NoError(tbl.Where(Eq("Group", "g1"), Eq("Id", "1")).Delete().Run())
+	s.NoError(tbl.Where(Eq("Group", "g1")).Read(&members).Run())
+	s.Equal([]member{{Group: "g1", Id: "2", Owner: "jim", Name: "b"}}, members)
+	s.NoError(tbl.Where(Eq("Group", "g1")).DeleteColumns("owner").Run())
+	s.NoError(tbl.Where(Eq("Group", "g1")).Read(&members).Run())
+	s.Equal([]member{{Group: "g1", Id: "2", Name: "b"}}, members)
+	s.NoError(tbl.Where(Eq("Group", "g2")).Delete().Run())
+	s.NoError(tbl.Where(Eq("Group", "g2")).Read(&members).Run())
+	s.Empty(members)
+}
+
+func (s *MockSuite) TestTupleRelations() {
+	type event struct {
+		Stream string
+		Day    int
+		Seq    int
+	}
+	tbl := s.ks.Table("events", event{}, Keys{
+		PartitionKeys:     []string{"Stream"},
+		ClusteringColumns: []string{"Day", "Seq"},
+	})
+	for _, e := range []event{{"s", 1, 1}, {"s", 1, 2}, {"s", 2, 1}, {"s", 2, 2}} {
+		s.NoError(tbl.Set(e).Run())
+	}
+
+	// Paging from the last row read, which independent relations per
+	// column can't express
+	var events []event
+	s.NoError(tbl.Where(Eq("Stream", "s"), GTTuple([]string{"Day", "Seq"}, []interface{}{1, 2})).
+		Read(&events).Run())
+	s.Equal([]event{{"s", 2, 1}, {"s", 2, 2}}, events)
+
+	s.NoError(tbl.Where(Eq("Stream", "s"), GTETuple([]string{"Day", "Seq"}, []interface{}{1, 2}),
+		LTTuple([]string{"Day", "Seq"}, []interface{}{2, 2})).Read(&events).Run())
+	s.Equal([]event{{"s", 1, 2}, {"s", 2, 1}}, events)
+
+	s.NoError(tbl.Where(Eq("Stream", "s"), InTuple([]string{"Day", "Seq"},
+		[]interface{}{1, 1}, []interface{}{2, 2}, []interface{}{3, 3})).Read(&events).Run())
+	s.Equal([]event{{"s", 1, 1}, {"s", 2, 2}}, events)
+}
+
+func (s *MockSuite) TestMapTableConditionalWrites() {
+	u1 := user{Pk1: 1, Pk2: 2, Name: "John"}
+	s.NoError(s.mapTbl.SetIfNotExists(u1).Run())
+
+	err := s.mapTbl.SetIfNotExists(user{Pk1: 1, Pk2: 3, Name: "Jane"}).Run()
+	var notApplied NotAppliedError
+	s.True(errors.As(err, &notApplied))
+	s.Equal("John", notApplied.Current["name"])
+	s.Equal(2, notApplied.Current["pk2"])
+
+	err = s.mapTbl.UpdateIf(1, []Relation{Eq("Name", "Jane")}, map[string]interface{}{"Name": "Joe"}).Run()
+	s.True(errors.As(err, &notApplied))
+	s.Equal("John", notApplied.Current["name"])
+
+	s.NoError(s.mapTbl.UpdateIf(1, []Relation{Eq("Name", "John")}, map[string]interface{}{"Name": "Joe"}).Run())
+	var res user
+	s.NoError(s.mapTbl.Read(1, &res).Run())
+	s.Equal("Joe", res.Name)
+
+	err = s.mapTbl.UpdateIf(5, []Relation{Eq("Name", "John")}, map[string]interface{}{"Name": "Joe"}).Run()
+	s.True(errors.As(err, &notApplied))
+	s.Empty(notApplied.Current)
+
+	s.NoError(s.mapTbl.DeleteIfExists(1).Run())
+	s.IsType(RowNotFoundError{}, s.mapTbl.Read(1, &res).Run())
+	s.IsType(NotAppliedError{}, s.mapTbl.DeleteIfExists(1).Run())
+}
+
+func (s *MockSuite) TestRecipeConditionalWrites() {
+	p := point{Time: s.parseTime("2015-01-01 00:00:00"), Id: 1, User: "John", X: 1, Y: 2}
+	update := map[string]interface{}{"X": 3.0}
+
+	s.NoError(s.tsTbl.SetIfNotExists(p).Run())
+	s.IsType(NotAppliedError{}, s.tsTbl.SetIfNotExists(p).Run())
+	s.IsType(NotAppliedError{}, s.tsTbl.UpdateIf(p.Time, p.Id, []Relation{Eq("X", 2.0)}, update).Run())
+	s.NoError(s.tsTbl.UpdateIf(p.Time, p.Id, []Relation{Eq("X", 1.0)}, update).Run())
+	s.NoError(s.tsTbl.DeleteIfExists(p.Time, p.Id).Run())
+	s.IsType(NotAppliedError{}, s.tsTbl.DeleteIfExists(p.Time, p.Id).Run())
+
+	s.NoError(s.mtsTbl.SetIfNotExists(p).Run())
+	s.IsType(NotAppliedError{}, s.mtsTbl.SetIfNotExists(p).Run())
+	s.IsType(NotAppliedError{}, s.mtsTbl.UpdateIf(p.User, p.Time, p.Id, []Relation{Eq("X", 2.0)}, update).Run())
+	s.NoError(s.mtsTbl.UpdateIf(p.User, p.Time, p.Id, []Relation{Eq("X", 1.0)}, update).Run())
+	s.NoError(s.mtsTbl.DeleteIfExists(p.User, p.Time, p.Id).Run())
+	s.IsType(NotAppliedError{}, s.mtsTbl.DeleteIfExists(p.User, p.Time, p.Id).Run())
+
+	fields := map[string]interface{}{"X": p.X, "Y": p.Y}
+	ids := map[string]interface{}{"Id": p.Id}
+	s.NoError(s.mkTsTbl.SetIfNotExists(p).Run())
+	s.IsType(NotAppliedError{}, s.mkTsTbl.SetIfNotExists(p).Run())
+	s.IsType(NotAppliedError{}, s.mkTsTbl.UpdateIf(fields, p.Time, ids, []Relation{Eq("User", "Jane")}, update).Run())
+	s.NoError(s.mkTsTbl.UpdateIf(fields, p.Time, ids, []Relation{Eq("User", "John")}, map[string]interface{}{"User": "Jane"}).Run())
+	s.NoError(s.mkTsTbl.DeleteIfExists(fields, p.Time, ids).Run())
+	s.IsType(NotAppliedError{}, s.mkTsTbl.DeleteIfExists(fields, p.Time, ids).Run())
+
+	add := address{Id: "1", TownID: "2", County: "London", PostCode: "N1"}
+	fields = map[string]interface{}{"Id": add.Id, "TownID": add.TownID}
+	ids = map[string]interface{}{"County": add.County}
+	s.NoError(s.mmMkTable.SetIfNotExists(add).Run())
+	s.IsType(NotAppliedError{}, s.mmMkTable.SetIfNotExists(add).Run())
+	s.IsType(NotAppliedError{}, s.mmMkTable.UpdateIf(fields, ids, []Relation{Eq("PostCode", "E1")},
+		map[string]interface{}{"PostCode": "E2"}).Run())
+	s.NoError(s.mmMkTable.UpdateIf(fields, ids, []Relation{Eq("PostCode", "N1")},
+		map[string]interface{}{"PostCode": "E2"}).Run())
+	var res address
+	s.NoError(s.mmMkTable.Read(fields, ids, &res).Run())
+	s.Equal(PostalCode("E2"), res.PostCode)
+	s.NoError(s.mmMkTable.DeleteIfExists(fields, ids).Run())
+	s.IsType(NotAppliedError{}, s.mmMkTable.DeleteIfExists(fields, ids).Run())
+}
+
+func (s *MockSuite) TestFlakeSeriesConditionalWrites() {
+	tbl := s.ks.FlakeSeriesTable("trips", "Id", time.Minute, Trip{})
+	mtbl := s.ks.MultiFlakeSeriesTable("trips_by_tag", "Tag", "Id", time.Minute, TripB{})
+	trip := Trip{Id: timeToFlake(s.T(), "2006 Jan 2 15:04:00"), Time: s.parseTime("2015-01-01 00:00:00")}
+	tripB := TripB{Id: trip.Id, Time: trip.Time, Tag: "tag"}
+	update := map[string]interface{}{"Time": s.parseTime("2015-01-02 00:00:00")}
+
+	s.NoError(tbl.SetIfNotExists(trip).Run())
+	s.IsType(NotAppliedError{}, tbl.SetIfNotExists(trip).Run())
+	s.IsType(NotAppliedError{}, tbl.UpdateIf(trip.Id, []Relation{Eq("Time", update["Time"])}, update).Run())
+	s.NoError(tbl.UpdateIf(trip.Id, []Relation{Eq("Time", trip.Time)}, update).Run())
+	s.NoError(tbl.DeleteIfExists(trip.Id).Run())
+	s.IsType(NotAppliedError{}, tbl.DeleteIfExists(trip.Id).Run())
+	s.Error(tbl.DeleteIfExists("not a flake").Run())
+
+	s.NoError(mtbl.SetIfNotExists(tripB).Run())
+	s.IsType(NotAppliedError{}, mtbl.SetIfNotExists(tripB).Run())
+	s.IsType(NotAppliedError{}, mtbl.UpdateIf(tripB.Tag, tripB.Id, []Relation{Eq("Time", update["Time"])}, update).Run())
+	s.NoError(mtbl.UpdateIf(tripB.Tag, tripB.Id, []Relation{Eq("Time", tripB.Time)}, update).Run())
+	s.NoError(mtbl.DeleteIfExists(tripB.Tag, tripB.Id).Run())
+	s.IsType(NotAppliedError{}, mtbl.DeleteIfExists(tripB.Tag, tripB.Id).Run())
+	s.Error(mtbl.UpdateIf(tripB.Tag, "not a flake", nil, update).Run())
+}
+
+func (s *MockSuite) TestTableConditionalDelete() {
+	u1, _, _, _ := s.insertUsers()
+	filter := s.tbl.Where(Eq("Pk1", u1.Pk1), Eq("Pk2", u1.Pk2), Eq("Ck1", u1.Ck1), Eq("Ck2", u1.Ck2))
+
+	s.IsType(NotAppliedError{}, filter.DeleteIf(Eq("Name", "Nobody")).Run())
+	var res []user
+	s.NoError(filter.Read(&res).Run())
+	s.Len(res, 1)
+
+	s.NoError(filter.DeleteIf(Eq("Name", u1.Name)).Run())
+	s.NoError(filter.Read(&res).Run())
+	s.Len(res, 0)
+
+	s.Error(s.tbl.Where(Eq("Pk1", u1.Pk1), Eq("Pk2", u1.Pk2), Eq("Ck1", u1.Ck1), In("Ck2", 1, 2)).DeleteIfExists().Run())
+}
+
 func (s *MockSuite) TestMapModifiers() {
 	tbl := s.ks.MapTable("user342135", "Id", UserWithMap{})
 	createIf(tbl.(TableChanger), s.T())
@@ -317,6 +742,106 @@ func (s *MockSuite) TestMapModifiers() {
 	}
 }
 
+type userWithSets struct {
+	Id     string
+	Tags   []string `cql:"tags,set"`
+	Scores map[string]int
+}
+
+func (s *MockSuite) TestSetModifiers() {
+	tbl := s.ks.MapTable("user_sets", "Id", userWithSets{})
+	s.NoError(tbl.Set(userWithSets{
+		Id:     "1",
+		Tags:   []string{"b"},
+		Scores: map[string]int{"x": 1, "y": 2, "z": 3},
+	}).Run())
+
+	s.NoError(tbl.Update("1", map[string]interface{}{
+		"tags":   SetAdd("c", "a", "b"),
+		"Scores": MapRemoveKeys("x", "z", "missing"),
+	}).Run())
+
+	var u userWithSets
+	s.NoError(tbl.Read("1", &u).Run())
+	s.Equal([]string{"a", "b", "c"}, u.Tags)
+	s.Equal(map[string]int{"y": 2}, u.Scores)
+
+	s.NoError(tbl.Update("1", map[string]interface{}{"tags": SetRemove("b", "missing")}).Run())
+	s.NoError(tbl.Read("1", &u).Run())
+	s.Equal([]string{"a", "c"}, u.Tags)
+
+	// Adding to a set which doesn't exist yet creates it
+	s.NoError(tbl.Set(userWithSets{Id: "2"}).Run())
+	s.NoError(tbl.Update("2", map[string]interface{}{"tags": SetAdd("x")}).Run())
+	s.NoError(tbl.Read("2", &u).Run())
+	s.Equal([]string{"x"}, u.Tags)
+
+	s.Error(tbl.Update("2", map[string]interface{}{"tags": SetAdd(1)}).Run())
+	s.Error(tbl.Update("2", map[string]interface{}{"Id": MapRemoveKeys("x")}).Run())
+}
+
+func (s *MockSuite) TestSetsNormalised() {
+	// Sets are written sorted and without duplicates, as Cassandra stores them
+	tbl := s.ks.MapTable("user_sets", "Id", userWithSets{})
+	tags := []string{"c", "a", "c", "b"}
+	s.NoError(tbl.Set(userWithSets{Id: "1", Tags: tags}).Run())
+	s.Equal([]string{"c", "a", "c", "b"}, tags)
+
+	var u userWithSets
+	s.NoError(tbl.Read("1", &u).Run())
+	s.Equal([]string{"a", "b", "c"}, u.Tags)
+
+	s.NoError(tbl.Update("1", map[string]interface{}{"tags": []string{"z", "y", "z"}}).Run())
+	s.NoError(tbl.Read("1", &u).Run())
+	s.Equal([]string{"y", "z"}, u.Tags)
+
+	s.NoError(tbl.Table().Where(Eq("Id", "1")).
+		UpdateIf([]Relation{Eq("Id", "1")}, map[string]interface{}{"tags": []string{"b", "b"}}).Run())
+	s.NoError(tbl.Read("1", &u).Run())
+	s.Equal([]string{"b"}, u.Tags)
+}
+
+func (s *MockSuite) TestScalarTypes() {
+	row := scalarTypes{
+		Id:       gocql.TimeUUID(),
+		Tiny:     -8,
+		Small:    16,
+		Int:      32,
+		Unsigned: 64,
+		Varint:   big.NewInt(1 << 40),
+		Decimal:  inf.NewDec(12345, 2),
+		IP:       net.ParseIP("10.0.0.1"),
+		Duration: gocql.Duration{Months: 1, Days: 2, Nanoseconds: 3},
+		Date:     time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		Time:     3 * time.Hour,
+		Ascii:    "abc",
+		Temp:     21.5,
+	}
+
+	tbl := s.ks.MapTable("scalars", "id", scalarTypes{})
+	s.NoError(tbl.Set(row).Run())
+	var result scalarTypes
+	s.NoError(tbl.Read(row.Id, &result).Run())
+	s.Equal(row, result)
+
+	// Each type can also be used as a key
+	for key, value := range map[string]interface{}{
+		"tiny":    row.Tiny,
+		"small":   row.Small,
+		"varint":  row.Varint,
+		"decimal": row.Decimal,
+		"ip":      row.IP,
+		"date":    row.Date,
+		"time":    row.Time,
+	} {
+		tbl := s.ks.MapTable("scalars_by_"+key, key, scalarTypes{})
+		s.NoError(tbl.Set(row).Run(), key)
+		var result scalarTypes
+		s.NoError(tbl.Read(value, &result).Run(), key)
+		s.Equal(row, result, key)
+	}
+}
+
 // MultiMapTable tests
 func (s *MockSuite) TestMultiMapTableRead() {
 	s.insertUsers()
@@ -349,6 +874,22 @@ func (s *MockSuite) TestMultiMapTableList() {
 	s.Equal("Joe", users[0].Name)
 }
 
+func (s *MockSuite) TestMultiMapTableListPage() {
+	s.insertUsers()
+
+	var users []user
+	var pageState []byte
+	s.NoError(s.mmapTbl.ListPage(1, 1, nil, &users, &pageState).Run())
+	s.Len(users, 1)
+	s.Equal("Jane", users[0].Name)
+	s.NotNil(pageState)
+
+	s.NoError(s.mmapTbl.ListPage(1, 1, pageState, &users, &pageState).Run())
+	s.Len(users, 1)
+	s.Equal("Joe", users[0].Name)
+	s.Nil(pageState)
+}
+
 func (s *MockSuite) TestMultiMapTableUpdate() {
 	s.insertUsers()
 
@@ -400,6 +941,20 @@ func (s *MockSuite) TestTimeSeriesTableList() {
 	s.Equal(points[2], ps[1])
 }
 
+func (s *MockSuite) TestTimeSeriesTableListIter() {
+	points := s.insertPoints()
+
+	iter := s.tsTbl.ListIter(points[0].Time, points[1].Time)
+	defer iter.Close()
+	var ps []point
+	var p point
+	for iter.Next(&p) {
+		ps = append(ps, p)
+	}
+	s.NoError(iter.Err())
+	s.Equal([]point{points[0], points[1]}, ps)
+}
+
 func (s *MockSuite) TestWithOptions() {
 	points := s.insertPoints()
 	var ps []point
@@ -503,6 +1058,137 @@ func (s *MockSuite) TestMultiKeyTimeSeriesTableList() {
 	s.Equal(points[1], ps[0])
 }
 
+func (s *MockSuite) TestCounterTable() {
+	ct := s.ks.CounterTable("views", []string{"Site"}, []string{"Page"}, pageViews{})
+	home := map[string]interface{}{"Site": "monzo.com", "Page": "/"}
+	about := map[string]interface{}{"Site": "monzo.com", "Page": "/about"}
+
+	s.NoError(ct.Increment(home, "Views", 3).Run())
+	s.NoError(ct.Increment(home, "Users", 1).Run())
+	s.NoError(ct.Decrement(home, "Views", 1).Run())
+	s.NoError(ct.Increment(about, "Views", 1).Run())
+
+	var views pageViews
+	s.NoError(ct.Get(home, &views).Run())
+	s.Equal(pageViews{Site: "monzo.com", Page: "/", Views: 2, Users: 1}, views)
+
+	var allViews []pageViews
+	s.NoError(ct.List(map[string]interface{}{"Site": "monzo.com"}, &allViews).Run())
+	s.Equal([]pageViews{
+		{Site: "monzo.com", Page: "/", Views: 2, Users: 1},
+		{Site: "monzo.com", Page: "/about", Views: 1},
+	}, allViews)
+
+	s.NoError(ct.Reset(home).Run())
+	s.Equal(RowNotFoundError{}, ct.Get(home, &views).Run())
+}
+
+func (s *MockSuite) TestMaterializedView() {
+	base := s.ks.MapTable("users", "Pk1", user{})
+	view := s.ks.MaterializedView(base.Table(), "users_by_name", Keys{
+		PartitionKeys:     []string{"Name"},
+		ClusteringColumns: []string{"Pk1"},
+	})
+
+	s.NoError(base.Set(user{Pk1: 1, Name: "Jane"}).Run())
+	s.NoError(base.Set(user{Pk1: 2, Name: "Jane"}).Run())
+	s.NoError(base.Set(user{Pk1: 3, Name: "Joe"}).Run())
+
+	var users []user
+	s.NoError(view.Where(Eq("Name", "Jane")).Read(&users).Run())
+	s.Equal([]user{{Pk1: 1, Name: "Jane"}, {Pk1: 2, Name: "Jane"}}, users)
+
+	// Base writes are reflected in the view
+	s.NoError(base.Update(2, map[string]interface{}{"Name": "Joe"}).Run())
+	s.NoError(base.Delete(3).Run())
+	s.NoError(view.Where(Eq("Name", "Jane")).Read(&users).Run())
+	s.Equal([]user{{Pk1: 1, Name: "Jane"}}, users)
+	s.NoError(view.Where(Eq("Name", "Joe")).Read(&users).Run())
+	s.Equal([]user{{Pk1: 2, Name: "Joe"}}, users)
+
+	// The view is read-only
+	op := view.Set(user{Pk1: 4, Name: "Jill"})
+	s.Error(op.Preflight())
+	s.Error(op.Run())
+	s.Error(view.Where(Eq("Name", "Joe"), Eq("Pk1", 2)).Delete().Run())
+
+	// The view holds copies of the base rows, so base writes don't change
+	// it until it's read again
+	viewTimestamps := func() map[string]int64 {
+		var timestamps map[string]int64
+		for _, row := range view.(*MockTable).rows {
+			row.Ascend(func(item btree.Item) bool {
+				if scol := item.(*superColumn); scol.Columns["Pk1"] == 1 {
+					timestamps = scol.Timestamps
+				}
+				return true
+			})
+		}
+		return timestamps
+	}
+	before := map[string]int64{}
+	for k, ts := range viewTimestamps() {
+		before[k] = ts
+	}
+	s.NoError(base.Update(1, map[string]interface{}{"Pk2": 5}).
+		WithOptions(Options{Timestamp: time.Now().Add(time.Hour)}).Run())
+	s.Equal(before, viewTimestamps())
+}
+
+func (s *MockSuite) TestMaterializedViewStaticColumns() {
+	type member struct {
+		Group string
+		Id    string
+		Owner string `cql:"owner,static"`
+	}
+	base := s.ks.Table("members", member{}, Keys{
+		PartitionKeys:     []string{"Group"},
+		ClusteringColumns: []string{"Id"},
+	})
+	view := s.ks.MaterializedView(base, "members_by_id", Keys{
+		PartitionKeys:     []string{"Id"},
+		ClusteringColumns: []string{"Group"},
+	})
+	s.NoError(base.Set(member{Group: "g1", Id: "1", Owner: "jane"}).Run())
+	s.NoError(base.Set(member{Group: "g1", Id: "2", Owner: "john"}).Run())
+
+	var members []member
+	s.NoError(view.Where(Eq("Id", "1")).Read(&members).Run())
+	s.Equal([]member{{Group: "g1", Id: "1", Owner: "john"}}, members)
+}
+
+func (s *MockSuite) TestUserDefinedTypes() {
+	tbl := s.ks.MapTable("customers", "Id", customerWithAddress{})
+	s.NoError(tbl.Set(customerWithAddress{Id: "1", Address: postalAddress{
+		Street:   "1 Main St",
+		Location: &geoPoint{Lat: 51.5, Lng: -0.1},
+	}}).Run())
+	s.NoError(tbl.Set(customerWithAddress{Id: "2"}).Run())
+
+	var customer customerWithAddress
+	s.NoError(tbl.Read("1", &customer).Run())
+	s.Equal("1 Main St", customer.Address.Street)
+	s.Equal(&geoPoint{Lat: 51.5, Lng: -0.1}, customer.Address.Location)
+
+	var customers []customerWithAddress
+	s.NoError(tbl.MultiRead([]interface{}{"1", "2"}, &customers).Run())
+	s.Len(customers, 2)
+	s.Equal(customer, customers[0])
+	s.Equal(postalAddress{}, customers[1].Address)
+
+	s.NoError(tbl.Update("2", map[string]interface{}{"address": postalAddress{Street: "2 High St"}}).Run())
+	s.NoError(tbl.Read("2", &customer).Run())
+	s.Equal(postalAddress{Street: "2 High St"}, customer.Address)
+}
+
+func (s *MockSuite) TestSchemaDiff() {
+	tbl := s.mapTbl.Table()
+	diff, err := tbl.SchemaDiff(context.Background())
+	s.NoError(err)
+	s.True(diff.Empty())
+	s.NoError(tbl.Migrate(context.Background(), MigrateOptions{AllowDrop: true}))
+}
+
 func (s *MockSuite) TestNoop() {
 	s.insertUsers()
 	var users []user
@@ -875,6 +1561,30 @@ func TestErrorInjectors(t *testing.T) {
 			assert.Equal(t, thing, readThing)
 		}
 	})
+
+	t.Run("RunConcurrently", func(t *testing.T) {
+		ks := NewMockKeySpace()
+		table := ks.MapTable("table_name", "ID", Thing{})
+
+		op := Noop()
+		for _, thing := range things {
+			op = op.Add(table.Set(thing))
+		}
+		ctx := ErrorInjectorContext(context.Background(), FailOnNthOperation(1, errToInject))
+		assert.Equal(t, errToInject, op.RunConcurrently(ctx, 2))
+
+		ctx = ErrorInjectorContext(context.Background(), FailOnNthOperation(1, errToInject))
+		err := op.WithOptions(Options{CollectErrors: true}).RunConcurrently(ctx, 2)
+		assert.Equal(t, MultiError{errToInject}, err)
+		for _, thing := range []Thing{things[0], things[2]} {
+			readThing := Thing{}
+			require.NoError(t, table.Read(thing.ID, &readThing).RunWithContext(context.Background()))
+			assert.Equal(t, thing, readThing)
+		}
+		assert.IsType(t, RowNotFoundError{}, table.Read(things[1].ID, &Thing{}).RunWithContext(context.Background()))
+
+		require.NoError(t, op.RunConcurrently(context.Background(), 0))
+	})
 }
 
 func TestMockClusteringOrder(t *testing.T) {
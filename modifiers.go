@@ -14,13 +14,18 @@ const (
 	// These modifier types represent the field modification operations
 	// on list/map types such as append/remove/map set to be used with
 	// UPDATE CQL statements
-	ModifierListPrepend      ModifierOp = iota // prepend to beginning of a list
-	ModifierListAppend                         // append to the end of a list
-	ModifierListSetAtIndex                     //	set a value for a specific list index
-	ModifierListRemove                         // remove an item from the list
-	ModifierMapSetFields                       // set values from the provided map
-	ModifierMapSetField                        // update a value for a specific key
-	ModifierCounterIncrement                   // increment a counter
+	ModifierListPrepend       ModifierOp = iota // prepend to beginning of a list
+	ModifierListAppend                          // append to the end of a list
+	ModifierListSetAtIndex                      //	set a value for a specific list index
+	ModifierListRemove                          // remove an item from the list
+	ModifierMapSetFields                        // set values from the provided map
+	ModifierMapSetField                         // update a value for a specific key
+	ModifierCounterIncrement                    // increment a counter
+	ModifierSetAdd                              // add items to a set
+	ModifierSetRemove                           // remove items from a set
+	ModifierMapRemoveKeys                       // remove keys from a map
+	ModifierMapDeleteKey                        // delete a key from a map, used with DELETE CQL statements
+	ModifierListDeleteAtIndex                   // delete the element at an index of a list, used with DELETE CQL statements
 )
 
 type Modifier struct {
@@ -49,6 +54,16 @@ func (m Modifier) Operation() ModifierOp {
 //     to be set in the underlying map
 //   - ModifierCounterIncrement returns 1 element (int) with how much the value
 //     should be incremented by (or decremented if the value is negative)
+//   - ModifierSetAdd returns 1 element with the values ([]interface{}) to be
+//     added to the set
+//   - ModifierSetRemove returns 1 element with the values ([]interface{}) to
+//     be removed from the set
+//   - ModifierMapRemoveKeys returns 1 element with the keys ([]interface{}) to
+//     be removed from the map
+//   - ModifierMapDeleteKey returns 1 element with the key (interface{}) to be
+//     deleted from the map
+//   - ModifierListDeleteAtIndex returns 1 element with the index (int) of the
+//     element to be deleted from the list
 func (m Modifier) Args() []interface{} {
 	return m.args
 }
@@ -110,6 +125,55 @@ func CounterIncrement(value int) Modifier {
 	}
 }
 
+// SetAdd adds the given values to the set, values already in it are ignored
+func SetAdd(values ...interface{}) Modifier {
+	return Modifier{
+		op:   ModifierSetAdd,
+		args: []interface{}{values},
+	}
+}
+
+// SetRemove removes the given values from the set
+func SetRemove(values ...interface{}) Modifier {
+	return Modifier{
+		op:   ModifierSetRemove,
+		args: []interface{}{values},
+	}
+}
+
+// MapRemoveKeys removes the given keys (and their values) from the map
+func MapRemoveKeys(keys ...interface{}) Modifier {
+	return Modifier{
+		op:   ModifierMapRemoveKeys,
+		args: []interface{}{keys},
+	}
+}
+
+// MapDeleteKey deletes the given key (and its value) from the map. Unlike the
+// other modifiers it is used with Filter.DeleteElements rather than Update
+func MapDeleteKey(key interface{}) Modifier {
+	return Modifier{
+		op:   ModifierMapDeleteKey,
+		args: []interface{}{key},
+	}
+}
+
+// ListDeleteAtIndex deletes the list element at a given index, shifting the
+// elements after it. Unlike the other modifiers it is used with
+// Filter.DeleteElements rather than Update
+func ListDeleteAtIndex(index int) Modifier {
+	return Modifier{
+		op:   ModifierListDeleteAtIndex,
+		args: []interface{}{index},
+	}
+}
+
+// deletesElement returns whether the modifier deletes an element of a
+// collection, and so belongs in a DELETE rather than an UPDATE statement
+func (m Modifier) deletesElement() bool {
+	return m.op == ModifierMapDeleteKey || m.op == ModifierListDeleteAtIndex
+}
+
 func (m Modifier) cql(name string) (string, []interface{}) {
 	str := ""
 	vals := []interface{}{}
@@ -149,6 +213,15 @@ func (m Modifier) cql(name string) (string, []interface{}) {
 	case ModifierMapSetField:
 		str = fmt.Sprintf("%s[?] = ?", name)
 		vals = append(vals, m.args[0], m.args[1])
+	case ModifierSetAdd:
+		str = fmt.Sprintf("%s = %s + ?", name, name)
+		vals = append(vals, m.args[0])
+	case ModifierSetRemove, ModifierMapRemoveKeys:
+		str = fmt.Sprintf("%s = %s - ?", name, name)
+		vals = append(vals, m.args[0])
+	case ModifierMapDeleteKey, ModifierListDeleteAtIndex:
+		str = fmt.Sprintf("%s[?]", name)
+		vals = append(vals, m.args[0])
 	case ModifierCounterIncrement:
 		val := m.args[0].(int)
 		if val > 0 {
@@ -23,6 +23,25 @@ func (o *multiFlakeSeriesT) CreateIfNotExistStatement() (Statement, error) {
 }
 
 func (o *multiFlakeSeriesT) Set(v interface{}) Op {
+	m, err := o.row(v)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return o.Table().
+		Set(m)
+}
+
+func (o *multiFlakeSeriesT) SetIfNotExists(v interface{}) Op {
+	m, err := o.row(v)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return o.Table().
+		SetIfNotExists(m)
+}
+
+// row converts the row struct to a map holding its timestamp and bucket
+func (o *multiFlakeSeriesT) row(v interface{}) (map[string]interface{}, error) {
 	m, ok := toMap(v)
 	if !ok {
 		panic("Can't set: not able to convert")
@@ -34,20 +53,19 @@ func (o *multiFlakeSeriesT) Set(v interface{}) Op {
 
 	timestamp, err := flakeToTime(id)
 	if err != nil {
-		return errOp{err: err}
+		return nil, err
 	}
 
 	m[flakeTimestampFieldName] = timestamp
 	m[bucketFieldName] = bucket(timestamp, o.bucketSize)
-
-	return o.Table().
-		Set(m)
+	return m, nil
 }
 
-func (o *multiFlakeSeriesT) Update(v interface{}, id string, m map[string]interface{}) Op {
+// rowFilter filters the single row with the given index value and id
+func (o *multiFlakeSeriesT) rowFilter(v interface{}, id string) (Filter, error) {
 	timestamp, err := flakeToTime(id)
 	if err != nil {
-		return errOp{err: err}
+		return nil, err
 	}
 	bucket := bucket(timestamp, o.bucketSize)
 
@@ -55,23 +73,39 @@ func (o *multiFlakeSeriesT) Update(v interface{}, id string, m map[string]interf
 		Where(Eq(o.indexField, v),
 			Eq(bucketFieldName, bucket),
 			Eq(flakeTimestampFieldName, timestamp),
-			Eq(o.idField, id)).
-		Update(m)
+			Eq(o.idField, id)), nil
+}
+
+func (o *multiFlakeSeriesT) Update(v interface{}, id string, m map[string]interface{}) Op {
+	f, err := o.rowFilter(v, id)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return f.Update(m)
+}
+
+func (o *multiFlakeSeriesT) UpdateIf(v interface{}, id string, conditions []Relation, m map[string]interface{}) Op {
+	f, err := o.rowFilter(v, id)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return f.UpdateIf(conditions, m)
 }
 
 func (o *multiFlakeSeriesT) Delete(v interface{}, id string) Op {
-	timestamp, err := flakeToTime(id)
+	f, err := o.rowFilter(v, id)
 	if err != nil {
 		return errOp{err: err}
 	}
-	bucket := bucket(timestamp, o.bucketSize)
+	return f.Delete()
+}
 
-	return o.Table().
-		Where(Eq(o.indexField, v),
-			Eq(bucketFieldName, bucket),
-			Eq(flakeTimestampFieldName, timestamp),
-			Eq(o.idField, id)).
-		Delete()
+func (o *multiFlakeSeriesT) DeleteIfExists(v interface{}, id string) Op {
+	f, err := o.rowFilter(v, id)
+	if err != nil {
+		return errOp{err: err}
+	}
+	return f.DeleteIfExists()
 }
 
 func (o *multiFlakeSeriesT) Read(v interface{}, id string, pointer interface{}) Op {
@@ -89,6 +123,14 @@ func (o *multiFlakeSeriesT) Read(v interface{}, id string, pointer interface{})
 }
 
 func (o *multiFlakeSeriesT) List(v interface{}, startTime, endTime time.Time, pointerToASlice interface{}) Op {
+	return o.listFilter(v, startTime, endTime).Read(pointerToASlice)
+}
+
+func (o *multiFlakeSeriesT) ListIter(v interface{}, startTime, endTime time.Time) Iterator {
+	return o.listFilter(v, startTime, endTime).Iter()
+}
+
+func (o *multiFlakeSeriesT) listFilter(v interface{}, startTime, endTime time.Time) Filter {
 	buckets := []interface{}{}
 	for bucket := o.Buckets(v, startTime); bucket.Bucket().Before(endTime); bucket = bucket.Next() {
 		buckets = append(buckets, bucket.Bucket())
@@ -97,8 +139,7 @@ func (o *multiFlakeSeriesT) List(v interface{}, startTime, endTime time.Time, po
 		Where(Eq(o.indexField, v),
 			In(bucketFieldName, buckets...),
 			GTE(flakeTimestampFieldName, startTime),
-			LT(flakeTimestampFieldName, endTime)).
-		Read(pointerToASlice)
+			LT(flakeTimestampFieldName, endTime))
 }
 
 func (o *multiFlakeSeriesT) Buckets(v interface{}, start time.Time) Buckets {
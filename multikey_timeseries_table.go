@@ -23,6 +23,17 @@ func (o *multiKeyTimeSeriesT) CreateIfNotExistStatement() (Statement, error) {
 }
 
 func (o *multiKeyTimeSeriesT) Set(v interface{}) Op {
+	return o.Table().
+		Set(o.row(v))
+}
+
+func (o *multiKeyTimeSeriesT) SetIfNotExists(v interface{}) Op {
+	return o.Table().
+		SetIfNotExists(o.row(v))
+}
+
+// row converts the row struct to a map holding its bucket
+func (o *multiKeyTimeSeriesT) row(v interface{}) map[string]interface{} {
 	m, ok := toMap(v)
 	if !ok {
 		panic("Can't set: not able to convert")
@@ -32,11 +43,11 @@ func (o *multiKeyTimeSeriesT) Set(v interface{}) Op {
 	} else {
 		m[bucketFieldName] = bucket(tim, o.bucketSize)
 	}
-	return o.Table().
-		Set(m)
+	return m
 }
 
-func (o *multiKeyTimeSeriesT) Update(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}, m map[string]interface{}) Op {
+// rowFilter filters the single row with the given index values, time and ids
+func (o *multiKeyTimeSeriesT) rowFilter(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}) Filter {
 	bucket := bucket(timeStamp, o.bucketSize)
 	relations := make([]Relation, 0)
 	relations = append(relations, o.ListOfEqualRelations(v, id)...)
@@ -44,20 +55,23 @@ func (o *multiKeyTimeSeriesT) Update(v map[string]interface{}, timeStamp time.Ti
 	relations = append(relations, Eq(o.timeField, timeStamp))
 
 	return o.Table().
-		Where(relations...).
-		Update(m)
+		Where(relations...)
+}
+
+func (o *multiKeyTimeSeriesT) Update(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}, m map[string]interface{}) Op {
+	return o.rowFilter(v, timeStamp, id).Update(m)
+}
+
+func (o *multiKeyTimeSeriesT) UpdateIf(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}, conditions []Relation, m map[string]interface{}) Op {
+	return o.rowFilter(v, timeStamp, id).UpdateIf(conditions, m)
 }
 
 func (o *multiKeyTimeSeriesT) Delete(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}) Op {
-	bucket := bucket(timeStamp, o.bucketSize)
-	relations := make([]Relation, 0)
-	relations = append(relations, o.ListOfEqualRelations(v, id)...)
-	relations = append(relations, Eq(bucketFieldName, bucket))
-	relations = append(relations, Eq(o.timeField, timeStamp))
+	return o.rowFilter(v, timeStamp, id).Delete()
+}
 
-	return o.Table().
-		Where(relations...).
-		Delete()
+func (o *multiKeyTimeSeriesT) DeleteIfExists(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}) Op {
+	return o.rowFilter(v, timeStamp, id).DeleteIfExists()
 }
 
 func (o *multiKeyTimeSeriesT) Read(v map[string]interface{}, timeStamp time.Time, id map[string]interface{}, pointer interface{}) Op {
@@ -74,6 +88,14 @@ func (o *multiKeyTimeSeriesT) Read(v map[string]interface{}, timeStamp time.Time
 }
 
 func (o *multiKeyTimeSeriesT) List(v map[string]interface{}, startTime time.Time, endTime time.Time, pointerToASlice interface{}) Op {
+	return o.listFilter(v, startTime, endTime).Read(pointerToASlice)
+}
+
+func (o *multiKeyTimeSeriesT) ListIter(v map[string]interface{}, startTime time.Time, endTime time.Time) Iterator {
+	return o.listFilter(v, startTime, endTime).Iter()
+}
+
+func (o *multiKeyTimeSeriesT) listFilter(v map[string]interface{}, startTime time.Time, endTime time.Time) Filter {
 	buckets := []interface{}{}
 	for bucket := o.Buckets(v, startTime); bucket.Bucket().Before(endTime); bucket = bucket.Next() {
 		buckets = append(buckets, bucket.Bucket())
@@ -86,8 +108,7 @@ func (o *multiKeyTimeSeriesT) List(v map[string]interface{}, startTime time.Time
 	relations = append(relations, LTE(o.timeField, endTime))
 
 	return o.Table().
-		Where(relations...).
-		Read(pointerToASlice)
+		Where(relations...)
 }
 
 func (o *multiKeyTimeSeriesT) Buckets(v map[string]interface{}, start time.Time) Buckets {
@@ -33,6 +33,23 @@ func (mm *multimapMkT) Delete(field, id map[string]interface{}) Op {
 		Delete()
 }
 
+func (mm *multimapMkT) SetIfNotExists(v interface{}) Op {
+	return mm.Table().
+		SetIfNotExists(v)
+}
+
+func (mm *multimapMkT) UpdateIf(field, id map[string]interface{}, conditions []Relation, m map[string]interface{}) Op {
+	return mm.Table().
+		Where(mm.ListOfEqualRelations(field, id)...).
+		UpdateIf(conditions, m)
+}
+
+func (mm *multimapMkT) DeleteIfExists(field, id map[string]interface{}) Op {
+	return mm.Table().
+		Where(mm.ListOfEqualRelations(field, id)...).
+		DeleteIfExists()
+}
+
 func (mm *multimapMkT) DeleteAll(field map[string]interface{}) Op {
 	return mm.Table().
 		Where(mm.ListOfEqualRelations(field, nil)...).
@@ -69,6 +86,12 @@ func (mm *multimapMkT) List(field, startId map[string]interface{}, limit int, po
 		Read(pointerToASlice)
 }
 
+func (mm *multimapMkT) ListPage(field map[string]interface{}, pageSize int, pageState []byte, pointerToASlice interface{}, nextPageState *[]byte) Op {
+	return mm.Table().
+		Where(mm.ListOfEqualRelations(field, nil)...).
+		ReadPage(pageSize, pageState, pointerToASlice, nextPageState)
+}
+
 func (mm *multimapMkT) WithOptions(o Options) MultimapMkTable {
 	return &multimapMkT{
 		t:               mm.Table().WithOptions(o),
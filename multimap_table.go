@@ -34,6 +34,24 @@ func (mm *multimapT) Delete(field, id interface{}) Op {
 		Delete()
 }
 
+func (mm *multimapT) SetIfNotExists(v interface{}) Op {
+	return mm.Table().
+		SetIfNotExists(v)
+}
+
+func (mm *multimapT) UpdateIf(field, id interface{}, conditions []Relation, m map[string]interface{}) Op {
+	return mm.Table().
+		Where(Eq(mm.fieldToIndexBy, field),
+			Eq(mm.idField, id)).
+		UpdateIf(conditions, m)
+}
+
+func (mm *multimapT) DeleteIfExists(field, id interface{}) Op {
+	return mm.Table().
+		Where(Eq(mm.fieldToIndexBy, field), Eq(mm.idField, id)).
+		DeleteIfExists()
+}
+
 func (mm *multimapT) DeleteAll(field interface{}) Op {
 	return mm.Table().
 		Where(Eq(mm.fieldToIndexBy, field)).
@@ -60,6 +78,12 @@ func (mm *multimapT) List(field, startId interface{}, limit int, pointerToASlice
 		Read(pointerToASlice)
 }
 
+func (mm *multimapT) ListPage(field interface{}, pageSize int, pageState []byte, pointerToASlice interface{}, nextPageState *[]byte) Op {
+	return mm.Table().
+		Where(Eq(mm.fieldToIndexBy, field)).
+		ReadPage(pageSize, pageState, pointerToASlice, nextPageState)
+}
+
 func (mm *multimapT) WithOptions(o Options) MultimapTable {
 	return &multimapT{
 		t:              mm.Table().WithOptions(o),
@@ -1,6 +1,10 @@
 package gocassa
 
-import "context"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
 type multiOp []Op
 
@@ -12,6 +16,25 @@ func (mo multiOp) Run() error {
 	if err := mo.Preflight(); err != nil {
 		return err
 	}
+	return mo.traced(mo.Options().Context, func(ctx context.Context) error {
+		return mo.WithOptions(Options{Context: ctx}).(multiOp).run()
+	})
+}
+
+// traced calls f within a span of the ops, if their keyspace has a tracer
+func (mo multiOp) traced(ctx context.Context, f func(ctx context.Context) error) error {
+	tracer := mo.tracer()
+	if tracer == nil {
+		return f(ctx)
+	}
+	ctx, span := tracer.StartSpan(spanContext(ctx), "gocassa.multi")
+	span.SetAttribute(OpsAttribute, len(mo))
+	err := f(ctx)
+	span.End(err)
+	return err
+}
+
+func (mo multiOp) run() error {
 	for _, op := range mo {
 		if err := op.Run(); err != nil {
 			return err
@@ -24,12 +47,12 @@ func (mo multiOp) RunWithContext(ctx context.Context) error {
 	return mo.WithOptions(Options{Context: ctx}).Run()
 }
 
-func (mo multiOp) runLoggedBatch() error {
+func (mo multiOp) runBatch(batchType BatchType) error {
 	if len(mo) == 0 {
 		return nil
 	}
 
-	if err := mo.Preflight(); err != nil {
+	if err := mo.PreflightBatch(batchType); err != nil {
 		return err
 	}
 	stmts := make([]Statement, len(mo))
@@ -39,25 +62,148 @@ func (mo multiOp) runLoggedBatch() error {
 	}
 
 	qe := mo.QueryExecutor()
-	return qe.ExecuteAtomicallyWithOptions(mo.Options(), stmts)
+	opts := mo.Options()
+	tracer := mo.tracer()
+	if tracer == nil {
+		return mo.executeBatch(qe, opts, batchType, stmts)
+	}
+
+	ctx, span := tracer.StartSpan(spanContext(opts.Context), "gocassa.batch")
+	span.SetAttribute(OpAttribute, string(BatchQuery))
+	span.SetAttribute(BatchTypeAttribute, batchType.String())
+	span.SetAttribute(OpsAttribute, len(stmts))
+	if opts.Consistency != nil {
+		span.SetAttribute(ConsistencyAttribute, opts.Consistency.String())
+	}
+	opts.Context = ctx
+	err := mo.executeBatch(qe, opts, batchType, stmts)
+	span.End(err)
+	return err
+}
+
+func (mo multiOp) executeBatch(qe QueryExecutor, opts Options, batchType BatchType, stmts []Statement) error {
+	start := time.Now()
+	err := qe.ExecuteBatchWithOptions(opts, batchType, stmts)
+	mo.logBatch(opts.Context, start, batchType, stmts, err)
+	return err
 }
 
 func (mo multiOp) RunLoggedBatchWithContext(ctx context.Context) error {
-	return mo.WithOptions(Options{Context: ctx}).RunAtomically()
+	return mo.RunBatchWithContext(ctx, LoggedBatch)
+}
+
+func (mo multiOp) RunBatchWithContext(ctx context.Context, batchType BatchType) error {
+	return mo.WithOptions(Options{Context: ctx}).(multiOp).runBatch(batchType)
 }
 
 func (mo multiOp) RunAtomically() error {
-	return mo.runLoggedBatch()
+	return mo.runBatch(LoggedBatch)
 }
 
 func (mo multiOp) RunAtomicallyWithContext(ctx context.Context) error {
 	return mo.RunLoggedBatchWithContext(ctx)
 }
 
+func (mo multiOp) RunConcurrently(ctx context.Context, maxParallel int) error {
+	if err := mo.Preflight(); err != nil {
+		return err
+	}
+	collectErrors := mo.Options().CollectErrors
+	return mo.traced(ctx, func(ctx context.Context) error {
+		return runConcurrently(ctx, mo, maxParallel, collectErrors, func(ctx context.Context, i int, op Op) error {
+			return op.RunWithContext(ctx)
+		})
+	})
+}
+
+// runConcurrently calls run for each op in parallel, with at most maxParallel
+// calls running at once. Unless collectErrors is set, the context passed to
+// run is cancelled after the first error and the remaining ops are skipped
+func runConcurrently(ctx context.Context, ops []Op, maxParallel int, collectErrors bool, run func(ctx context.Context, i int, op Op) error) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	if maxParallel < 1 || maxParallel > len(ops) {
+		maxParallel = len(ops)
+	}
+
+	ctx, cancel := context.WithCancel(spanContext(ctx))
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, maxParallel)
+		errs     = make([]error, len(ops))
+		firstErr error
+		once     sync.Once
+		// skipErr is the error of the caller's context if it stopped ops
+		// from being started
+		skipErr error
+	)
+	for i, op := range ops {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if skipErr = ctx.Err(); skipErr != nil {
+			// The ops which haven't started fail with the error of the caller's context
+			if collectErrors {
+				for j := i; j < len(ops); j++ {
+					errs[j] = ctx.Err()
+				}
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, op Op) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if errs[i] = run(ctx, i, op); errs[i] != nil && !collectErrors {
+				once.Do(func() {
+					firstErr = errs[i]
+					cancel()
+				})
+			}
+		}(i, op)
+	}
+	wg.Wait()
+
+	if !collectErrors {
+		if firstErr == nil {
+			// Every op which was started succeeded, so the ops only failed
+			// if the caller's context stopped some from being started
+			return skipErr
+		}
+		return firstErr
+	}
+	var multiErr MultiError
+	for _, err := range errs {
+		if err != nil {
+			multiErr = append(multiErr, err)
+		}
+	}
+	if len(multiErr) > 0 {
+		return multiErr
+	}
+	return nil
+}
+
 func (mo multiOp) GenerateStatement() Statement {
 	return noOpStatement{}
 }
 
+// keySpace returns the keyspace of the first op, or nil if there are no ops
+// of gocassa tables
+func (mo multiOp) keySpace() *k {
+	for _, op := range mo {
+		if o, ok := op.(*singleOp); ok {
+			return o.f.t.keySpace
+		}
+	}
+	return nil
+}
+
 func (mo multiOp) QueryExecutor() QueryExecutor {
 	if len(mo) == 0 {
 		return nil
@@ -113,3 +259,10 @@ func (mo multiOp) Preflight() error {
 	}
 	return nil
 }
+
+func (mo multiOp) PreflightBatch(batchType BatchType) error {
+	if err := mo.Preflight(); err != nil {
+		return err
+	}
+	return checkBatch(batchType, mo)
+}
@@ -0,0 +1,134 @@
+package gocassa
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrentQE records how many writes run at once, and fails writes of the
+// ids in fail. If set, done is called as each write finishes
+type concurrentQE struct {
+	*OptionCheckingQE
+	fail map[string]error
+	done func(executedIds int)
+
+	mtx         sync.Mutex
+	running     int
+	maxRunning  int
+	executedIds []string
+}
+
+func (qe *concurrentQE) ExecuteWithOptions(opts Options, stmt Statement) error {
+	id := stmt.(UpdateStatement).where[0].Terms()[0].(string)
+
+	qe.mtx.Lock()
+	qe.running++
+	if qe.running > qe.maxRunning {
+		qe.maxRunning = qe.running
+	}
+	qe.executedIds = append(qe.executedIds, id)
+	qe.mtx.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	qe.mtx.Lock()
+	qe.running--
+	if qe.done != nil {
+		qe.done(len(qe.executedIds))
+	}
+	qe.mtx.Unlock()
+	return qe.fail[id]
+}
+
+func newConcurrentTestOp(qe *concurrentQE, ids ...string) Op {
+	tbl := NewConnection(qe).KeySpace("app").MapTable("users", "Id", interceptedUser{})
+	op := Noop()
+	for _, id := range ids {
+		op = op.Add(tbl.Set(interceptedUser{Id: id}))
+	}
+	return op
+}
+
+func TestRunConcurrently(t *testing.T) {
+	qe := &concurrentQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	op := newConcurrentTestOp(qe, "1", "2", "3", "4", "5", "6", "7")
+
+	require.NoError(t, op.RunConcurrently(context.Background(), 3))
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4", "5", "6", "7"}, qe.executedIds)
+	assert.True(t, qe.maxRunning > 1 && qe.maxRunning <= 3, "ran %d ops at once", qe.maxRunning)
+
+	qe.executedIds, qe.maxRunning = nil, 0
+	require.NoError(t, op.RunConcurrently(context.Background(), 0))
+	assert.Len(t, qe.executedIds, 7)
+}
+
+func TestRunConcurrentlyFirstError(t *testing.T) {
+	errFailed := errors.New("failed")
+	qe := &concurrentQE{
+		OptionCheckingQE: &OptionCheckingQE{opts: &Options{}},
+		fail:             map[string]error{"1": errFailed},
+	}
+	op := newConcurrentTestOp(qe, "1", "2", "3", "4")
+
+	assert.Equal(t, errFailed, op.RunConcurrently(context.Background(), 1))
+	assert.Equal(t, []string{"1"}, qe.executedIds)
+}
+
+func TestRunConcurrentlyCollectErrors(t *testing.T) {
+	errFailed := errors.New("failed")
+	qe := &concurrentQE{
+		OptionCheckingQE: &OptionCheckingQE{opts: &Options{}},
+		fail:             map[string]error{"1": errFailed, "3": errFailed},
+	}
+	op := newConcurrentTestOp(qe, "1", "2", "3", "4").WithOptions(Options{CollectErrors: true})
+
+	err := op.RunConcurrently(context.Background(), 2)
+	assert.Equal(t, MultiError{errFailed, errFailed}, err)
+	assert.EqualError(t, err, "2 ops failed: failed; failed")
+	assert.Len(t, qe.executedIds, 4)
+}
+
+func TestRunConcurrentlyPreflight(t *testing.T) {
+	qe := &concurrentQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	op := newConcurrentTestOp(qe, "1", "2").Add(errOp{err: errors.New("invalid")})
+
+	assert.EqualError(t, op.RunConcurrently(context.Background(), 2), "invalid")
+	assert.Empty(t, qe.executedIds)
+}
+
+func TestRunConcurrentlyCancelled(t *testing.T) {
+	qe := &concurrentQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	op := newConcurrentTestOp(qe, "1", "2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, op.RunConcurrently(ctx, 1))
+	assert.Empty(t, qe.executedIds)
+
+	err := op.WithOptions(Options{CollectErrors: true}).RunConcurrently(ctx, 1)
+	assert.Equal(t, MultiError{context.Canceled, context.Canceled}, err)
+}
+
+func TestRunConcurrentlyCancelledAfterStarting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	qe := &concurrentQE{
+		OptionCheckingQE: &OptionCheckingQE{opts: &Options{}},
+		done: func(executedIds int) {
+			if executedIds == 2 {
+				cancel()
+			}
+		},
+	}
+	op := newConcurrentTestOp(qe, "1", "2")
+
+	// Every op was started and succeeded, so the cancellation doesn't fail them
+	require.NoError(t, op.RunConcurrently(ctx, 2))
+	assert.Len(t, qe.executedIds, 2)
+}
@@ -23,6 +23,17 @@ func (o *multiTimeSeriesT) CreateIfNotExistStatement() (Statement, error) {
 }
 
 func (o *multiTimeSeriesT) Set(v interface{}) Op {
+	return o.Table().
+		Set(o.row(v))
+}
+
+func (o *multiTimeSeriesT) SetIfNotExists(v interface{}) Op {
+	return o.Table().
+		SetIfNotExists(o.row(v))
+}
+
+// row converts the row struct to a map holding its bucket
+func (o *multiTimeSeriesT) row(v interface{}) map[string]interface{} {
 	m, ok := toMap(v)
 	if !ok {
 		panic("Can't set: not able to convert")
@@ -32,28 +43,33 @@ func (o *multiTimeSeriesT) Set(v interface{}) Op {
 	} else {
 		m[bucketFieldName] = bucket(tim, o.bucketSize)
 	}
-	return o.Table().
-		Set(m)
+	return m
 }
 
-func (o *multiTimeSeriesT) Update(v interface{}, timeStamp time.Time, id interface{}, m map[string]interface{}) Op {
+// rowFilter filters the single row with the given index value, time and id
+func (o *multiTimeSeriesT) rowFilter(v interface{}, timeStamp time.Time, id interface{}) Filter {
 	bucket := bucket(timeStamp, o.bucketSize)
 	return o.Table().
 		Where(Eq(o.indexField, v),
 			Eq(bucketFieldName, bucket),
 			Eq(o.timeField, timeStamp),
-			Eq(o.idField, id)).
-		Update(m)
+			Eq(o.idField, id))
+}
+
+func (o *multiTimeSeriesT) Update(v interface{}, timeStamp time.Time, id interface{}, m map[string]interface{}) Op {
+	return o.rowFilter(v, timeStamp, id).Update(m)
+}
+
+func (o *multiTimeSeriesT) UpdateIf(v interface{}, timeStamp time.Time, id interface{}, conditions []Relation, m map[string]interface{}) Op {
+	return o.rowFilter(v, timeStamp, id).UpdateIf(conditions, m)
 }
 
 func (o *multiTimeSeriesT) Delete(v interface{}, timeStamp time.Time, id interface{}) Op {
-	bucket := bucket(timeStamp, o.bucketSize)
-	return o.Table().
-		Where(Eq(o.indexField, v),
-			Eq(bucketFieldName, bucket),
-			Eq(o.timeField, timeStamp),
-			Eq(o.idField, id)).
-		Delete()
+	return o.rowFilter(v, timeStamp, id).Delete()
+}
+
+func (o *multiTimeSeriesT) DeleteIfExists(v interface{}, timeStamp time.Time, id interface{}) Op {
+	return o.rowFilter(v, timeStamp, id).DeleteIfExists()
 }
 
 func (o *multiTimeSeriesT) Read(v interface{}, timeStamp time.Time, id, pointer interface{}) Op {
@@ -68,6 +84,14 @@ func (o *multiTimeSeriesT) Read(v interface{}, timeStamp time.Time, id, pointer
 }
 
 func (o *multiTimeSeriesT) List(v interface{}, startTime time.Time, endTime time.Time, pointerToASlice interface{}) Op {
+	return o.listFilter(v, startTime, endTime).Read(pointerToASlice)
+}
+
+func (o *multiTimeSeriesT) ListIter(v interface{}, startTime time.Time, endTime time.Time) Iterator {
+	return o.listFilter(v, startTime, endTime).Iter()
+}
+
+func (o *multiTimeSeriesT) listFilter(v interface{}, startTime time.Time, endTime time.Time) Filter {
 	buckets := []interface{}{}
 	for bucket := o.Buckets(v, startTime); bucket.Bucket().Before(endTime); bucket = bucket.Next() {
 		buckets = append(buckets, bucket.Bucket())
@@ -76,8 +100,7 @@ func (o *multiTimeSeriesT) List(v interface{}, startTime time.Time, endTime time
 		Where(Eq(o.indexField, v),
 			In(bucketFieldName, buckets...),
 			GTE(o.timeField, startTime),
-			LTE(o.timeField, endTime)).
-		Read(pointerToASlice)
+			LTE(o.timeField, endTime))
 }
 
 func (o *multiTimeSeriesT) Buckets(v interface{}, start time.Time) Buckets {
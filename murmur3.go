@@ -0,0 +1,150 @@
+package gocassa
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// This is the Murmur3 hash as implemented by Cassandra's Murmur3Partitioner,
+// adapted from gocql's internal murmur package. Cassandra's implementation
+// differs from the reference one as it sign extends the bytes of the tail.
+
+const (
+	murmurC1    int64 = -8663945395140668459 // 0x87c37b91114253d5
+	murmurC2    int64 = 5545529020109919103  // 0x4cf5ad432745937f
+	murmurFmix1 int64 = -49064778989728563   // 0xff51afd7ed558ccd
+	murmurFmix2 int64 = -4265267296055464877 // 0xc4ceb9fe1a85ec53
+)
+
+// murmur3Token returns the token Cassandra assigns to a serialized partition key
+func murmur3Token(data []byte) int64 {
+	h := murmur3H1(data)
+	// Cassandra reserves the minimum token, so it is never assigned to a key
+	if h == math.MinInt64 {
+		return math.MaxInt64
+	}
+	return h
+}
+
+func murmur3H1(data []byte) int64 {
+	length := len(data)
+
+	var h1, h2, k1, k2 int64
+
+	// body
+	nBlocks := length / 16
+	for i := 0; i < nBlocks; i++ {
+		k1 = int64(binary.LittleEndian.Uint64(data[i*16:]))
+		k2 = int64(binary.LittleEndian.Uint64(data[i*16+8:]))
+
+		k1 *= murmurC1
+		k1 = murmurRotl(k1, 31)
+		k1 *= murmurC2
+		h1 ^= k1
+
+		h1 = murmurRotl(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		k2 *= murmurC2
+		k2 = murmurRotl(k2, 33)
+		k2 *= murmurC1
+		h2 ^= k2
+
+		h2 = murmurRotl(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	// tail
+	tail := data[nBlocks*16:]
+	k1 = 0
+	k2 = 0
+	switch length & 15 {
+	case 15:
+		k2 ^= murmurBlock(tail[14]) << 48
+		fallthrough
+	case 14:
+		k2 ^= murmurBlock(tail[13]) << 40
+		fallthrough
+	case 13:
+		k2 ^= murmurBlock(tail[12]) << 32
+		fallthrough
+	case 12:
+		k2 ^= murmurBlock(tail[11]) << 24
+		fallthrough
+	case 11:
+		k2 ^= murmurBlock(tail[10]) << 16
+		fallthrough
+	case 10:
+		k2 ^= murmurBlock(tail[9]) << 8
+		fallthrough
+	case 9:
+		k2 ^= murmurBlock(tail[8])
+
+		k2 *= murmurC2
+		k2 = murmurRotl(k2, 33)
+		k2 *= murmurC1
+		h2 ^= k2
+
+		fallthrough
+	case 8:
+		k1 ^= murmurBlock(tail[7]) << 56
+		fallthrough
+	case 7:
+		k1 ^= murmurBlock(tail[6]) << 48
+		fallthrough
+	case 6:
+		k1 ^= murmurBlock(tail[5]) << 40
+		fallthrough
+	case 5:
+		k1 ^= murmurBlock(tail[4]) << 32
+		fallthrough
+	case 4:
+		k1 ^= murmurBlock(tail[3]) << 24
+		fallthrough
+	case 3:
+		k1 ^= murmurBlock(tail[2]) << 16
+		fallthrough
+	case 2:
+		k1 ^= murmurBlock(tail[1]) << 8
+		fallthrough
+	case 1:
+		k1 ^= murmurBlock(tail[0])
+
+		k1 *= murmurC1
+		k1 = murmurRotl(k1, 31)
+		k1 *= murmurC2
+		h1 ^= k1
+	}
+
+	h1 ^= int64(length)
+	h2 ^= int64(length)
+
+	h1 += h2
+	h2 += h1
+
+	h1 = murmurFmix(h1)
+	h2 = murmurFmix(h2)
+
+	return h1 + h2
+}
+
+func murmurFmix(n int64) int64 {
+	// cast to unsigned for logical right bitshift (to match C* MM3 implementation)
+	n ^= int64(uint64(n) >> 33)
+	n *= murmurFmix1
+	n ^= int64(uint64(n) >> 33)
+	n *= murmurFmix2
+	n ^= int64(uint64(n) >> 33)
+	return n
+}
+
+func murmurBlock(p byte) int64 {
+	return int64(int8(p))
+}
+
+func murmurRotl(x int64, r uint8) int64 {
+	// cast to unsigned for logical right bitshift (to match C* MM3 implementation)
+	return (x << r) | int64(uint64(x)>>(64-r))
+}
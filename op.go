@@ -9,6 +9,7 @@ import (
 const (
 	readOpType uint8 = iota
 	singleReadOpType
+	readPageOpType
 	deleteOpType
 	updateOpType
 	insertOpType
@@ -21,6 +22,22 @@ type singleOp struct {
 	result  interface{}
 	m       map[string]interface{} // map for updates, sets etc
 	qe      QueryExecutor
+
+	// Lightweight transaction conditions, a write with any of these set is
+	// only applied if they hold
+	ifNotExists bool       // inserts only
+	ifExists    bool       // updates and deletes only
+	conditions  []Relation // updates and deletes only
+
+	// Columns and collection elements deleted by deletes, the whole row is
+	// deleted if neither is set
+	deleteColumns  []string
+	deleteElements map[string]Modifier
+
+	// Paging state for paged reads
+	pageSize      int
+	pageState     []byte
+	nextPageState *[]byte
 }
 
 func (o *singleOp) Options() Options {
@@ -29,12 +46,22 @@ func (o *singleOp) Options() Options {
 
 func (o *singleOp) WithOptions(opts Options) Op {
 	return &singleOp{
-		options: o.options.Merge(opts),
-		f:       o.f,
-		opType:  o.opType,
-		result:  o.result,
-		m:       o.m,
-		qe:      o.qe}
+		options:     o.options.Merge(opts),
+		f:           o.f,
+		opType:      o.opType,
+		result:      o.result,
+		m:           o.m,
+		qe:          o.qe,
+		ifNotExists: o.ifNotExists,
+		ifExists:    o.ifExists,
+		conditions:  o.conditions,
+
+		deleteColumns:  o.deleteColumns,
+		deleteElements: o.deleteElements,
+
+		pageSize:      o.pageSize,
+		pageState:     o.pageState,
+		nextPageState: o.nextPageState}
 }
 
 func (o *singleOp) Add(additions ...Op) Op {
@@ -42,9 +69,30 @@ func (o *singleOp) Add(additions ...Op) Op {
 }
 
 func (o *singleOp) Preflight() error {
+	if o.f.t.base != nil && o.isWrite() {
+		return viewWriteError(o.f.t.Name())
+	}
+	if o.isConditional() && !o.f.t.options.Merge(o.options).Timestamp.IsZero() {
+		return errConditionalTimestamp
+	}
 	return nil
 }
 
+func (o *singleOp) PreflightBatch(batchType BatchType) error {
+	if err := o.Preflight(); err != nil {
+		return err
+	}
+	return checkBatch(batchType, []Op{o})
+}
+
+func (o *singleOp) isWrite() bool {
+	switch o.opType {
+	case insertOpType, updateOpType, deleteOpType:
+		return true
+	}
+	return false
+}
+
 func newWriteOp(qe QueryExecutor, f filter, opType uint8, m map[string]interface{}) *singleOp {
 	return &singleOp{
 		qe:     qe,
@@ -54,21 +102,81 @@ func newWriteOp(qe QueryExecutor, f filter, opType uint8, m map[string]interface
 }
 
 func (o *singleOp) Run() error {
+	if err := o.Preflight(); err != nil {
+		return err
+	}
+	if tracer := o.f.t.keySpace.tracer; tracer != nil {
+		return o.runTraced(tracer)
+	}
+	_, err := o.runLogged()
+	return err
+}
+
+// run executes the op, returning the number of rows read
+func (o *singleOp) run() (int, error) {
 	switch o.opType {
 	case readOpType, singleReadOpType:
 		stmt := o.generateSelect(o.options)
-		scanner := NewScanner(stmt, o.result)
-		return o.qe.QueryWithOptions(o.options, stmt, scanner)
+		sc := NewScanner(stmt, o.result).(*scanner)
+		err := o.qe.QueryWithOptions(o.options, stmt, sc)
+		return sc.rowsScanned, err
+	case readPageOpType:
+		stmt := o.generateSelect(o.options)
+		sc := NewScanner(stmt, o.result).(*scanner)
+		nextPageState, err := o.qe.QueryPageWithOptions(o.options, stmt, sc, o.pageSize, o.pageState)
+		if err != nil {
+			return 0, err
+		}
+		if len(nextPageState) == 0 {
+			nextPageState = nil
+		}
+		*o.nextPageState = nextPageState
+		return sc.rowsScanned, nil
 	case insertOpType:
 		stmt := o.generateInsert(o.options)
-		return o.qe.ExecuteWithOptions(o.options, stmt)
+		return 0, o.execute(stmt)
 	case updateOpType:
 		stmt := o.generateUpdate(o.options)
-		return o.qe.ExecuteWithOptions(o.options, stmt)
+		return 0, o.execute(stmt)
 	case deleteOpType:
 		stmt := o.generateDelete(o.options)
+		return 0, o.execute(stmt)
+	}
+	return 0, nil
+}
+
+func (o *singleOp) queryType() QueryType {
+	switch o.opType {
+	case insertOpType:
+		return InsertQuery
+	case updateOpType:
+		return UpdateQuery
+	case deleteOpType:
+		return DeleteQuery
+	}
+	return ReadQuery
+}
+
+// isConditional returns whether this op is a lightweight transaction
+func (o *singleOp) isConditional() bool {
+	return o.ifNotExists || o.ifExists || len(o.conditions) > 0
+}
+
+// execute runs a DML statement, going through the CAS path of the executor
+// if the op is conditional so we can report whether it was applied
+func (o *singleOp) execute(stmt Statement) error {
+	if !o.isConditional() {
 		return o.qe.ExecuteWithOptions(o.options, stmt)
 	}
+
+	current := map[string]interface{}{}
+	applied, err := o.qe.ExecuteCASWithOptions(o.options, stmt, current)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return NotAppliedError{Current: current}
+	}
 	return nil
 }
 
@@ -84,13 +192,26 @@ func (o *singleOp) RunLoggedBatchWithContext(ctx context.Context) error {
 	return o.WithOptions(Options{Context: ctx}).Run()
 }
 
+// RunBatchWithContext runs the op on its own, as there's nothing to batch it
+// with, once it's been checked it could be run in a batch of the given type
+func (o *singleOp) RunBatchWithContext(ctx context.Context, batchType BatchType) error {
+	if err := o.PreflightBatch(batchType); err != nil {
+		return err
+	}
+	return o.RunWithContext(ctx)
+}
+
 func (o *singleOp) RunAtomicallyWithContext(ctx context.Context) error {
 	return o.RunLoggedBatchWithContext(ctx)
 }
 
+func (o *singleOp) RunConcurrently(ctx context.Context, maxParallel int) error {
+	return o.RunWithContext(ctx)
+}
+
 func (o *singleOp) GenerateStatement() Statement {
 	switch o.opType {
-	case readOpType, singleReadOpType:
+	case readOpType, singleReadOpType, readPageOpType:
 		return o.generateSelect(o.options)
 	case insertOpType:
 		return o.generateInsert(o.options)
@@ -123,32 +244,41 @@ func (o *singleOp) generateSelect(opt Options) SelectStatement {
 func (o *singleOp) generateInsert(opt Options) InsertStatement {
 	mopt := o.f.t.options.Merge(opt)
 	return InsertStatement{
-		keyspace: o.f.t.keySpace.name,
-		table:    o.f.t.Name(),
-		fieldMap: o.m,
-		ttl:      mopt.TTL,
-		keys:     o.f.t.info.keys,
+		keyspace:    o.f.t.keySpace.name,
+		table:       o.f.t.Name(),
+		fieldMap:    bindUDTs(o.f.t.info.fieldInfo, o.m),
+		ttl:         mopt.TTL,
+		timestamp:   mopt.Timestamp,
+		keys:        o.f.t.info.keys,
+		ifNotExists: o.ifNotExists,
 	}
 }
 
 func (o *singleOp) generateUpdate(opt Options) UpdateStatement {
 	mopt := o.f.t.options.Merge(opt)
 	return UpdateStatement{
-		keyspace: o.f.t.keySpace.name,
-		table:    o.f.t.Name(),
-		fieldMap: o.m,
-		where:    o.f.rs,
-		ttl:      mopt.TTL,
-		keys:     o.f.t.info.keys,
+		keyspace:   o.f.t.keySpace.name,
+		table:      o.f.t.Name(),
+		fieldMap:   bindUDTs(o.f.t.info.fieldInfo, o.m),
+		where:      o.f.rs,
+		ttl:        mopt.TTL,
+		timestamp:  mopt.Timestamp,
+		keys:       o.f.t.info.keys,
+		ifExists:   o.ifExists,
+		conditions: o.conditions,
 	}
 }
 
 func (o *singleOp) generateDelete(opt Options) DeleteStatement {
 	return DeleteStatement{
-		keyspace: o.f.t.keySpace.name,
-		table:    o.f.t.Name(),
-		where:    o.f.rs,
-		keys:     o.f.t.info.keys,
+		keyspace:   o.f.t.keySpace.name,
+		table:      o.f.t.Name(),
+		columns:    o.deleteColumns,
+		elements:   o.deleteElements,
+		where:      o.f.rs,
+		keys:       o.f.t.info.keys,
+		ifExists:   o.ifExists,
+		conditions: o.conditions,
 	}
 }
 
@@ -2,6 +2,7 @@ package gocassa
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/gocql/gocql"
@@ -36,6 +37,116 @@ func (c ClusteringOrderColumn) Field() string {
 	return c.Column
 }
 
+// sasiIndexClass is the class name of Cassandra's SASI index implementation
+const sasiIndexClass = "org.apache.cassandra.index.sasi.SASIIndex"
+
+// Index describes a secondary index on a column, which lets you query the
+// column with equality relations without the partition key
+type Index struct {
+	// Column is the field to index
+	Column string
+	// Name of the index, defaults to "<table>_<column>_idx"
+	Name string
+	// SASI creates a SASI index rather than a regular secondary index, which
+	// also supports range and LIKE queries
+	SASI bool
+	// SASIOptions are passed as the options of a SASI index, eg. "mode": "CONTAINS"
+	SASIOptions map[string]string
+	// Keys indexes the keys of a map column rather than its values, which
+	// lets you query the column with ContainsKey relations. The name defaults
+	// to "<table>_<column>_keys_idx"
+	Keys bool
+}
+
+// CompactionStrategy configures how the SSTables of a table are compacted
+type CompactionStrategy struct {
+	// Class is the compaction strategy, eg. "LeveledCompactionStrategy"
+	Class string
+	// Options are the sub-options of the strategy, eg. "sstable_size_in_mb": "160"
+	Options map[string]string
+}
+
+// TimeWindowCompaction returns a TimeWindowCompactionStrategy which compacts the data written in
+// each window into a single SSTable. It suits data which is written in time order and expires
+// with a TTL, such as the rows of time series tables
+func TimeWindowCompaction(window time.Duration) CompactionStrategy {
+	unit, size := "MINUTES", int64(window/time.Minute)
+	switch {
+	case window >= 24*time.Hour && window%(24*time.Hour) == 0:
+		unit, size = "DAYS", int64(window/(24*time.Hour))
+	case window >= time.Hour && window%time.Hour == 0:
+		unit, size = "HOURS", int64(window/time.Hour)
+	}
+	if size < 1 {
+		size = 1
+	}
+	return CompactionStrategy{
+		Class: "TimeWindowCompactionStrategy",
+		Options: map[string]string{
+			"compaction_window_unit": unit,
+			"compaction_window_size": strconv.FormatInt(size, 10),
+		},
+	}
+}
+
+// Caching configures which data of a table Cassandra caches
+type Caching struct {
+	// Keys is "ALL" or "NONE"
+	Keys string
+	// RowsPerPartition is "ALL", "NONE" or the number of rows to cache per partition
+	RowsPerPartition string
+}
+
+// Compression configures how the SSTables of a table are compressed
+type Compression struct {
+	// Class is the compressor, eg. "LZ4Compressor". Compression is disabled if it is empty
+	Class string
+	// ChunkLengthKB is the size of the blocks which are compressed. If zero, Cassandra's default is used
+	ChunkLengthKB int
+}
+
+// TableProperties are the properties a table is created with. Properties which aren't set are
+// omitted, so Cassandra's defaults are used
+type TableProperties struct {
+	Compaction *CompactionStrategy
+	// DefaultTTL is the TTL of data written without one. It will be truncated to second precision
+	DefaultTTL time.Duration
+	// GCGrace is how long tombstones are kept before they can be purged. If nil, it is considered not set
+	GCGrace *time.Duration
+	Caching *Caching
+	// BloomFilterFPChance is the false positive chance of the bloom filters of the SSTables
+	BloomFilterFPChance float64
+	Comment             string
+	// Compression overrides the Compressor option of the table
+	Compression *Compression
+}
+
+// Merge returns a new TableProperties which is a right biased merge of the two initial TableProperties.
+func (p TableProperties) Merge(neu TableProperties) TableProperties {
+	if neu.Compaction != nil {
+		p.Compaction = neu.Compaction
+	}
+	if neu.DefaultTTL != 0 {
+		p.DefaultTTL = neu.DefaultTTL
+	}
+	if neu.GCGrace != nil {
+		p.GCGrace = neu.GCGrace
+	}
+	if neu.Caching != nil {
+		p.Caching = neu.Caching
+	}
+	if neu.BloomFilterFPChance != 0 {
+		p.BloomFilterFPChance = neu.BloomFilterFPChance
+	}
+	if len(neu.Comment) > 0 {
+		p.Comment = neu.Comment
+	}
+	if neu.Compression != nil {
+		p.Compression = neu.Compression
+	}
+	return p
+}
+
 // Options can contain table or statement specific options.
 // The reason for this is because statement specific (TTL, Limit) options make sense as table level options
 // (eg. have default TTL for every Update without specifying it all the time)
@@ -43,6 +154,10 @@ type Options struct {
 	// TTL specifies a duration over which data is valid. It will be truncated to second precision upon statement
 	// execution.
 	TTL time.Duration
+	// Timestamp sets the write timestamp of inserts and updates, which Cassandra uses to resolve conflicting
+	// writes (the latest timestamp wins). It will be truncated to microsecond precision. If zero, the time the
+	// query is run is used. Conditional writes can't be given a timestamp, and fail their Preflight check if they are
+	Timestamp time.Time
 	// Limit query result set
 	Limit int
 	// TableName overrides the default internal table name. When naming a table 'users' the internal table name becomes 'users_someTableSpecificMetaInformation'.
@@ -61,23 +176,41 @@ type Options struct {
 	Compressor string
 	// Context allows a request context to passed, which is propagated to the QueryExecutor
 	Context context.Context
+	// Indexes specifies the secondary indexes created alongside the table
+	Indexes []Index
+	// Properties specifies the properties a newly created table has, such as its compaction strategy
+	Properties TableProperties
+	// CollectErrors makes RunConcurrently run every op even if some fail, returning a MultiError of their
+	// errors, rather than cancelling the remaining ops after the first error
+	CollectErrors bool
 }
 
 // Merge returns a new Options which is a right biased merge of the two initial Options.
+// Options which aren't set on the right are kept from the left, so merging in
+// eg. just a Context doesn't drop the Consistency or AllowFiltering already set.
 func (o Options) Merge(neu Options) Options {
 	ret := Options{
 		TTL:             o.TTL,
+		Timestamp:       o.Timestamp,
 		Limit:           o.Limit,
 		TableName:       o.TableName,
 		ClusteringOrder: o.ClusteringOrder,
+		AllowFiltering:  o.AllowFiltering,
 		Select:          o.Select,
+		Consistency:     o.Consistency,
 		CompactStorage:  o.CompactStorage,
 		Compressor:      o.Compressor,
 		Context:         o.Context,
+		Indexes:         o.Indexes,
+		Properties:      o.Properties.Merge(neu.Properties),
+		CollectErrors:   o.CollectErrors,
 	}
 	if neu.TTL != time.Duration(0) {
 		ret.TTL = neu.TTL
 	}
+	if !neu.Timestamp.IsZero() {
+		ret.Timestamp = neu.Timestamp
+	}
 	if neu.Limit != 0 {
 		ret.Limit = neu.Limit
 	}
@@ -102,6 +235,12 @@ func (o Options) Merge(neu Options) Options {
 	if len(neu.Compressor) > 0 {
 		ret.Compressor = neu.Compressor
 	}
+	if neu.Indexes != nil {
+		ret.Indexes = neu.Indexes
+	}
+	if neu.CollectErrors {
+		ret.CollectErrors = neu.CollectErrors
+	}
 	// Take the latest context added, so it can be overridden
 	if neu.Context != nil {
 		ret.Context = neu.Context
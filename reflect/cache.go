@@ -16,6 +16,7 @@ type Field struct {
 	index     []int
 	typ       reflect.Type
 	omitEmpty bool
+	options   tagOptions
 }
 
 func (f Field) Name() string {
@@ -30,6 +31,77 @@ func (f Field) Index() []int {
 	return f.index
 }
 
+// UDT returns the name of the user-defined type the field is stored as, set
+// with the udt option of the tag (eg. `cql:"address,udt=address_t"`), or the
+// empty string if the field isn't a user-defined type
+func (f Field) UDT() string {
+	udt, _ := f.options.Get("udt")
+	return udt
+}
+
+// CQLType returns the CQL type the field is stored as, set with the type
+// option of the tag (eg. `cql:"created,type=timeuuid"`), or the empty string
+// if the type is inferred from the Go type of the field. As commas separate
+// the options of the tag, the types of a collection or tuple are separated by
+// semicolons instead (eg. `cql:"scores,type=map<text;int>"`)
+func (f Field) CQLType() string {
+	typ, _ := f.options.Get("type")
+	return strings.Replace(typ, ";", ", ", -1)
+}
+
+// Set returns whether a slice field is stored as a set rather than a list,
+// set with the set option of the tag (eg. `cql:"tags,set"`)
+func (f Field) Set() bool {
+	return f.options.Contains("set")
+}
+
+// Frozen returns whether a collection field is frozen, set with the frozen
+// option of the tag (eg. `cql:"scores,frozen"`)
+func (f Field) Frozen() bool {
+	return f.options.Contains("frozen")
+}
+
+// Tuple returns whether the structs in the field are stored as tuples, set
+// with the tuple option of the tag (eg. `cql:"points,tuple"`)
+func (f Field) Tuple() bool {
+	return f.options.Contains("tuple")
+}
+
+// Sensitive returns whether the values of the field are redacted from logs,
+// set with the sensitive option of the tag (eg. `cql:"email,sensitive"`)
+func (f Field) Sensitive() bool {
+	return f.options.Contains("sensitive")
+}
+
+// Static returns whether the field is a static column, shared by all the rows
+// of a partition, set with the static option of the tag (eg.
+// `cql:"owner,static"`)
+func (f Field) Static() bool {
+	return f.options.Contains("static")
+}
+
+// WriteTime returns whether the field holds the write timestamp of a column
+// in microseconds, rather than the column itself. It is set with the writetime
+// option of the tag (eg. `cql:"name,writetime"`), and the field is named after
+// the selector, eg. "writetime(name)"
+func (f Field) WriteTime() bool {
+	return f.options.Contains("writetime")
+}
+
+// TTL returns whether the field holds the remaining time-to-live of a column
+// in seconds, rather than the column itself. It is set with the ttl option of
+// the tag (eg. `cql:"name,ttl"`), and the field is named after the selector,
+// eg. "ttl(name)"
+func (f Field) TTL() bool {
+	return f.options.Contains("ttl")
+}
+
+// Metadata returns whether the field holds the write timestamp or TTL of a
+// column. Such fields are read, but never written
+func (f Field) Metadata() bool {
+	return f.WriteTime() || f.TTL()
+}
+
 func fillField(f Field) Field {
 	f.nameBytes = []byte(f.name)
 
@@ -135,12 +207,19 @@ func typeFields(t reflect.Type) []Field {
 					if name == "" {
 						name = sf.Name
 					}
+					switch {
+					case opts.Contains("writetime"):
+						name = "writetime(" + name + ")"
+					case opts.Contains("ttl"):
+						name = "ttl(" + name + ")"
+					}
 					fields = append(fields, fillField(Field{
 						name:      name,
 						tag:       tagged,
 						index:     index,
 						typ:       ft,
 						omitEmpty: opts.Contains("omitempty"),
+						options:   opts,
 					}))
 					if count[f.typ] > 1 {
 						// If there were multiple instances, add a second,
@@ -29,6 +29,9 @@ func StructToMap(val interface{}) (map[string]interface{}, bool) {
 	structFields := cachedTypeFields(structVal.Type())
 	mapVal := make(map[string]interface{}, len(structFields))
 	for _, info := range structFields {
+		if info.Metadata() {
+			continue
+		}
 		field := fieldByIndex(structVal, info.index)
 		mapVal[info.name] = field.Interface()
 	}
@@ -84,12 +87,15 @@ func FieldsAndValues(val interface{}) ([]string, []interface{}, bool) {
 		return nil, nil, false
 	}
 	structFields := cachedTypeFields(structVal.Type())
-	fields := make([]string, len(structFields))
-	values := make([]interface{}, len(structFields))
-	for i, info := range structFields {
+	fields := make([]string, 0, len(structFields))
+	values := make([]interface{}, 0, len(structFields))
+	for _, info := range structFields {
+		if info.Metadata() {
+			continue
+		}
 		field := fieldByIndex(structVal, info.index)
-		fields[i] = info.name
-		values[i] = field.Interface()
+		fields = append(fields, info.name)
+		values = append(values, field.Interface())
 	}
 	return fields, values, true
 }
@@ -240,6 +240,127 @@ func TestStructFieldMapNonStruct(t *testing.T) {
 	}
 }
 
+func TestStructFieldMapUDT(t *testing.T) {
+	type Address struct {
+		Street string
+	}
+	type User struct {
+		Name    string
+		Home    Address  `cql:"home,udt=address_t"`
+		Work    *Address `cql:"work,omitempty,udt=address_t"`
+		Billing Address  `cql:"billing,udt"`
+	}
+
+	m, err := StructFieldMap(reflect.TypeOf(User{}), true)
+	if err != nil {
+		t.Fatalf("expected field map to be created, err: %v", err)
+	}
+
+	for name, udt := range map[string]string{"name": "", "home": "address_t", "work": "address_t", "billing": ""} {
+		if got := m[name].UDT(); got != udt {
+			t.Errorf("expected %s to have UDT %q but got %q", name, udt, got)
+		}
+	}
+}
+
+func TestStructFieldMapCQLType(t *testing.T) {
+	type User struct {
+		Name   string
+		Id     string         `cql:"id,type=timeuuid"`
+		Scores map[string]int `cql:"scores,omitempty,type=map<text;int>"`
+		Pair   []interface{}  `cql:"pair,type=frozen<tuple<int;text>>"`
+	}
+
+	m, err := StructFieldMap(reflect.TypeOf(User{}), true)
+	if err != nil {
+		t.Fatalf("expected field map to be created, err: %v", err)
+	}
+
+	for name, typ := range map[string]string{
+		"name":   "",
+		"id":     "timeuuid",
+		"scores": "map<text, int>",
+		"pair":   "frozen<tuple<int, text>>",
+	} {
+		if got := m[name].CQLType(); got != typ {
+			t.Errorf("expected %s to have CQL type %q but got %q", name, typ, got)
+		}
+	}
+}
+
+func TestStructFieldMapCollectionOptions(t *testing.T) {
+	type Point struct {
+		X, Y int
+	}
+	type Shape struct {
+		Tags   []string `cql:"tags,set"`
+		Sides  []int    `cql:",frozen"`
+		Points []Point  `cql:"points,tuple,frozen"`
+	}
+
+	m, err := StructFieldMap(reflect.TypeOf(Shape{}), true)
+	if err != nil {
+		t.Fatalf("expected field map to be created, err: %v", err)
+	}
+
+	if !m["tags"].Set() || m["tags"].Frozen() || m["tags"].Tuple() {
+		t.Errorf("expected tags to only be a set")
+	}
+	if m["sides"].Set() || !m["sides"].Frozen() || m["sides"].Tuple() {
+		t.Errorf("expected sides to only be frozen")
+	}
+	if m["points"].Set() || !m["points"].Frozen() || !m["points"].Tuple() {
+		t.Errorf("expected points to be frozen tuples")
+	}
+}
+
+func TestStructFieldMapSensitive(t *testing.T) {
+	type Account struct {
+		Id    string
+		Email string `cql:"email,sensitive"`
+	}
+
+	m, err := StructFieldMap(reflect.TypeOf(Account{}), true)
+	if err != nil {
+		t.Fatalf("expected field map to be created, err: %v", err)
+	}
+
+	if m["id"].Sensitive() || !m["email"].Sensitive() {
+		t.Errorf("expected only email to be sensitive")
+	}
+}
+
+func TestMetadataFields(t *testing.T) {
+	type Account struct {
+		Id           string
+		Email        string `cql:"email"`
+		EmailWritten int64  `cql:"email,writetime"`
+		EmailTTL     int    `cql:"email,ttl"`
+	}
+
+	m, err := StructFieldMap(reflect.TypeOf(Account{}), true)
+	if err != nil {
+		t.Fatalf("expected field map to be created, err: %v", err)
+	}
+	if f, ok := m["writetime(email)"]; !ok || !f.WriteTime() || !f.Metadata() {
+		t.Errorf("expected writetime(email) to be a write time field, got %+v", m)
+	}
+	if f, ok := m["ttl(email)"]; !ok || !f.TTL() || !f.Metadata() {
+		t.Errorf("expected ttl(email) to be a TTL field, got %+v", m)
+	}
+	if m["email"].Metadata() {
+		t.Errorf("expected email not to be a metadata field")
+	}
+
+	account := Account{Id: "1", Email: "jane@example.com", EmailWritten: 1, EmailTTL: 2}
+	if got, _ := StructToMap(account); !reflect.DeepEqual(got, map[string]interface{}{"Id": "1", "email": "jane@example.com"}) {
+		t.Errorf("expected metadata fields not to be written, got %v", got)
+	}
+	if fields, _, _ := FieldsAndValues(account); !reflect.DeepEqual(fields, []string{"Id", "email"}) {
+		t.Errorf("expected metadata fields not to be written, got %v", fields)
+	}
+}
+
 func TestFieldsAndValues(t *testing.T) {
 	var emptyUUID gocql.UUID
 	id := gocql.TimeUUID()
@@ -65,3 +65,21 @@ func (o tagOptions) Contains(optionName string) bool {
 	}
 	return false
 }
+
+// Get returns the value of a key=value option from a comma-separated list of
+// options, and whether the option was found
+func (o tagOptions) Get(optionName string) (string, bool) {
+	s := string(o)
+	for s != "" {
+		var next string
+		i := strings.Index(s, ",")
+		if i >= 0 {
+			s, next = s[:i], s[i+1:]
+		}
+		if j := strings.Index(s, "="); j >= 0 && s[:j] == optionName {
+			return s[j+1:], true
+		}
+		s = next
+	}
+	return "", false
+}
@@ -2,8 +2,13 @@ package gocassa
 
 import (
 	"fmt"
+	"math/big"
+	"net"
 	"reflect"
+	"strings"
 	"time"
+
+	"gopkg.in/inf.v0"
 )
 
 // Comparator represents a comparison operand
@@ -18,6 +23,8 @@ const (
 	CmpGreaterThanOrEquals                   // larger than or equal (foo >= 1)
 	CmpLesserThan                            // less than (foo < 1)
 	CmpLesserThanOrEquals                    // less than or equal (foo <= 1)
+	CmpContains                              // collection membership (foo CONTAINS 1)
+	CmpContainsKey                           // map key membership (foo CONTAINS KEY 1)
 )
 
 // Relation describes the comparison of a field against a list of terms
@@ -25,17 +32,37 @@ const (
 type Relation struct {
 	cmp   Comparator
 	field string
+	// fields are the fields of a tuple relation, which compares several
+	// fields at once. Each of its terms is a tuple with a value per field.
+	fields []string
 	// terms represents the list of terms on the right hand side to match
 	// against. It is expected that all comparators except the CmpIn have
 	// exactly one term.
 	terms []interface{}
 }
 
-// Field provides the field name for this relation
+// Field provides the field name for this relation, or the tuple of field
+// names, eg. "(a, b)", for a tuple relation
 func (r Relation) Field() string {
+	if r.isTuple() {
+		return "(" + strings.Join(r.fields, ", ") + ")"
+	}
 	return r.field
 }
 
+// Fields provides the field names compared by this relation, which is a
+// single field unless it's a tuple relation
+func (r Relation) Fields() []string {
+	if r.isTuple() {
+		return r.fields
+	}
+	return []string{r.field}
+}
+
+func (r Relation) isTuple() bool {
+	return len(r.fields) > 0
+}
+
 // Comparator provides the comparator for this relation
 func (r Relation) Comparator() Comparator {
 	return r.cmp
@@ -73,6 +100,13 @@ func convertToPrimitive(i interface{}) interface{} {
 		// We mostly want this to allow comparisons of blob types in the primary key of a table,
 		// since []byte are not `==` comparable in go, but strings are
 		return string(v)
+	case net.IP:
+		// IPs are also slices, and the same IP may be 4 or 16 bytes long
+		return v.String()
+	case *big.Int:
+		return v.String()
+	case *inf.Dec:
+		return v.String()
 	default:
 		// If the underlying type is a string, we want to represent this value
 		// as a string for comparison across proxy types.
@@ -87,10 +121,22 @@ func (r Relation) accept(i interface{}) bool {
 	var result bool
 	var err error
 
+	if r.isTuple() {
+		return r.acceptTuple(i)
+	}
+
 	if r.Comparator() == CmpEquality || r.Comparator() == CmpIn {
 		return anyEquals(i, r.Terms())
 	}
 
+	if r.Comparator() == CmpContains || r.Comparator() == CmpContainsKey {
+		return collectionContains(i, r.Terms()[0], r.Comparator() == CmpContainsKey)
+	}
+
+	if cmp, ok := compareNumbers(i, r.Terms()[0]); ok {
+		return rangeAccepts(r.Comparator(), cmp)
+	}
+
 	a, b := convertToPrimitive(i), convertToPrimitive(r.Terms()[0])
 
 	switch r.Comparator() {
@@ -109,6 +155,134 @@ func (r Relation) accept(i interface{}) bool {
 	return err == nil && result
 }
 
+// acceptTuple compares a tuple of values, one per field, against the terms of
+// a tuple relation. Tuples are ordered lexicographically, as clustering
+// columns are.
+func (r Relation) acceptTuple(i interface{}) bool {
+	values, ok := i.([]interface{})
+	if !ok {
+		return false
+	}
+
+	for _, term := range r.Terms() {
+		cmp, err := compareTuples(values, term)
+		if err != nil {
+			return false
+		}
+
+		switch r.Comparator() {
+		case CmpEquality, CmpIn:
+			if cmp == 0 {
+				return true
+			}
+		default:
+			return rangeAccepts(r.Comparator(), cmp)
+		}
+	}
+	return false
+}
+
+// rangeAccepts returns whether the result of comparing a value with a term,
+// -1, 0 or 1, satisfies a range comparator
+func rangeAccepts(comparator Comparator, cmp int) bool {
+	switch comparator {
+	case CmpGreaterThan:
+		return cmp > 0
+	case CmpGreaterThanOrEquals:
+		return cmp >= 0
+	case CmpLesserThan:
+		return cmp < 0
+	case CmpLesserThanOrEquals:
+		return cmp <= 0
+	}
+	return false
+}
+
+// compareNumbers compares arbitrary precision numbers with their Cmp method,
+// as their primitive form is a string which doesn't sort numerically. It
+// returns false if the values aren't both numbers of the same type
+func compareNumbers(a, b interface{}) (int, bool) {
+	switch a := a.(type) {
+	case *big.Int:
+		if b, ok := b.(*big.Int); ok && a != nil && b != nil {
+			return a.Cmp(b), true
+		}
+	case *inf.Dec:
+		if b, ok := b.(*inf.Dec); ok && a != nil && b != nil {
+			return a.Cmp(b), true
+		}
+	}
+	return 0, false
+}
+
+// compareTuples returns -1, 0 or 1 if the values are less than, equal to or
+// greater than the tuple term
+func compareTuples(values []interface{}, term interface{}) (int, error) {
+	terms, ok := term.([]interface{})
+	if !ok || len(terms) != len(values) {
+		return 0, fmt.Errorf("tuple of %d values can't be compared with %v", len(values), term)
+	}
+
+	for i := range values {
+		if values[i] == nil || terms[i] == nil {
+			return 0, fmt.Errorf("can't compare null tuple values")
+		}
+		if cmp, ok := compareNumbers(values[i], terms[i]); ok {
+			if cmp != 0 {
+				return cmp, nil
+			}
+			continue
+		}
+		a, b := convertToPrimitive(values[i]), convertToPrimitive(terms[i])
+		if a == b {
+			continue
+		}
+		less, err := builtinLessThan(a, b)
+		if err != nil {
+			return 0, err
+		}
+		if less {
+			return -1, nil
+		}
+		greater, err := builtinGreaterThan(a, b)
+		if err != nil {
+			return 0, err
+		}
+		if greater {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// collectionContains returns whether a list, set or map contains the term,
+// or for keys whether a map has the term as a key
+func collectionContains(collection, term interface{}, keys bool) bool {
+	v := reflect.ValueOf(collection)
+	switch {
+	case v.Kind() == reflect.Map && keys:
+		for _, k := range v.MapKeys() {
+			if anyEquals(k.Interface(), toI(term)) {
+				return true
+			}
+		}
+	case v.Kind() == reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if anyEquals(iter.Value().Interface(), toI(term)) {
+				return true
+			}
+		}
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !keys:
+		for i := 0; i < v.Len(); i++ {
+			if anyEquals(v.Index(i).Interface(), toI(term)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func toI(i interface{}) []interface{} {
 	return []interface{}{i}
 }
@@ -164,3 +338,79 @@ func LTE(field string, term interface{}) Relation {
 		terms: toI(term),
 	}
 }
+
+// Contains matches rows whose list, set or map column contains the term as
+// one of its values. Without the partition key, the column needs an index or
+// filtering to be allowed.
+func Contains(field string, term interface{}) Relation {
+	return Relation{
+		cmp:   CmpContains,
+		field: field,
+		terms: toI(term),
+	}
+}
+
+// ContainsKey matches rows whose map column has the term as one of its keys.
+// Without the partition key, the keys of the column need an index (see
+// Index.Keys) or filtering to be allowed.
+func ContainsKey(field string, term interface{}) Relation {
+	return Relation{
+		cmp:   CmpContainsKey,
+		field: field,
+		terms: toI(term),
+	}
+}
+
+// InTuple allows several fields to be queried with multiple tuples of terms
+// simultaneously, eg. (a, b) IN ((1, 2), (3, 4)). Each tuple must have a term
+// for every field.
+func InTuple(fields []string, terms ...[]interface{}) Relation {
+	tuples := make([]interface{}, len(terms))
+	for i, term := range terms {
+		tuples[i] = term
+	}
+	return Relation{
+		cmp:    CmpIn,
+		fields: fields,
+		terms:  tuples,
+	}
+}
+
+// GTTuple compares several fields at once against a tuple of terms, eg.
+// (a, b) > (1, 2). Tuples are compared lexicographically, which makes this
+// useful for paging through rows with several clustering columns. The fields
+// must be clustering columns, in their order in the primary key.
+func GTTuple(fields []string, terms []interface{}) Relation {
+	return Relation{
+		cmp:    CmpGreaterThan,
+		fields: fields,
+		terms:  toI(terms),
+	}
+}
+
+// GTETuple is the >= equivalent of GTTuple
+func GTETuple(fields []string, terms []interface{}) Relation {
+	return Relation{
+		cmp:    CmpGreaterThanOrEquals,
+		fields: fields,
+		terms:  toI(terms),
+	}
+}
+
+// LTTuple is the < equivalent of GTTuple
+func LTTuple(fields []string, terms []interface{}) Relation {
+	return Relation{
+		cmp:    CmpLesserThan,
+		fields: fields,
+		terms:  toI(terms),
+	}
+}
+
+// LTETuple is the <= equivalent of GTTuple
+func LTETuple(fields []string, terms []interface{}) Relation {
+	return Relation{
+		cmp:    CmpLesserThanOrEquals,
+		fields: fields,
+		terms:  toI(terms),
+	}
+}
@@ -1,8 +1,11 @@
 package gocassa
 
 import (
+	"math/big"
 	"testing"
 	"time"
+
+	"gopkg.in/inf.v0"
 )
 
 func TestAnyEquals(t *testing.T) {
@@ -32,6 +35,94 @@ func TestAnyEquals(t *testing.T) {
 	}
 }
 
+func TestTupleRelationAccept(t *testing.T) {
+	fields := []string{"a", "b"}
+	values := []interface{}{1, "m"}
+
+	testCases := []struct {
+		relation Relation
+		accept   bool
+	}{
+		{GTTuple(fields, []interface{}{0, "z"}), true},
+		{GTTuple(fields, []interface{}{1, "a"}), true},
+		{GTTuple(fields, []interface{}{1, "m"}), false},
+		{GTETuple(fields, []interface{}{1, "m"}), true},
+		{GTETuple(fields, []interface{}{2, "a"}), false},
+		{LTTuple(fields, []interface{}{2, "a"}), true},
+		{LTTuple(fields, []interface{}{1, "m"}), false},
+		{LTETuple(fields, []interface{}{1, "m"}), true},
+		{LTETuple(fields, []interface{}{1, "a"}), false},
+		{InTuple(fields, []interface{}{0, "m"}, []interface{}{1, "m"}), true},
+		{InTuple(fields, []interface{}{1, "a"}), false},
+		// Tuples of a different size or type never match
+		{GTETuple(fields, []interface{}{1}), false},
+		{GTETuple(fields, []interface{}{"1", "m"}), false},
+	}
+
+	for _, tc := range testCases {
+		if accept := tc.relation.accept(values); accept != tc.accept {
+			t.Fatalf("expected %v, got %v (testcase: %v)", tc.accept, accept, tc)
+		}
+	}
+	if field := GTTuple(fields, values).Field(); field != "(a, b)" {
+		t.Fatalf("unexpected field %s", field)
+	}
+}
+
+func TestContainsRelationAccept(t *testing.T) {
+	type name string
+
+	testCases := []struct {
+		relation Relation
+		value    interface{}
+		accept   bool
+	}{
+		{Contains("tags", "a"), []string{"a", "b"}, true},
+		{Contains("tags", "c"), []string{"a", "b"}, false},
+		{Contains("tags", "a"), []name{"a"}, true},
+		{Contains("scores", 1), map[string]int{"a": 1}, true},
+		{Contains("scores", "a"), map[string]int{"a": 1}, false},
+		{ContainsKey("scores", "a"), map[string]int{"a": 1}, true},
+		{ContainsKey("scores", "b"), map[string]int{"a": 1}, false},
+		{ContainsKey("tags", "a"), []string{"a"}, false},
+		{Contains("tags", "a"), nil, false},
+	}
+
+	for _, tc := range testCases {
+		if accept := tc.relation.accept(tc.value); accept != tc.accept {
+			t.Fatalf("expected %v, got %v (testcase: %v)", tc.accept, accept, tc)
+		}
+	}
+}
+
+func TestNumberRelationAccept(t *testing.T) {
+	testCases := []struct {
+		relation Relation
+		value    interface{}
+		accept   bool
+	}{
+		{GT("amount", big.NewInt(9)), big.NewInt(10), true},
+		{GT("amount", big.NewInt(10)), big.NewInt(9), false},
+		{GTE("amount", big.NewInt(100)), big.NewInt(100), true},
+		{LT("amount", big.NewInt(-1)), big.NewInt(-5), true},
+		{LT("amount", big.NewInt(-5)), big.NewInt(-10), true},
+		{LTE("amount", big.NewInt(-10)), big.NewInt(-5), false},
+		{GT("amount", inf.NewDec(95, 1)), inf.NewDec(1005, 2), true},
+		{LT("amount", inf.NewDec(-5, 0)), inf.NewDec(-105, 1), true},
+		{GTE("amount", inf.NewDec(-105, 1)), inf.NewDec(-5, 0), true},
+		{LTE("amount", inf.NewDec(10, 0)), inf.NewDec(100, 1), true},
+		{LT("amount", inf.NewDec(10, 0)), inf.NewDec(100, 1), false},
+		{GTTuple([]string{"a", "b"}, []interface{}{big.NewInt(9), "z"}), []interface{}{big.NewInt(10), "a"}, true},
+		{LTTuple([]string{"a", "b"}, []interface{}{big.NewInt(-1), "a"}), []interface{}{big.NewInt(-5), "z"}, true},
+	}
+
+	for _, tc := range testCases {
+		if accept := tc.relation.accept(tc.value); accept != tc.accept {
+			t.Fatalf("expected %v, got %v (testcase: %v)", tc.accept, accept, tc)
+		}
+	}
+}
+
 func makeInterfaceArray(terms ...interface{}) []interface{} {
 	interfaceSlice := make([]interface{}, len(terms))
 	for i, d := range terms {
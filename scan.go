@@ -0,0 +1,155 @@
+package gocassa
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ScanOptions configures a full table scan
+type ScanOptions struct {
+	// Splits is the number of token ranges the token ring is split into, each
+	// range is read with a separate query. Defaults to 1
+	Splits int
+	// Concurrency is the number of token ranges read in parallel. Defaults to 1
+	Concurrency int
+}
+
+// tokenRange is a range of tokens on the Murmur3 token ring, exclusive of
+// start and inclusive of end (as Cassandra uses)
+type tokenRange struct {
+	start, end int64
+}
+
+// tokenRanges splits the whole token ring into the given number of evenly
+// sized ranges
+func tokenRanges(splits int) []tokenRange {
+	step := uint64(math.MaxUint64) / uint64(splits)
+	ranges := make([]tokenRange, splits)
+	for i := range ranges {
+		// This relies on integer overflow wrapping around, the ring starts at
+		// the minimum int64 rather than 0
+		ranges[i].start = math.MinInt64 + int64(uint64(i)*step)
+		ranges[i].end = math.MinInt64 + int64(uint64(i+1)*step)
+	}
+	ranges[len(ranges)-1].end = math.MaxInt64
+	return ranges
+}
+
+func (r tokenRange) contains(token int64) bool {
+	return token > r.start && token <= r.end
+}
+
+// tokenRelations returns the relations restricting the partition key to a
+// token range, eg. "token(id) > ? AND token(id) <= ?"
+func tokenRelations(partitionKeys []string, r tokenRange) []Relation {
+	field := "token(" + strings.Join(partitionKeys, ", ") + ")"
+	return []Relation{GT(field, r.start), LTE(field, r.end)}
+}
+
+// runScan reads each token range with scanRange, using up to the configured
+// number of workers. The first error cancels the scan and is returned
+func runScan(ctx context.Context, opts ScanOptions, scanRange func(context.Context, tokenRange) error) error {
+	if opts.Splits < 1 {
+		opts.Splits = 1
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.Concurrency > opts.Splits {
+		opts.Concurrency = opts.Splits
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ranges := make(chan tokenRange)
+	go func() {
+		defer close(ranges)
+		for _, r := range tokenRanges(opts.Splits) {
+			select {
+			case ranges <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range ranges {
+				if err := scanRange(ctx, r); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	// Ranges may have been skipped if the parent context was cancelled
+	return ctx.Err()
+}
+
+// scanRows decodes each row of the iterator into a new instance of rowType
+// and passes it to the callback, closing the iterator once done
+func scanRows(iter Iterator, rowType reflect.Type, callback func(row interface{}) error) error {
+	defer iter.Close()
+	for {
+		row := reflect.New(rowType).Interface()
+		if !iter.Next(row) {
+			break
+		}
+		if err := callback(row); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// scanRowType returns the struct type rows of a table with the given entity
+// are decoded into
+func scanRowType(entity interface{}) (reflect.Type, error) {
+	typ := getNonPtrType(reflect.TypeOf(entity))
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can only scan tables with a struct entity, not %T", entity)
+	}
+	return typ, nil
+}
+
+func (t t) Scan(ctx context.Context, opts ScanOptions, callback func(row interface{}) error) error {
+	rowType, err := scanRowType(t.info.marshalSource)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	var rows int64
+	err = runScan(ctx, opts, func(ctx context.Context, r tokenRange) error {
+		iter := t.Where(tokenRelations(t.info.keys.PartitionKeys, r)...).IterWithContext(ctx)
+		return scanRows(iter, rowType, func(row interface{}) error {
+			atomic.AddInt64(&rows, 1)
+			return callback(row)
+		})
+	})
+	t.logScan(ctx, start, atomic.LoadInt64(&rows), err)
+	return err
+}
@@ -0,0 +1,57 @@
+package gocassa
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRanges(t *testing.T) {
+	ranges := tokenRanges(1)
+	assert.Equal(t, []tokenRange{{start: math.MinInt64, end: math.MaxInt64}}, ranges)
+
+	ranges = tokenRanges(5)
+	assert.Len(t, ranges, 5)
+	assert.Equal(t, int64(math.MinInt64), ranges[0].start)
+	assert.Equal(t, int64(math.MaxInt64), ranges[4].end)
+	for i := 1; i < len(ranges); i++ {
+		assert.Equal(t, ranges[i-1].end, ranges[i].start)
+		assert.True(t, ranges[i].start < ranges[i].end)
+	}
+}
+
+func TestPartitionKeyToken(t *testing.T) {
+	// Expected tokens were generated by Cassandra
+	k := key{{Key: "id", Value: "0"}}
+	assert.Equal(t, int64(0x2ac9debed546a380), k.Token())
+
+	id, err := gocql.ParseUUID("4327529f-b645-dd00-b883-ec39ae448bb8")
+	assert.NoError(t, err)
+	k = key{{Key: "id", Value: id}, {Key: "n", Value: 420459}}
+	assert.Equal(t, int64(-9223371632693506265), k.Token())
+}
+
+func TestScan(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	conn := &connection{q: qe}
+	ks := conn.KeySpace("user")
+	cs := ks.Table("user", Customer{}, Keys{PartitionKeys: []string{"Id", "Name"}}).
+		WithOptions(Options{TableName: "user_by_id"})
+
+	err := cs.Scan(context.Background(), ScanOptions{Splits: 2}, func(row interface{}) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id, name FROM user.user_by_id WHERE token(id, name) > ? AND token(id, name) <= ?", qe.stmt.Query())
+	assert.Equal(t, []interface{}{tokenRanges(2)[1].start, int64(math.MaxInt64)}, qe.stmt.Values())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = cs.Scan(ctx, ScanOptions{Splits: 2}, func(row interface{}) error {
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+}
@@ -136,6 +136,39 @@ func (s *scanner) iterSingle(iter Scannable) (int, error) {
 	return 1, nil
 }
 
+// scanRow decodes the current row of the iterator into the struct pointed to
+// by result, zeroing it first so nothing is left over from a previous row
+func scanRow(iter Scannable, fields []string, result interface{}) error {
+	if result == nil || reflect.TypeOf(result).Kind() != reflect.Ptr {
+		return fmt.Errorf("can only decode into a pointer to a struct, not %T", result)
+	}
+	if err := allocateNilReference(result); err != nil {
+		return err
+	}
+
+	outVal := reflect.ValueOf(result).Elem()
+	for outVal.Kind() == reflect.Ptr {
+		outVal = outVal.Elem()
+	}
+	if outVal.Kind() != reflect.Struct {
+		return fmt.Errorf("can only decode into a pointer to a struct, not %T", result)
+	}
+	outVal.Set(reflect.Zero(outVal.Type()))
+
+	fieldMap, err := r.StructFieldMap(outVal.Type(), true)
+	if err != nil {
+		return fmt.Errorf("could not decode struct of type %v: %v", outVal.Type(), err)
+	}
+
+	ptrs := generatePtrs(fields, fieldMap, outVal)
+	if err := iter.Scan(ptrs...); err != nil {
+		return err
+	}
+	removeSentinelValues(ptrs)
+	fillInZeroedPtrs(ptrs)
+	return nil
+}
+
 // generatePtrs takes in a list of fields, the field map giving the type info
 // per field and the target struct value and generates a list of interface
 // pointers
@@ -182,6 +215,10 @@ func generatePtrs(fields []string, fieldMap map[string]r.Field, structVal reflec
 			}
 		}
 
+		if field.UDT() != "" {
+			ptrs[i] = &udtValue{v: elem}
+			continue
+		}
 		ptrs[i] = elem.Addr().Interface()
 	}
 	return ptrs
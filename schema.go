@@ -0,0 +1,360 @@
+package gocassa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SchemaColumn describes a column of a table
+type SchemaColumn struct {
+	Name string
+	// Type is the CQL type of the column, eg. "map<text, frozen<list<int>>>"
+	Type string
+	// Kind is one of "partition_key", "clustering", "regular" or "static"
+	Kind string
+	// ClusteringOrder is "asc" or "desc" for clustering columns, and "none"
+	// for all other columns
+	ClusteringOrder string
+}
+
+// SchemaColumnChange describes a column whose type or kind differs between a
+// table in Cassandra and the schema generated for it
+type SchemaColumnChange struct {
+	Current  SchemaColumn
+	Expected SchemaColumn
+}
+
+func (c SchemaColumnChange) String() string {
+	return fmt.Sprintf("%s %s %s (%s) can't be changed to %s %s (%s)", c.Current.Kind, c.Current.Name,
+		c.Current.Type, c.Current.ClusteringOrder, c.Expected.Kind, c.Expected.Type, c.Expected.ClusteringOrder)
+}
+
+// SchemaDiff describes how a table in Cassandra differs from the schema
+// generated for it (which CreateStatement returns)
+type SchemaDiff struct {
+	Keyspace string
+	Table    string
+	// Exists is false if the table doesn't exist at all, in which case the
+	// other fields are empty
+	Exists bool
+	// AddedColumns are fields of the row which are missing from the table
+	AddedColumns []SchemaColumn
+	// DroppedColumns are columns of the table which aren't fields of the row
+	DroppedColumns []SchemaColumn
+	// ChangedColumns are columns of the table whose type, kind or clustering
+	// order doesn't match the row, which Cassandra doesn't allow altering
+	ChangedColumns []SchemaColumnChange
+	// Properties are the table properties (as CQL) which need altering
+	Properties map[string]string
+
+	// createStmts create the table if it doesn't exist, and typeStmts create
+	// the user-defined types of added columns
+	createStmts []Statement
+	typeStmts   []Statement
+}
+
+// Empty returns whether the table in Cassandra matches its generated schema
+func (d SchemaDiff) Empty() bool {
+	return d.Exists &&
+		len(d.AddedColumns) == 0 &&
+		len(d.DroppedColumns) == 0 &&
+		len(d.ChangedColumns) == 0 &&
+		len(d.Properties) == 0
+}
+
+// MigrateOptions configures how a table is migrated
+type MigrateOptions struct {
+	// AllowDrop allows columns which aren't fields of the row to be dropped,
+	// along with all their data. Otherwise they're left as is
+	AllowDrop bool
+}
+
+// Statements returns the statements which migrate the table to match its
+// generated schema. It returns an error if there are changed columns, as
+// these can't be migrated
+func (d SchemaDiff) Statements(opts MigrateOptions) ([]Statement, error) {
+	if !d.Exists {
+		return d.createStmts, nil
+	}
+
+	if len(d.ChangedColumns) > 0 {
+		changes := make([]string, len(d.ChangedColumns))
+		for i, change := range d.ChangedColumns {
+			changes[i] = change.String()
+		}
+		return nil, fmt.Errorf("can't migrate %s.%s: %s", d.Keyspace, d.Table, strings.Join(changes, "; "))
+	}
+
+	stmts := append([]Statement{}, d.typeStmts...)
+	for _, col := range d.AddedColumns {
+		query := fmt.Sprintf("ALTER TABLE %s.%s ADD %s %s", d.Keyspace, d.Table, col.Name, col.Type)
+		if col.Kind == "static" {
+			query += " static"
+		}
+		stmts = append(stmts, cqlStatement{query: query})
+	}
+	if opts.AllowDrop {
+		for _, col := range d.DroppedColumns {
+			stmts = append(stmts, cqlStatement{query: fmt.Sprintf("ALTER TABLE %s.%s DROP %s", d.Keyspace, d.Table, col.Name)})
+		}
+	}
+	if len(d.Properties) > 0 {
+		props := make([]string, 0, len(d.Properties))
+		for name, value := range d.Properties {
+			props = append(props, fmt.Sprintf("%s = %s", name, value))
+		}
+		sort.Strings(props)
+		stmts = append(stmts, cqlStatement{query: fmt.Sprintf("ALTER TABLE %s.%s WITH %s", d.Keyspace, d.Table, strings.Join(props, " AND "))})
+	}
+	return stmts, nil
+}
+
+type schemaColumnMarshal struct {
+	ColumnName      string `cql:"column_name"`
+	Kind            string `cql:"kind"`
+	Type            string `cql:"type"`
+	Position        int    `cql:"position"`
+	ClusteringOrder string `cql:"clustering_order"`
+}
+
+type schemaTableMarshal struct {
+	TableName           string            `cql:"table_name"`
+	BloomFilterFPChance float64           `cql:"bloom_filter_fp_chance"`
+	Caching             map[string]string `cql:"caching"`
+	Comment             string            `cql:"comment"`
+	Compaction          map[string]string `cql:"compaction"`
+	Compression         map[string]string `cql:"compression"`
+	DefaultTTL          int               `cql:"default_time_to_live"`
+	GCGraceSeconds      int               `cql:"gc_grace_seconds"`
+}
+
+func (t t) SchemaDiff(ctx context.Context) (SchemaDiff, error) {
+	diff := SchemaDiff{
+		Keyspace: t.keySpace.name,
+		Table:    t.Name(),
+	}
+	opts := Options{Context: ctx}
+
+	// Views are listed separately from tables, but their columns aren't
+	tables := []schemaTableMarshal{}
+	stmt := SelectStatement{
+		keyspace: "system_schema",
+		table:    "tables",
+		fields: []string{"table_name", "bloom_filter_fp_chance", "caching", "comment", "compaction",
+			"compression", "default_time_to_live", "gc_grace_seconds"},
+		where: []Relation{Eq("keyspace_name", diff.Keyspace), Eq("table_name", strings.ToLower(diff.Table))},
+	}
+	if t.base != nil {
+		stmt.table = "views"
+		stmt.fields = []string{"view_name"}
+		stmt.where[1] = Eq("view_name", strings.ToLower(diff.Table))
+	}
+	if err := t.keySpace.qe.QueryWithOptions(opts, stmt, NewScanner(stmt, &tables)); err != nil {
+		return diff, err
+	}
+
+	if len(tables) == 0 {
+		createStmts, err := t.createStatements()
+		if err != nil {
+			return diff, err
+		}
+		diff.createStmts = createStmts
+		return diff, nil
+	}
+	diff.Exists = true
+
+	columns := []schemaColumnMarshal{}
+	stmt = SelectStatement{
+		keyspace: "system_schema",
+		table:    "columns",
+		fields:   []string{"column_name", "kind", "type", "position", "clustering_order"},
+		where:    []Relation{Eq("keyspace_name", diff.Keyspace), Eq("table_name", strings.ToLower(diff.Table))},
+	}
+	if err := t.keySpace.qe.QueryWithOptions(opts, stmt, NewScanner(stmt, &columns)); err != nil {
+		return diff, err
+	}
+	current := map[string]SchemaColumn{}
+	for _, col := range columns {
+		current[col.ColumnName] = SchemaColumn{
+			Name:            col.ColumnName,
+			Type:            col.Type,
+			Kind:            col.Kind,
+			ClusteringOrder: col.ClusteringOrder,
+		}
+	}
+
+	expected, err := t.schemaColumns()
+	if err != nil {
+		return diff, err
+	}
+	addsUDT := false
+	for _, exp := range expected {
+		cur, ok := current[exp.Name]
+		switch {
+		case !ok:
+			diff.AddedColumns = append(diff.AddedColumns, exp)
+			addsUDT = addsUDT || t.info.fieldInfo[exp.Name].UDT() != ""
+		case cur.Kind != exp.Kind || normalizeCQLType(cur.Type) != normalizeCQLType(exp.Type) ||
+			(exp.Kind == "clustering" && cur.ClusteringOrder != exp.ClusteringOrder):
+			diff.ChangedColumns = append(diff.ChangedColumns, SchemaColumnChange{Current: cur, Expected: exp})
+		}
+		delete(current, exp.Name)
+	}
+	for _, name := range sortedColumnNames(current) {
+		diff.DroppedColumns = append(diff.DroppedColumns, current[name])
+	}
+
+	if addsUDT {
+		if diff.typeStmts, err = t.CreateTypeStatements(); err != nil {
+			return diff, err
+		}
+	}
+
+	if t.base == nil {
+		for _, prop := range tableProperties(t.options.Properties, t.options.Compressor) {
+			if prop.matches(tables[0]) {
+				continue
+			}
+			if diff.Properties == nil {
+				diff.Properties = map[string]string{}
+			}
+			diff.Properties[prop.name] = prop.value
+		}
+	}
+	return diff, nil
+}
+
+func (t t) Migrate(ctx context.Context, opts MigrateOptions) error {
+	diff, err := t.SchemaDiff(ctx)
+	if err != nil {
+		return err
+	}
+	if t.base != nil && diff.Exists && !diff.Empty() {
+		return fmt.Errorf("can't migrate view %s.%s, it needs recreating", diff.Keyspace, diff.Table)
+	}
+
+	stmts, err := diff.Statements(opts)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if err := t.keySpace.qe.ExecuteWithOptions(Options{Context: ctx}, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createStatements returns every statement which Create runs
+func (t t) createStatements() ([]Statement, error) {
+	typeStmts, err := t.CreateTypeStatements()
+	if err != nil {
+		return nil, err
+	}
+	createStmt, err := t.CreateIfNotExistStatement()
+	if err != nil {
+		return nil, err
+	}
+	indexStmts, err := t.CreateIndexStatements()
+	if err != nil {
+		return nil, err
+	}
+	return append(append(typeStmts, createStmt), indexStmts...), nil
+}
+
+// schemaColumns returns the columns of the schema generated for the table,
+// as Cassandra describes them in system_schema.columns
+func (t t) schemaColumns() ([]SchemaColumn, error) {
+	desc := map[string]bool{}
+	for _, o := range t.options.ClusteringOrder {
+		desc[strings.ToLower(o.Column)] = o.Direction == DESC
+	}
+
+	cols := make([]SchemaColumn, len(t.info.fields))
+	for i, field := range t.info.fields {
+		name := strings.ToLower(field)
+		typ, err := columnTypeOf(t.info.fieldValues[i], t.info.fieldInfo[name])
+		if err != nil {
+			return nil, err
+		}
+		col := SchemaColumn{Name: name, Type: typ, Kind: "regular", ClusteringOrder: "none"}
+		switch {
+		case containsField(t.info.keys.PartitionKeys, field):
+			col.Kind = "partition_key"
+		case containsField(t.info.keys.ClusteringColumns, field):
+			col.Kind = "clustering"
+			col.ClusteringOrder = "asc"
+			if desc[name] {
+				col.ClusteringOrder = "desc"
+			}
+		case containsField(t.info.staticColumns, field):
+			col.Kind = "static"
+		}
+		cols[i] = col
+	}
+	return cols, nil
+}
+
+func sortedColumnNames(m map[string]SchemaColumn) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// replaceTypeName replaces the type names of typ which are old, leaving alone
+// the names which only contain it, such as those of user defined types
+func replaceTypeName(typ, old, replacement string) string {
+	var b strings.Builder
+	start := 0
+	for i := 0; i <= len(typ); i++ {
+		if i < len(typ) && !strings.ContainsRune("<>,", rune(typ[i])) {
+			continue
+		}
+		if name := typ[start:i]; name == old {
+			b.WriteString(replacement)
+		} else {
+			b.WriteString(name)
+		}
+		if i < len(typ) {
+			b.WriteByte(typ[i])
+		}
+		start = i + 1
+	}
+	return b.String()
+}
+
+// normalizeCQLType converts a CQL type to the form Cassandra describes it in,
+// as varchar is an alias of text and tuples are always frozen
+func normalizeCQLType(typ string) string {
+	typ = strings.ToLower(strings.Join(strings.Fields(typ), ""))
+	typ = replaceTypeName(typ, "varchar", "text")
+
+	for {
+		i := strings.Index(typ, "frozen<tuple<")
+		if i < 0 {
+			return typ
+		}
+		// Find the bracket closing the frozen<...>
+		depth, end := 0, -1
+		for j := i + len("frozen"); j < len(typ) && end < 0; j++ {
+			switch typ[j] {
+			case '<':
+				depth++
+			case '>':
+				depth--
+				if depth == 0 {
+					end = j
+				}
+			}
+		}
+		if end < 0 {
+			return typ
+		}
+		typ = typ[:i] + typ[i+len("frozen<"):end] + typ[end+1:]
+	}
+}
@@ -0,0 +1,241 @@
+package gocassa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// schemaQE returns canned rows for queries of system_schema, and records
+// every statement executed
+type schemaQE struct {
+	*OptionCheckingQE
+	tables   []map[string]interface{}
+	columns  []map[string]interface{}
+	executed []string
+}
+
+func (qe *schemaQE) QueryWithOptions(opts Options, stmt Statement, scanner Scanner) error {
+	sel := stmt.(SelectStatement)
+	rows := qe.columns
+	if sel.table == "tables" || sel.table == "views" {
+		rows = qe.tables
+	}
+	_, err := scanner.ScanIter(newMockIterator(rows, sel.fields))
+	return err
+}
+
+func (qe *schemaQE) ExecuteWithOptions(opts Options, stmt Statement) error {
+	qe.executed = append(qe.executed, stmt.Query())
+	return nil
+}
+
+type account struct {
+	Id      string
+	Opened  time.Time
+	Owner   string
+	Balance int64
+	Tags    []string `cql:",set"`
+}
+
+func schemaColumnRow(name, kind, typ, order string) map[string]interface{} {
+	return map[string]interface{}{
+		"column_name":      name,
+		"kind":             kind,
+		"type":             typ,
+		"position":         -1,
+		"clustering_order": order,
+	}
+}
+
+func newSchemaTestTable(qe *schemaQE) Table {
+	conn := &connection{q: qe}
+	return conn.KeySpace("bank").Table("account", account{}, Keys{
+		PartitionKeys:     []string{"Id"},
+		ClusteringColumns: []string{"Opened"},
+	}).WithOptions(Options{
+		TableName:       "account",
+		ClusteringOrder: []ClusteringOrderColumn{{DESC, "Opened"}},
+	})
+}
+
+func TestSchemaDiff(t *testing.T) {
+	qe := &schemaQE{
+		OptionCheckingQE: &OptionCheckingQE{opts: &Options{}},
+		tables:           []map[string]interface{}{{"table_name": "account", "compression": map[string]string{}}},
+		columns: []map[string]interface{}{
+			schemaColumnRow("id", "partition_key", "text", "none"),
+			schemaColumnRow("opened", "clustering", "timestamp", "desc"),
+			schemaColumnRow("owner", "regular", "varchar", "none"),
+			schemaColumnRow("legacy", "regular", "int", "none"),
+		},
+	}
+	tbl := newSchemaTestTable(qe)
+
+	diff, err := tbl.SchemaDiff(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, diff.Exists)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []SchemaColumn{
+		{Name: "balance", Type: "bigint", Kind: "regular", ClusteringOrder: "none"},
+		{Name: "tags", Type: "set<varchar>", Kind: "regular", ClusteringOrder: "none"},
+	}, diff.AddedColumns)
+	assert.Equal(t, []SchemaColumn{
+		{Name: "legacy", Type: "int", Kind: "regular", ClusteringOrder: "none"},
+	}, diff.DroppedColumns)
+	assert.Empty(t, diff.ChangedColumns)
+	assert.Empty(t, diff.Properties)
+
+	assert.NoError(t, tbl.Migrate(context.Background(), MigrateOptions{}))
+	assert.Equal(t, []string{
+		"ALTER TABLE bank.account ADD balance bigint",
+		"ALTER TABLE bank.account ADD tags set<varchar>",
+	}, qe.executed)
+
+	qe.executed = nil
+	assert.NoError(t, tbl.Migrate(context.Background(), MigrateOptions{AllowDrop: true}))
+	assert.Equal(t, []string{
+		"ALTER TABLE bank.account ADD balance bigint",
+		"ALTER TABLE bank.account ADD tags set<varchar>",
+		"ALTER TABLE bank.account DROP legacy",
+	}, qe.executed)
+}
+
+func TestSchemaDiffUpToDate(t *testing.T) {
+	qe := &schemaQE{
+		OptionCheckingQE: &OptionCheckingQE{opts: &Options{}},
+		tables: []map[string]interface{}{{"table_name": "account", "compression": map[string]string{
+			"class": "org.apache.cassandra.io.compress.LZ4Compressor",
+		}}},
+		columns: []map[string]interface{}{
+			schemaColumnRow("id", "partition_key", "text", "none"),
+			schemaColumnRow("opened", "clustering", "timestamp", "desc"),
+			schemaColumnRow("owner", "regular", "text", "none"),
+			schemaColumnRow("balance", "regular", "bigint", "none"),
+			schemaColumnRow("tags", "regular", "set<text>", "none"),
+		},
+	}
+	tbl := newSchemaTestTable(qe)
+
+	diff, err := tbl.SchemaDiff(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, diff.Empty())
+	assert.NoError(t, tbl.Migrate(context.Background(), MigrateOptions{AllowDrop: true}))
+	assert.Empty(t, qe.executed)
+
+	// Changing the compressor alters the table's properties
+	tbl = tbl.WithOptions(Options{Compressor: "DeflateCompressor"})
+	diff, err = tbl.SchemaDiff(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"compression": "{'sstable_compression': 'DeflateCompressor'}"}, diff.Properties)
+	assert.NoError(t, tbl.Migrate(context.Background(), MigrateOptions{}))
+	assert.Equal(t, []string{
+		"ALTER TABLE bank.account WITH compression = {'sstable_compression': 'DeflateCompressor'}",
+	}, qe.executed)
+}
+
+func TestSchemaDiffProperties(t *testing.T) {
+	qe := &schemaQE{
+		OptionCheckingQE: &OptionCheckingQE{opts: &Options{}},
+		tables: []map[string]interface{}{{
+			"table_name":             "account",
+			"bloom_filter_fp_chance": 0.01,
+			"caching":                map[string]string{"keys": "ALL", "rows_per_partition": "NONE"},
+			"comment":                "",
+			"compaction": map[string]string{
+				"class":                  "org.apache.cassandra.db.compaction.TimeWindowCompactionStrategy",
+				"compaction_window_size": "1",
+				"compaction_window_unit": "DAYS",
+				"max_threshold":          "32",
+			},
+			"compression":          map[string]string{"class": "org.apache.cassandra.io.compress.LZ4Compressor", "chunk_length_in_kb": "16"},
+			"default_time_to_live": 0,
+			"gc_grace_seconds":     864000,
+		}},
+		columns: []map[string]interface{}{
+			schemaColumnRow("id", "partition_key", "text", "none"),
+			schemaColumnRow("opened", "clustering", "timestamp", "desc"),
+			schemaColumnRow("owner", "regular", "text", "none"),
+			schemaColumnRow("balance", "regular", "bigint", "none"),
+			schemaColumnRow("tags", "regular", "set<text>", "none"),
+		},
+	}
+	gcGrace := 10 * 24 * time.Hour
+	compaction := TimeWindowCompaction(24 * time.Hour)
+	tbl := newSchemaTestTable(qe).WithOptions(Options{Properties: TableProperties{
+		Compaction:          &compaction,
+		GCGrace:             &gcGrace,
+		Caching:             &Caching{Keys: "all"},
+		BloomFilterFPChance: 0.01,
+		Compression:         &Compression{Class: "LZ4Compressor"},
+	}})
+
+	diff, err := tbl.SchemaDiff(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, diff.Empty())
+
+	tbl = tbl.WithOptions(Options{Properties: TableProperties{
+		DefaultTTL:  time.Hour,
+		Comment:     "accounts",
+		Compression: &Compression{Class: "LZ4Compressor", ChunkLengthKB: 64},
+	}})
+	assert.NoError(t, tbl.Migrate(context.Background(), MigrateOptions{}))
+	assert.Equal(t, []string{
+		"ALTER TABLE bank.account WITH comment = 'accounts' AND " +
+			"compression = {'class': 'LZ4Compressor', 'chunk_length_in_kb': '64'} AND default_time_to_live = 3600",
+	}, qe.executed)
+}
+
+func TestSchemaDiffUnmigratable(t *testing.T) {
+	qe := &schemaQE{
+		OptionCheckingQE: &OptionCheckingQE{opts: &Options{}},
+		tables:           []map[string]interface{}{{"table_name": "account", "compression": map[string]string{}}},
+		columns: []map[string]interface{}{
+			schemaColumnRow("id", "partition_key", "text", "none"),
+			schemaColumnRow("opened", "clustering", "timestamp", "asc"),
+			schemaColumnRow("owner", "regular", "text", "none"),
+			schemaColumnRow("balance", "regular", "int", "none"),
+		},
+	}
+	tbl := newSchemaTestTable(qe)
+
+	diff, err := tbl.SchemaDiff(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"balance", "opened"}, []string{diff.ChangedColumns[0].Current.Name, diff.ChangedColumns[1].Current.Name})
+
+	err = tbl.Migrate(context.Background(), MigrateOptions{AllowDrop: true})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "can't migrate bank.account")
+	// Nothing is altered, not even the columns which could be added
+	assert.Empty(t, qe.executed)
+}
+
+func TestSchemaDiffMissingTable(t *testing.T) {
+	qe := &schemaQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	tbl := newSchemaTestTable(qe)
+
+	diff, err := tbl.SchemaDiff(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, diff.Exists)
+
+	assert.NoError(t, tbl.Migrate(context.Background(), MigrateOptions{}))
+	if assert.Len(t, qe.executed, 1) {
+		assert.Contains(t, qe.executed[0], "CREATE TABLE IF NOT EXISTS bank.account (")
+	}
+}
+
+func TestNormalizeCQLType(t *testing.T) {
+	for typ, normalized := range map[string]string{
+		"varchar":                                           "text",
+		"map<varchar, frozen<list<int>>>":                   "map<text,frozen<list<int>>>",
+		"frozen<tuple<double, double>>":                     "tuple<double,double>",
+		"list<frozen<tuple<int, frozen<tuple<int, int>>>>>": "list<tuple<int,tuple<int,int>>>",
+		"frozen<address_t>":                                 "frozen<address_t>",
+		"frozen<varchar_pair>":                              "frozen<varchar_pair>",
+		"map<myvarchar, varchar>":                           "map<myvarchar,text>",
+	} {
+		assert.Equal(t, normalized, normalizeCQLType(typ), typ)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 )
@@ -194,8 +195,10 @@ type InsertStatement struct {
 	table                string                 // name of the table
 	fieldMap             map[string]interface{} // fields to be inserted
 	ttl                  time.Duration          // ttl of the row
+	timestamp            time.Time              // write timestamp, zero means the time the query is run
 	keys                 Keys                   // partition / clustering keys for table
 	allowClusterSentinel bool                   // whether we should enable our clustering sentinel
+	ifNotExists          bool                   // whether the insert only applies if the row doesn't exist
 }
 
 // NewInsertStatement adds the ability to craft a new InsertStatement
@@ -254,10 +257,14 @@ func (s InsertStatement) QueryAndValues() (string, []interface{}) {
 	query = append(query, "("+strings.Join(fieldNames, ", ")+")")
 	query = append(query, "VALUES ("+strings.Join(placeholders, ", ")+")")
 
-	// Determine if we need to set a TTL
-	if s.TTL() > time.Duration(0) {
-		query = append(query, "USING TTL ?")
-		values = append(values, int(s.TTL().Seconds()))
+	if s.IfNotExists() {
+		query = append(query, "IF NOT EXISTS")
+	}
+
+	usingCQL, usingValues := generateUsingCQL(s.TTL(), s.Timestamp())
+	if usingCQL != "" {
+		query = append(query, "USING", usingCQL)
+		values = append(values, usingValues...)
 	}
 
 	return strings.Join(query, " "), values
@@ -298,6 +305,32 @@ func (s InsertStatement) WithTTL(ttl time.Duration) InsertStatement {
 	return s
 }
 
+// Timestamp returns the write timestamp of this statement. A zero time means
+// the time the statement is run
+func (s InsertStatement) Timestamp() time.Time {
+	return s.timestamp
+}
+
+// WithTimestamp allows setting of the write timestamp for this insert
+// statement, which Cassandra resolves conflicting writes with
+func (s InsertStatement) WithTimestamp(timestamp time.Time) InsertStatement {
+	s.timestamp = timestamp
+	return s
+}
+
+// IfNotExists returns whether the insert is conditional on the row not
+// existing already (a lightweight transaction)
+func (s InsertStatement) IfNotExists() bool {
+	return s.ifNotExists
+}
+
+// WithIfNotExists allows toggling of the IF NOT EXISTS condition on this
+// insert statement
+func (s InsertStatement) WithIfNotExists(enabled bool) InsertStatement {
+	s.ifNotExists = enabled
+	return s
+}
+
 // Keys provides the Partition / Clustering keys defined by the table recipe
 func (s InsertStatement) Keys() Keys {
 	return s.keys
@@ -318,8 +351,11 @@ type UpdateStatement struct {
 	fieldMap             map[string]interface{} // fields to be updated
 	where                []Relation             // where filter clauses
 	ttl                  time.Duration          // ttl of the row
+	timestamp            time.Time              // write timestamp, zero means the time the query is run
 	keys                 Keys                   // partition / clustering keys for table
 	allowClusterSentinel bool                   // whether we should enable our clustering sentinel
+	ifExists             bool                   // whether the update only applies if the row exists
+	conditions           []Relation             // conditions which must hold for the update to apply
 }
 
 // NewUpdateStatement adds the ability to craft a new UpdateStatement
@@ -367,10 +403,10 @@ func (s UpdateStatement) QueryAndValues() (string, []interface{}) {
 	values := make([]interface{}, 0)
 	query := []string{"UPDATE", fmt.Sprintf("%s.%s", s.Keyspace(), s.Table())}
 
-	// Determine if we need to set a TTL
-	if s.TTL() > 0 {
-		query = append(query, "USING TTL ?")
-		values = append(values, int(s.TTL().Seconds()))
+	usingCQL, usingValues := generateUsingCQL(s.TTL(), s.Timestamp())
+	if usingCQL != "" {
+		query = append(query, "USING", usingCQL)
+		values = append(values, usingValues...)
 	}
 
 	setCQL, setValues := generateUpdateSetCQL(s.FieldMap())
@@ -382,6 +418,12 @@ func (s UpdateStatement) QueryAndValues() (string, []interface{}) {
 		query = append(query, "WHERE", whereCQL)
 		values = append(values, whereValues...)
 	}
+
+	ifCQL, ifValues := generateIfCQL(s.IfExists(), s.Conditions())
+	if ifCQL != "" {
+		query = append(query, "IF", ifCQL)
+		values = append(values, ifValues...)
+	}
 	return strings.Join(query, " "), values
 }
 
@@ -426,6 +468,45 @@ func (s UpdateStatement) WithTTL(ttl time.Duration) UpdateStatement {
 	return s
 }
 
+// Timestamp returns the write timestamp of this statement. A zero time means
+// the time the statement is run
+func (s UpdateStatement) Timestamp() time.Time {
+	return s.timestamp
+}
+
+// WithTimestamp allows setting of the write timestamp for this update
+// statement, which Cassandra resolves conflicting writes with
+func (s UpdateStatement) WithTimestamp(timestamp time.Time) UpdateStatement {
+	s.timestamp = timestamp
+	return s
+}
+
+// IfExists returns whether the update is conditional on the row existing
+// (a lightweight transaction)
+func (s UpdateStatement) IfExists() bool {
+	return s.ifExists
+}
+
+// WithIfExists allows toggling of the IF EXISTS condition on this update
+// statement
+func (s UpdateStatement) WithIfExists(enabled bool) UpdateStatement {
+	s.ifExists = enabled
+	return s
+}
+
+// Conditions provides the IF clause Relation items which must hold for
+// this update to be applied
+func (s UpdateStatement) Conditions() []Relation {
+	return s.conditions
+}
+
+// WithConditions sets the conditions (IF clauses) for this statement,
+// making it a lightweight transaction
+func (s UpdateStatement) WithConditions(conditions []Relation) UpdateStatement {
+	s.conditions = conditions
+	return s
+}
+
 // Keys provides the Partition / Clustering keys defined by the table recipe
 func (s UpdateStatement) Keys() Keys {
 	return s.keys
@@ -441,11 +522,15 @@ func (s UpdateStatement) WithClusteringSentinel(enabled bool) UpdateStatement {
 // DeleteStatement represents a DELETE query to delete some data in C*
 // It satisfies the Statement interface
 type DeleteStatement struct {
-	keyspace             string     // name of the keyspace
-	table                string     // name of the table
-	where                []Relation // where filter clauses
-	keys                 Keys       // partition / clustering keys for table
-	allowClusterSentinel bool       // whether we should enable our clustering sentinel
+	keyspace             string              // name of the keyspace
+	table                string              // name of the table
+	columns              []string            // columns to delete, the whole row is deleted if empty
+	elements             map[string]Modifier // collection elements to delete, keyed by column
+	where                []Relation          // where filter clauses
+	keys                 Keys                // partition / clustering keys for table
+	allowClusterSentinel bool                // whether we should enable our clustering sentinel
+	ifExists             bool                // whether the delete only applies if the row exists
+	conditions           []Relation          // conditions which must hold for the delete to apply
 }
 
 // NewDeleteStatement adds the ability to craft a new DeleteStatement
@@ -485,12 +570,25 @@ func (s DeleteStatement) Values() []interface{} {
 
 // QueryAndValues returns the CQL query and any bind values
 func (s DeleteStatement) QueryAndValues() (string, []interface{}) {
-	query := fmt.Sprintf("DELETE FROM %s.%s", s.Keyspace(), s.Table())
+	query := "DELETE"
+	selectors, values := generateDeleteSelectorsCQL(s.Columns(), s.Elements())
+	if selectors != "" {
+		query += " " + selectors
+	}
+	query += fmt.Sprintf(" FROM %s.%s", s.Keyspace(), s.Table())
+
 	whereCQL, whereValues := generateWhereCQL(s.Relations(), s.Keys(), s.allowClusterSentinel)
 	if whereCQL != "" {
 		query += " WHERE " + whereCQL
+		values = append(values, whereValues...)
+	}
+
+	ifCQL, ifValues := generateIfCQL(s.IfExists(), s.Conditions())
+	if ifCQL != "" {
+		query += " IF " + ifCQL
+		values = append(values, ifValues...)
 	}
-	return query, whereValues
+	return query, values
 }
 
 // Keyspace returns the name of the Keyspace for the statement
@@ -503,12 +601,63 @@ func (s DeleteStatement) Table() string {
 	return s.table
 }
 
+// Columns returns the columns to be deleted. If neither columns nor elements
+// are given, the whole row is deleted
+func (s DeleteStatement) Columns() []string {
+	return s.columns
+}
+
+// WithColumns sets the columns to be deleted, rather than the whole row
+func (s DeleteStatement) WithColumns(columns []string) DeleteStatement {
+	s.columns = columns
+	return s
+}
+
+// Elements returns the collection elements to be deleted, keyed by column.
+// The values are MapDeleteKey or ListDeleteAtIndex modifiers
+func (s DeleteStatement) Elements() map[string]Modifier {
+	return s.elements
+}
+
+// WithElements sets the collection elements to be deleted, rather than the
+// whole row
+func (s DeleteStatement) WithElements(elements map[string]Modifier) DeleteStatement {
+	s.elements = elements
+	return s
+}
+
 // Relations provides the WHERE clause Relation items used to evaluate
 // this query
 func (s DeleteStatement) Relations() []Relation {
 	return s.where
 }
 
+// IfExists returns whether the delete is conditional on the row existing
+// (a lightweight transaction)
+func (s DeleteStatement) IfExists() bool {
+	return s.ifExists
+}
+
+// WithIfExists allows toggling of the IF EXISTS condition on this delete
+// statement
+func (s DeleteStatement) WithIfExists(enabled bool) DeleteStatement {
+	s.ifExists = enabled
+	return s
+}
+
+// Conditions provides the IF clause Relation items which must hold for
+// this delete to be applied
+func (s DeleteStatement) Conditions() []Relation {
+	return s.conditions
+}
+
+// WithConditions sets the conditions (IF clauses) for this statement,
+// making it a lightweight transaction
+func (s DeleteStatement) WithConditions(conditions []Relation) DeleteStatement {
+	s.conditions = conditions
+	return s
+}
+
 // Keys provides the Partition / Clustering keys defined by the table recipe
 func (s DeleteStatement) Keys() Keys {
 	return s.keys
@@ -559,6 +708,49 @@ func generateUpdateSetCQL(fm map[string]interface{}) (string, []interface{}) {
 	return strings.Join(clauses, ", "), values
 }
 
+// generateDeleteSelectorsCQL generates the columns and collection elements
+// deleted by a DELETE statement. An expected output may be something like:
+//   - "foo, bar[?]", {"key"}
+func generateDeleteSelectorsCQL(columns []string, elements map[string]Modifier) (string, []interface{}) {
+	selectors, values := make([]string, 0, len(columns)+len(elements)), []interface{}{}
+	for _, column := range columns {
+		selectors = append(selectors, strings.ToLower(column))
+	}
+	elementColumns := make([]string, 0, len(elements))
+	for column := range elements {
+		elementColumns = append(elementColumns, column)
+	}
+	sort.Strings(elementColumns)
+	for _, column := range elementColumns {
+		selector, vals := elements[column].cql(strings.ToLower(column))
+		selectors = append(selectors, selector)
+		values = append(values, vals...)
+	}
+	return strings.Join(selectors, ", "), values
+}
+
+// generateUsingCQL generates the USING clause of writes, which is empty when
+// neither a TTL nor a timestamp is set
+func generateUsingCQL(ttl time.Duration, timestamp time.Time) (string, []interface{}) {
+	clauses := []string{}
+	values := []interface{}{}
+	if ttl > 0 {
+		clauses = append(clauses, "TTL ?")
+		values = append(values, int(ttl.Seconds()))
+	}
+	if !timestamp.IsZero() {
+		clauses = append(clauses, "TIMESTAMP ?")
+		values = append(values, timestampMicros(timestamp))
+	}
+	return strings.Join(clauses, " AND "), values
+}
+
+// timestampMicros converts a time to a write timestamp, which Cassandra counts
+// in microseconds since the epoch
+func timestampMicros(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Microsecond)
+}
+
 // generateWhereCQL takes a list of relations and generates the CQL for
 // a WHERE clause. An expected output may be something like:
 //	- "foo = ?", {1}
@@ -573,6 +765,21 @@ func generateWhereCQL(rs []Relation, keys Keys, clusteringSentinelsEnabled bool)
 	return strings.Join(clauses, " AND "), values
 }
 
+// generateIfCQL generates the CQL for the IF clause of a conditional update
+// or delete. Explicit conditions take precedence over IF EXISTS as Cassandra
+// doesn't allow both. An expected output may be something like:
+//   - "EXISTS", {}
+//   - "foo = ? AND bar > ?", {1, 2}
+func generateIfCQL(ifExists bool, conditions []Relation) (string, []interface{}) {
+	if len(conditions) > 0 {
+		return generateWhereCQL(conditions, Keys{}, false)
+	}
+	if ifExists {
+		return "EXISTS", []interface{}{}
+	}
+	return "", []interface{}{}
+}
+
 func generateRelationCQL(rel Relation, keys Keys, clusteringSentinelsEnabled bool) (string, interface{}) {
 	field := strings.ToLower(rel.Field())
 	switch rel.Comparator() {
@@ -591,6 +798,10 @@ func generateRelationCQL(rel Relation, keys Keys, clusteringSentinelsEnabled boo
 		return field + " < ?", rel.Terms()[0]
 	case CmpLesserThanOrEquals:
 		return field + " <= ?", rel.Terms()[0]
+	case CmpContains:
+		return field + " CONTAINS ?", rel.Terms()[0]
+	case CmpContainsKey:
+		return field + " CONTAINS KEY ?", rel.Terms()[0]
 	default:
 		// This represents an invalid Comparator and would only manifest
 		// if we've initialised a Relation incorrectly within this package
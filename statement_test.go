@@ -54,6 +54,14 @@ func TestInsertStatement(t *testing.T) {
 	stmt = stmt.WithTTL(1 * time.Hour)
 	assert.Equal(t, "INSERT INTO ks1.tbl1 (a, c) VALUES (?, ?) USING TTL ?", stmt.Query())
 	assert.Equal(t, []interface{}{"b", "d", 3600}, stmt.Values())
+
+	stmt = stmt.WithTimestamp(time.Unix(1600000000, 123456789))
+	assert.Equal(t, "INSERT INTO ks1.tbl1 (a, c) VALUES (?, ?) USING TTL ? AND TIMESTAMP ?", stmt.Query())
+	assert.Equal(t, []interface{}{"b", "d", 3600, int64(1600000000123456)}, stmt.Values())
+
+	stmt = stmt.WithTTL(0)
+	assert.Equal(t, "INSERT INTO ks1.tbl1 (a, c) VALUES (?, ?) USING TIMESTAMP ?", stmt.Query())
+	assert.Equal(t, []interface{}{"b", "d", int64(1600000000123456)}, stmt.Values())
 }
 
 func TestUpdateStatement(t *testing.T) {
@@ -71,6 +79,12 @@ func TestUpdateStatement(t *testing.T) {
 	assert.Equal(t, "UPDATE ks1.tbl1 SET a = ?, c = c + ? WHERE foo = ?", stmt.Query())
 	assert.Equal(t, []interface{}{"b", []interface{}{"d"}, "bar"}, stmt.Values())
 
+	fieldMap = map[string]interface{}{"a": SetAdd("x", "y"), "c": SetRemove("z"), "e": MapRemoveKeys("k")}
+	stmt, err = NewUpdateStatement("ks1", "tbl1", fieldMap, relations, keys)
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE ks1.tbl1 SET a = a + ?, c = c - ?, e = e - ? WHERE foo = ?", stmt.Query())
+	assert.Equal(t, []interface{}{[]interface{}{"x", "y"}, []interface{}{"z"}, []interface{}{"k"}, "bar"}, stmt.Values())
+
 	fieldMap = map[string]interface{}{"a": "b", "c": "d"}
 	stmt, err = NewUpdateStatement("ks1", "tbl1", fieldMap, relations, keys)
 	assert.NoError(t, err)
@@ -89,6 +103,44 @@ func TestUpdateStatement(t *testing.T) {
 	stmt = stmt.WithTTL(1 * time.Hour)
 	assert.Equal(t, "UPDATE ks1.tbl1 USING TTL ? SET a = ?, c = ? WHERE foo = ? AND baz IN ?", stmt.Query())
 	assert.Equal(t, []interface{}{3600, "b", "d", "bar", []interface{}{"a", "b", "c"}}, stmt.Values())
+
+	stmt = stmt.WithTimestamp(time.Unix(1600000000, 0))
+	assert.Equal(t, "UPDATE ks1.tbl1 USING TTL ? AND TIMESTAMP ? SET a = ?, c = ? WHERE foo = ? AND baz IN ?", stmt.Query())
+	assert.Equal(t, []interface{}{3600, int64(1600000000000000), "b", "d", "bar", []interface{}{"a", "b", "c"}}, stmt.Values())
+}
+
+func TestWriteTimestampStatements(t *testing.T) {
+	type account struct {
+		Id           string
+		Email        string `cql:"email"`
+		EmailWritten int64  `cql:"email,writetime"`
+		EmailTTL     int    `cql:"email,ttl"`
+	}
+	qe := &OptionCheckingQE{opts: &Options{}}
+	tbl := NewConnection(qe).KeySpace("app").MapTable("accounts", "Id", account{})
+	written := time.Unix(1600000000, 0)
+
+	require.NoError(t, tbl.Set(account{Id: "1", Email: "jane@example.com", EmailWritten: 1}).WithOptions(Options{Timestamp: written}).Run())
+	assert.Equal(t, "UPDATE app.accounts_map_Id USING TIMESTAMP ? SET email = ? WHERE id = ?", qe.stmt.Query())
+	assert.Equal(t, []interface{}{int64(1600000000000000), "jane@example.com", "1"}, qe.stmt.Values())
+
+	require.NoError(t, tbl.Read("1", &account{}).Run())
+	assert.Equal(t, "SELECT id, email, ttl(email), writetime(email) FROM app.accounts_map_Id WHERE id = ?", qe.stmt.Query())
+
+	// Cassandra rejects custom timestamps on conditional writes
+	timestamped := Options{Timestamp: written}
+	for _, op := range []Op{
+		tbl.SetIfNotExists(account{Id: "2"}).WithOptions(timestamped),
+		tbl.UpdateIf("1", []Relation{Eq("Email", "jane@example.com")}, map[string]interface{}{"Email": "joe@example.com"}).
+			WithOptions(timestamped),
+		tbl.DeleteIfExists("1").WithOptions(timestamped),
+		tbl.WithOptions(timestamped).SetIfNotExists(account{Id: "2"}),
+	} {
+		qe.stmt = nil
+		assert.Equal(t, errConditionalTimestamp, op.Preflight())
+		assert.Equal(t, errConditionalTimestamp, op.Run())
+		assert.Nil(t, qe.stmt)
+	}
 }
 
 func TestDeleteStatement(t *testing.T) {
@@ -107,6 +159,76 @@ func TestDeleteStatement(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "DELETE FROM ks1.tbl1 WHERE foo = ? AND baz IN ?", stmt.Query())
 	assert.Equal(t, []interface{}{"bar", []interface{}{"a", "b", "c"}}, stmt.Values())
+
+	stmt = stmt.WithColumns([]string{"A", "b"}).WithElements(map[string]Modifier{
+		"m": MapDeleteKey("k"),
+		"l": ListDeleteAtIndex(2),
+	})
+	assert.Equal(t, "DELETE a, b, l[?], m[?] FROM ks1.tbl1 WHERE foo = ? AND baz IN ?", stmt.Query())
+	assert.Equal(t, []interface{}{2, "k", "bar", []interface{}{"a", "b", "c"}}, stmt.Values())
+}
+
+func TestDeleteColumnsStatements(t *testing.T) {
+	type account struct {
+		Id     string
+		Email  string
+		Tags   []string
+		Scores map[string]int
+	}
+	qe := &OptionCheckingQE{opts: &Options{}}
+	tbl := NewConnection(qe).KeySpace("app").MapTable("accounts", "Id", account{})
+	filter := tbl.Table().Where(Eq("Id", "1"))
+
+	require.NoError(t, filter.DeleteColumns("Email", "Tags").Run())
+	assert.Equal(t, "DELETE email, tags FROM app.accounts_map_Id WHERE id = ?", qe.stmt.Query())
+	assert.Equal(t, []interface{}{"1"}, qe.stmt.Values())
+
+	require.NoError(t, filter.DeleteElements(map[string]Modifier{"Scores": MapDeleteKey("a")}).Run())
+	assert.Equal(t, "DELETE scores[?] FROM app.accounts_map_Id WHERE id = ?", qe.stmt.Query())
+	assert.Equal(t, []interface{}{"a", "1"}, qe.stmt.Values())
+
+	assert.EqualError(t, filter.DeleteColumns().Run(), "no columns to delete, use Delete to delete the whole row")
+	assert.EqualError(t, filter.DeleteColumns("id").Run(), "can't delete primary key column id, use Delete to delete the whole row")
+	assert.EqualError(t, filter.DeleteElements(map[string]Modifier{"Tags": ListAppend("x")}).Run(),
+		"modifier of column Tags doesn't delete an element, use Update instead")
+
+	// Deleting elements can't be expressed in an update
+	deleteKey := map[string]interface{}{"Scores": MapDeleteKey("a")}
+	const deleteErr = "modifier of column Scores deletes an element, use DeleteElements instead"
+	assert.EqualError(t, filter.Update(deleteKey).Run(), deleteErr)
+	assert.EqualError(t, filter.UpdateIf([]Relation{Eq("Email", "a")}, deleteKey).Run(), deleteErr)
+	assert.EqualError(t, tbl.Update("1", deleteKey).Run(), deleteErr)
+}
+
+func TestConditionalStatements(t *testing.T) {
+	keys := Keys{PartitionKeys: []string{"foo"}}
+	relations := []Relation{Eq("foo", "bar")}
+
+	insert, err := NewInsertStatement("ks1", "tbl1", map[string]interface{}{"foo": "bar", "a": 1}, keys)
+	require.NoError(t, err)
+	insert = insert.WithIfNotExists(true).WithTTL(1 * time.Hour)
+	assert.Equal(t, "INSERT INTO ks1.tbl1 (a, foo) VALUES (?, ?) IF NOT EXISTS USING TTL ?", insert.Query())
+	assert.Equal(t, []interface{}{1, "bar", 3600}, insert.Values())
+
+	update, err := NewUpdateStatement("ks1", "tbl1", map[string]interface{}{"a": 2}, relations, keys)
+	require.NoError(t, err)
+	update = update.WithIfExists(true)
+	assert.Equal(t, "UPDATE ks1.tbl1 SET a = ? WHERE foo = ? IF EXISTS", update.Query())
+	assert.Equal(t, []interface{}{2, "bar"}, update.Values())
+
+	update = update.WithConditions([]Relation{Eq("a", 1), LT("b", 5)})
+	assert.Equal(t, "UPDATE ks1.tbl1 SET a = ? WHERE foo = ? IF a = ? AND b < ?", update.Query())
+	assert.Equal(t, []interface{}{2, "bar", 1, 5}, update.Values())
+
+	del, err := NewDeleteStatement("ks1", "tbl1", relations, keys)
+	require.NoError(t, err)
+	del = del.WithIfExists(true)
+	assert.Equal(t, "DELETE FROM ks1.tbl1 WHERE foo = ? IF EXISTS", del.Query())
+	assert.Equal(t, []interface{}{"bar"}, del.Values())
+
+	del = del.WithConditions([]Relation{Eq("a", 1)})
+	assert.Equal(t, "DELETE FROM ks1.tbl1 WHERE foo = ? IF a = ?", del.Query())
+	assert.Equal(t, []interface{}{"bar", 1}, del.Values())
 }
 
 func TestStatementsWithSentinel(t *testing.T) {
@@ -259,6 +381,26 @@ func TestGenerateRelationCQL(t *testing.T) {
 	assert.Equal(t, "foo <= ?", stmt)
 	assert.Equal(t, 1, value)
 
+	stmt, value = generateRelationCQL(Contains("Foo", "a"), Keys{}, false)
+	assert.Equal(t, "foo CONTAINS ?", stmt)
+	assert.Equal(t, "a", value)
+
+	stmt, value = generateRelationCQL(ContainsKey("foo", "a"), Keys{}, false)
+	assert.Equal(t, "foo CONTAINS KEY ?", stmt)
+	assert.Equal(t, "a", value)
+
+	stmt, value = generateRelationCQL(GTTuple([]string{"Foo", "bar"}, []interface{}{1, "a"}), Keys{}, false)
+	assert.Equal(t, "(foo, bar) > ?", stmt)
+	assert.Equal(t, []interface{}{1, "a"}, value)
+
+	stmt, value = generateRelationCQL(LTETuple([]string{"foo", "bar"}, []interface{}{1, "a"}), Keys{}, false)
+	assert.Equal(t, "(foo, bar) <= ?", stmt)
+	assert.Equal(t, []interface{}{1, "a"}, value)
+
+	stmt, value = generateRelationCQL(InTuple([]string{"foo", "bar"}, []interface{}{1, "a"}, []interface{}{2, "b"}), Keys{}, false)
+	assert.Equal(t, "(foo, bar) IN ?", stmt)
+	assert.Equal(t, []interface{}{[]interface{}{1, "a"}, []interface{}{2, "b"}}, value)
+
 	assert.PanicsWithValue(t, "unknown comparator -1", func() {
 		stmt, value = generateRelationCQL(Relation{cmp: -1}, Keys{}, false)
 	})
@@ -1,7 +1,9 @@
 package gocassa
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	r "github.com/monzo/gocassa/reflect"
@@ -11,6 +13,9 @@ type t struct {
 	keySpace *k
 	info     *tableInfo
 	options  Options
+
+	// base is set for materialized views, which are read-only
+	base *t
 }
 
 // Contains mostly analyzed information about the entity
@@ -22,6 +27,9 @@ type tableInfo struct {
 	fieldNames     map[string]struct{} // This is here only to check containment
 	fields         []string
 	fieldValues    []interface{}
+	fieldInfo      map[string]r.Field // Keyed by lowercased field name, empty for non-struct entities
+	metadataFields []string           // writetime and ttl selectors read alongside the fields
+	staticColumns  []string           // fields shared by all the rows of a partition
 }
 
 func newTableInfo(keyspace, name string, keys Keys, entity interface{}, fieldSource map[string]interface{}) *tableInfo {
@@ -44,9 +52,62 @@ func newTableInfo(keyspace, name string, keys Keys, entity interface{}, fieldSou
 	}
 	cinf.fields = fields
 	cinf.fieldValues = values
+	cinf.fieldInfo = entityFieldInfo(entity)
+	cinf.metadataFields = metadataFields(entity)
+	cinf.staticColumns = staticColumns(keys, fields, cinf.fieldInfo)
 	return cinf
 }
 
+// entityFieldInfo returns the fields of a struct entity keyed by lowercased
+// field name, or an empty map for other entities
+func entityFieldInfo(entity interface{}) map[string]r.Field {
+	if entity != nil {
+		if typ := getNonPtrType(reflect.TypeOf(entity)); typ.Kind() == reflect.Struct {
+			if fieldInfo, err := r.StructFieldMap(typ, true); err == nil {
+				return fieldInfo
+			}
+		}
+	}
+	return map[string]r.Field{}
+}
+
+// staticColumns returns the fields which are static columns, either listed in
+// the keys or tagged as static
+func staticColumns(keys Keys, fields []string, fieldInfo map[string]r.Field) []string {
+	var static []string
+	for _, field := range fields {
+		if containsField(keys.StaticColumns, field) || fieldInfo[strings.ToLower(field)].Static() {
+			static = append(static, field)
+		}
+	}
+	return static
+}
+
+// metadataFields returns the selectors of the struct fields tagged with
+// writetime or ttl, which are read but aren't columns of the table
+func metadataFields(entity interface{}) []string {
+	if entity == nil {
+		return nil
+	}
+	typ := getNonPtrType(reflect.TypeOf(entity))
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	fieldInfo, err := r.StructFieldMap(typ, false)
+	if err != nil {
+		return nil
+	}
+
+	selectors := []string{}
+	for name, field := range fieldInfo {
+		if field.Metadata() {
+			selectors = append(selectors, strings.ToLower(name))
+		}
+	}
+	sort.Strings(selectors)
+	return selectors
+}
+
 func toMap(i interface{}) (m map[string]interface{}, ok bool) {
 	switch v := i.(type) {
 	case map[string]interface{}:
@@ -73,6 +134,7 @@ func (t t) generateFieldList(sel []string) []string {
 		for i, v := range t.info.fields {
 			xs[i] = strings.ToLower(v)
 		}
+		xs = append(xs, t.info.metadataFields...)
 	}
 	return xs
 }
@@ -147,23 +209,97 @@ func (t t) Set(i interface{}) Op {
 	}, updateOpType, updFields)
 }
 
+// SetIfNotExists always generates an INSERT, as the IF NOT EXISTS condition
+// can't be expressed on an UPDATE
+func (t t) SetIfNotExists(i interface{}) Op {
+	m, ok := toMap(i)
+	if !ok {
+		panic("SetIfNotExists: Incompatible type")
+	}
+	op := newWriteOp(t.keySpace.qe, filter{t: t}, insertOpType, m)
+	op.ifNotExists = true
+	return op
+}
+
 func (t t) Create() error {
+	if err := t.createTypes(); err != nil {
+		return err
+	}
 	if stmt, err := t.CreateStatement(); err != nil {
 		return err
-	} else {
-		return t.keySpace.qe.Execute(stmt)
+	} else if err := t.keySpace.qe.Execute(stmt); err != nil {
+		return err
 	}
+	return t.createIndexes()
 }
 
 func (t t) CreateIfNotExist() error {
+	if err := t.createTypes(); err != nil {
+		return err
+	}
 	if stmt, err := t.CreateIfNotExistStatement(); err != nil {
 		return err
-	} else {
-		return t.keySpace.qe.Execute(stmt)
+	} else if err := t.keySpace.qe.Execute(stmt); err != nil {
+		return err
+	}
+	return t.createIndexes()
+}
+
+func (t t) CreateTypeStatements() ([]Statement, error) {
+	return createTypeStmts(t.keySpace.name, t.info.fields, t.info.fieldInfo)
+}
+
+func (t t) createTypes() error {
+	stmts, err := t.CreateTypeStatements()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if err := t.keySpace.qe.Execute(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t t) CreateIndexStatements() ([]Statement, error) {
+	return createIndexStmts(t.keySpace.name, t.Name(), t.options.Indexes, t.info.fields)
+}
+
+func (t t) createIndexes() error {
+	stmts, err := t.CreateIndexStatements()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if err := t.keySpace.qe.Execute(stmt); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+func (t t) createViewStatement(createStmt string) (Statement, error) {
+	return createViewStmt(createStmt,
+		t.keySpace.name,
+		t.Name(),
+		t.base.Name(),
+		t.base.info.keys,
+		t.info.keys,
+		t.info.fields,
+		t.options.ClusteringOrder,
+	)
 }
 
 func (t t) Recreate() error {
+	if t.base != nil {
+		// Views aren't listed with the tables of the keyspace
+		stmt := cqlStatement{query: fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s.%s", t.keySpace.name, t.Name())}
+		if err := t.keySpace.qe.Execute(stmt); err != nil {
+			return err
+		}
+		return t.Create()
+	}
 	if ex, err := t.keySpace.Exists(t.Name()); ex && err == nil {
 		if err := t.keySpace.DropTable(t.Name()); err != nil {
 			return err
@@ -175,30 +311,42 @@ func (t t) Recreate() error {
 }
 
 func (t t) CreateStatement() (Statement, error) {
+	if t.base != nil {
+		return t.createViewStatement("CREATE MATERIALIZED VIEW")
+	}
 	return createTable(t.keySpace.name,
 		t.Name(),
 		t.info.keys.PartitionKeys,
 		t.info.keys.ClusteringColumns,
+		t.info.staticColumns,
 		t.info.fields,
 		t.info.fieldValues,
+		t.info.fieldInfo,
 		t.options.ClusteringOrder,
 		t.info.keys.Compound,
 		t.options.CompactStorage,
 		t.options.Compressor,
+		t.options.Properties,
 	)
 }
 
 func (t t) CreateIfNotExistStatement() (Statement, error) {
+	if t.base != nil {
+		return t.createViewStatement("CREATE MATERIALIZED VIEW IF NOT EXISTS")
+	}
 	return createTableIfNotExist(t.keySpace.name,
 		t.Name(),
 		t.info.keys.PartitionKeys,
 		t.info.keys.ClusteringColumns,
+		t.info.staticColumns,
 		t.info.fields,
 		t.info.fieldValues,
+		t.info.fieldInfo,
 		t.options.ClusteringOrder,
 		t.info.keys.Compound,
 		t.options.CompactStorage,
 		t.options.Compressor,
+		t.options.Properties,
 	)
 }
 
@@ -214,5 +362,6 @@ func (table t) WithOptions(o Options) Table {
 		keySpace: table.keySpace,
 		info:     table.info,
 		options:  table.options.Merge(o),
+		base:     table.base,
 	}
 }
@@ -1,6 +1,7 @@
 package gocassa
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -272,8 +273,11 @@ func TestKeysCreation(t *testing.T) {
 
 // Mock QueryExecutor that keeps track of options passed to it
 type OptionCheckingQE struct {
-	stmt Statement
-	opts *Options
+	stmt      Statement
+	opts      *Options
+	pageSize  int
+	pageState []byte
+	batchType BatchType
 }
 
 func (qe *OptionCheckingQE) QueryWithOptions(opts Options, stmt Statement, scanner Scanner) error {
@@ -286,6 +290,20 @@ func (qe *OptionCheckingQE) Query(stmt Statement, scanner Scanner) error {
 	return qe.QueryWithOptions(Options{}, stmt, scanner)
 }
 
+func (qe *OptionCheckingQE) QueryPageWithOptions(opts Options, stmt Statement, scanner Scanner, pageSize int, pageState []byte) ([]byte, error) {
+	qe.stmt = stmt
+	qe.opts.Consistency = opts.Consistency
+	qe.pageSize = pageSize
+	qe.pageState = pageState
+	return []byte("next"), nil
+}
+
+func (qe *OptionCheckingQE) QueryIterWithOptions(opts Options, stmt Statement) Scannable {
+	qe.stmt = stmt
+	qe.opts.Consistency = opts.Consistency
+	return newMockIterator(nil, nil)
+}
+
 func (qe *OptionCheckingQE) ExecuteWithOptions(opts Options, stmt Statement) error {
 	qe.stmt = stmt
 	qe.opts.Consistency = opts.Consistency
@@ -305,6 +323,17 @@ func (qe *OptionCheckingQE) ExecuteAtomicallyWithOptions(opts Options, stmt []St
 	return nil
 }
 
+func (qe *OptionCheckingQE) ExecuteBatchWithOptions(opts Options, batchType BatchType, stmt []Statement) error {
+	qe.batchType = batchType
+	return qe.ExecuteAtomicallyWithOptions(opts, stmt)
+}
+
+func (qe *OptionCheckingQE) ExecuteCASWithOptions(opts Options, stmt Statement, current map[string]interface{}) (bool, error) {
+	qe.stmt = stmt
+	qe.opts.Consistency = opts.Consistency
+	return true, nil
+}
+
 func TestQueryWithConsistency(t *testing.T) {
 	// It's tricky to verify this against a live DB, so mock out the
 	// query executor and make sure the right options get passed
@@ -331,6 +360,36 @@ func TestQueryWithConsistency(t *testing.T) {
 	}
 }
 
+func TestMergeKeepsConsistencyAndAllowFiltering(t *testing.T) {
+	cons := gocql.Quorum
+	merged := Options{Consistency: &cons, AllowFiltering: true}.Merge(Options{Context: context.Background()})
+	if merged.Consistency == nil || *merged.Consistency != cons {
+		t.Fatal(fmt.Sprint("Expected consistency:", cons, "got:", merged.Consistency))
+	}
+	if !merged.AllowFiltering {
+		t.Fatal("Expected filtering to be allowed")
+	}
+
+	// Running an op with a context merges in the context, which mustn't drop
+	// the options of the op
+	resultOpts := Options{}
+	qe := &OptionCheckingQE{opts: &resultOpts}
+	cs := (&connection{q: qe}).KeySpace("some ks").
+		Table("customerWithConsistency3", Customer{}, Keys{PartitionKeys: []string{"Id"}})
+	err := cs.Where(Eq("Name", "Joe")).Read(&[]Customer{}).
+		WithOptions(Options{Consistency: &cons, AllowFiltering: true}).
+		RunWithContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resultOpts.Consistency == nil || *resultOpts.Consistency != cons {
+		t.Fatal(fmt.Sprint("Expected consistency:", cons, "got:", resultOpts.Consistency))
+	}
+	if !strings.HasSuffix(qe.stmt.Query(), " ALLOW FILTERING") {
+		t.Fatal("Expected filtering to be allowed, got:", qe.stmt.Query())
+	}
+}
+
 func TestExecuteWithConsistency(t *testing.T) {
 	resultOpts := Options{}
 	qe := &OptionCheckingQE{opts: &resultOpts}
@@ -416,6 +475,177 @@ func TestExecuteWithNullableFields(t *testing.T) {
 	assert.Equal(t, "UPDATE user.user_by_id SET metadata = ?, status = ? WHERE id = ? AND name = ?", qe.stmt.Query())
 }
 
+func TestConditionalWrites(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	conn := &connection{q: qe}
+	ks := conn.KeySpace("user")
+	cs := ks.Table("user", Customer{}, Keys{PartitionKeys: []string{"Id"}}).
+		WithOptions(Options{TableName: "user_by_id"})
+
+	// SetIfNotExists must always be an INSERT, even with non-nullable fields
+	err := cs.SetIfNotExists(Customer{Id: "100", Name: "Moss"}).Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO user.user_by_id (id, name) VALUES (?, ?) IF NOT EXISTS", qe.stmt.Query())
+
+	err = cs.Where(Eq("Id", "100")).UpdateIf([]Relation{Eq("Name", "Moss")}, map[string]interface{}{"Name": "Roy"}).Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE user.user_by_id SET Name = ? WHERE id = ? IF name = ?", qe.stmt.Query())
+
+	err = cs.Where(Eq("Id", "100")).DeleteIfExists().Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM user.user_by_id WHERE id = ? IF EXISTS", qe.stmt.Query())
+}
+
+func TestReadPage(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	conn := &connection{q: qe}
+	ks := conn.KeySpace("user")
+	cs := ks.Table("user", Customer{}, Keys{PartitionKeys: []string{"Id"}}).
+		WithOptions(Options{TableName: "user_by_id"})
+
+	var customers []Customer
+	var pageState []byte
+	err := cs.Where(Eq("Id", "100")).ReadPage(10, []byte("current"), &customers, &pageState).Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id, name FROM user.user_by_id WHERE id = ?", qe.stmt.Query())
+	assert.Equal(t, 10, qe.pageSize)
+	assert.Equal(t, []byte("current"), qe.pageState)
+	assert.Equal(t, []byte("next"), pageState)
+
+	err = cs.Where(Eq("Id", "100")).ReadPage(0, nil, &customers, &pageState).Run()
+	assert.Error(t, err)
+	err = cs.Where(Eq("Id", "100")).ReadPage(10, nil, &customers, nil).Run()
+	assert.Error(t, err)
+}
+
+func TestIter(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	conn := &connection{q: qe}
+	ks := conn.KeySpace("user")
+	cs := ks.Table("user", Customer{}, Keys{PartitionKeys: []string{"Id"}}).
+		WithOptions(Options{TableName: "user_by_id"})
+
+	iter := cs.Where(Eq("Id", "100")).Iter()
+	assert.False(t, iter.Next(&Customer{}))
+	assert.NoError(t, iter.Close())
+	assert.Equal(t, "SELECT id, name FROM user.user_by_id WHERE id = ?", qe.stmt.Query())
+}
+
+func TestCreateWithIndexes(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	conn := &connection{q: qe}
+	ks := conn.KeySpace("user")
+	cs := ks.Table("user", Customer{}, Keys{PartitionKeys: []string{"Id"}}).
+		WithOptions(Options{TableName: "user_by_id", Indexes: []Index{{Column: "Name"}}})
+
+	// The indexes are created after the table
+	assert.NoError(t, cs.Create())
+	assert.Equal(t, "CREATE INDEX IF NOT EXISTS user_by_id_name_idx ON user.user_by_id (name)", qe.stmt.Query())
+
+	assert.NoError(t, cs.CreateIfNotExist())
+	assert.Equal(t, "CREATE INDEX IF NOT EXISTS user_by_id_name_idx ON user.user_by_id (name)", qe.stmt.Query())
+}
+
+func TestMaterializedView(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	conn := &connection{q: qe}
+	ks := conn.KeySpace("user")
+	base := ks.Table("user", Customer2{}, Keys{PartitionKeys: []string{"Id"}}).
+		WithOptions(Options{TableName: "user_by_id"})
+	view := ks.MaterializedView(base, "user_by_name", Keys{
+		PartitionKeys:     []string{"Name"},
+		ClusteringColumns: []string{"Id"},
+	})
+
+	stmt, err := view.CreateStatement()
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE MATERIALIZED VIEW user.user_by_name AS\n"+
+		"    SELECT id, name, tag FROM user.user_by_id\n"+
+		"    WHERE name IS NOT NULL AND id IS NOT NULL\n"+
+		"    PRIMARY KEY ((name), id)", stmt.Query())
+
+	stmt, err = view.CreateIfNotExistStatement()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stmt.Query(), "CREATE MATERIALIZED VIEW IF NOT EXISTS user.user_by_name AS"))
+
+	// Reads go to the view
+	var customers []Customer2
+	assert.NoError(t, view.Where(Eq("Name", "Moss")).Read(&customers).Run())
+	assert.Equal(t, "SELECT id, name, tag FROM user.user_by_name WHERE name = ?", qe.stmt.Query())
+
+	// Writes are rejected before being executed
+	qe.stmt = nil
+	op := view.Set(Customer2{Id: "100", Name: "Moss"})
+	assert.Error(t, op.Preflight())
+	assert.Error(t, op.Run())
+	assert.Error(t, view.Where(Eq("Name", "Moss")).Delete().Run())
+	assert.Error(t, view.WithOptions(Options{Limit: 1}).Where(Eq("Name", "Moss")).Update(map[string]interface{}{"Tag": "a"}).Run())
+	assert.Nil(t, qe.stmt)
+
+	// The view must include the primary key of the base table
+	_, err = ks.MaterializedView(base, "user_by_tag", Keys{PartitionKeys: []string{"Tag"}}).CreateStatement()
+	assert.Error(t, err)
+	_, err = ks.MaterializedView(base, "user_by_tag", Keys{
+		PartitionKeys:     []string{"Tag"},
+		ClusteringColumns: []string{"Name", "Id"},
+	}).CreateStatement()
+	assert.Error(t, err)
+}
+
+type geoPoint struct {
+	Lat, Lng float64
+}
+
+type postalAddress struct {
+	Street   string
+	Postcode string
+	Location *geoPoint `cql:"location,udt=geo_point"`
+}
+
+type customerWithAddress struct {
+	Id      string
+	Address postalAddress `cql:"address,udt=postal_address"`
+}
+
+func TestUserDefinedTypes(t *testing.T) {
+	qe := &OptionCheckingQE{opts: &Options{}}
+	conn := &connection{q: qe}
+	ks := conn.KeySpace("user")
+	cs := ks.Table("user", customerWithAddress{}, Keys{PartitionKeys: []string{"Id"}}).
+		WithOptions(Options{TableName: "user_by_id"})
+
+	// Nested types are created first
+	stmts, err := cs.CreateTypeStatements()
+	assert.NoError(t, err)
+	if assert.Len(t, stmts, 2) {
+		assert.Equal(t, "CREATE TYPE IF NOT EXISTS user.geo_point (\n"+
+			"    lat double,\n"+
+			"    lng double\n"+
+			")", stmts[0].Query())
+		assert.Equal(t, "CREATE TYPE IF NOT EXISTS user.postal_address (\n"+
+			"    location frozen<geo_point>,\n"+
+			"    postcode varchar,\n"+
+			"    street varchar\n"+
+			")", stmts[1].Query())
+	}
+
+	stmt, err := cs.CreateStatement()
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE user.user_by_id (\n"+
+		"    id varchar,\n"+
+		"    address frozen<postal_address>,\n"+
+		"    PRIMARY KEY ((id ))\n"+
+		")\n;", stmt.Query())
+
+	// Writes bind the struct so that gocql can marshal it
+	address := postalAddress{Street: "1 Main St", Postcode: "EC1"}
+	assert.NoError(t, cs.Set(customerWithAddress{Id: "1", Address: address}).Run())
+	udt, ok := qe.stmt.Values()[0].(udtValue)
+	if assert.True(t, ok) {
+		assert.Equal(t, address, udt.v.Interface())
+	}
+}
+
 func TestAllFieldValuesAreNullable(t *testing.T) {
 	// all collection types defined are nullable
 	assert.True(t, allFieldValuesAreNullable(map[string]interface{}{
@@ -24,6 +24,15 @@ func (o *timeSeriesT) CreateIfNotExistStatement() (Statement, error) {
 }
 
 func (o *timeSeriesT) Set(v interface{}) Op {
+	return o.Table().Set(o.row(v))
+}
+
+func (o *timeSeriesT) SetIfNotExists(v interface{}) Op {
+	return o.Table().SetIfNotExists(o.row(v))
+}
+
+// row converts the row struct to a map holding its bucket
+func (o *timeSeriesT) row(v interface{}) map[string]interface{} {
 	m, ok := toMap(v)
 	if !ok {
 		panic("Can't set: not able to convert")
@@ -33,25 +42,32 @@ func (o *timeSeriesT) Set(v interface{}) Op {
 	} else {
 		m[bucketFieldName] = bucket(tim, o.bucketSize)
 	}
-	return o.Table().Set(m)
+	return m
 }
 
-func (o *timeSeriesT) Update(timeStamp time.Time, id interface{}, m map[string]interface{}) Op {
+// rowFilter filters the single row with the given time and id
+func (o *timeSeriesT) rowFilter(timeStamp time.Time, id interface{}) Filter {
 	bucket := bucket(timeStamp, o.bucketSize)
 	return o.Table().
 		Where(Eq(bucketFieldName, bucket),
 			Eq(o.timeField, timeStamp),
-			Eq(o.idField, id)).
-		Update(m)
+			Eq(o.idField, id))
+}
+
+func (o *timeSeriesT) Update(timeStamp time.Time, id interface{}, m map[string]interface{}) Op {
+	return o.rowFilter(timeStamp, id).Update(m)
+}
+
+func (o *timeSeriesT) UpdateIf(timeStamp time.Time, id interface{}, conditions []Relation, m map[string]interface{}) Op {
+	return o.rowFilter(timeStamp, id).UpdateIf(conditions, m)
 }
 
 func (o *timeSeriesT) Delete(timeStamp time.Time, id interface{}) Op {
-	bucket := bucket(timeStamp, o.bucketSize)
-	return o.Table().
-		Where(Eq(bucketFieldName, bucket),
-			Eq(o.timeField, timeStamp),
-			Eq(o.idField, id)).
-		Delete()
+	return o.rowFilter(timeStamp, id).Delete()
+}
+
+func (o *timeSeriesT) DeleteIfExists(timeStamp time.Time, id interface{}) Op {
+	return o.rowFilter(timeStamp, id).DeleteIfExists()
 }
 
 func (o *timeSeriesT) Read(timeStamp time.Time, id, pointer interface{}) Op {
@@ -64,6 +80,14 @@ func (o *timeSeriesT) Read(timeStamp time.Time, id, pointer interface{}) Op {
 }
 
 func (o *timeSeriesT) List(startTime time.Time, endTime time.Time, pointerToASlice interface{}) Op {
+	return o.listFilter(startTime, endTime).Read(pointerToASlice)
+}
+
+func (o *timeSeriesT) ListIter(startTime time.Time, endTime time.Time) Iterator {
+	return o.listFilter(startTime, endTime).Iter()
+}
+
+func (o *timeSeriesT) listFilter(startTime time.Time, endTime time.Time) Filter {
 	buckets := []interface{}{}
 	for bucket := o.Buckets(startTime); bucket.Bucket().Before(endTime); bucket = bucket.Next() {
 		buckets = append(buckets, bucket.Bucket())
@@ -71,8 +95,7 @@ func (o *timeSeriesT) List(startTime time.Time, endTime time.Time, pointerToASli
 	return o.Table().
 		Where(In(bucketFieldName, buckets...),
 			GTE(o.timeField, startTime),
-			LTE(o.timeField, endTime)).
-		Read(pointerToASlice)
+			LTE(o.timeField, endTime))
 }
 
 func (o *timeSeriesT) Buckets(start time.Time) Buckets {
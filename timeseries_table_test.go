@@ -83,3 +83,23 @@ func TestOptions(t *testing.T) {
 	assert.Len(t, res, 6)
 	assert.Len(t, res1, 6)
 }
+
+func TestTimeSeriesTableCompaction(t *testing.T) {
+	conn := &connection{q: &OptionCheckingQE{opts: &Options{}}}
+	ks := conn.KeySpace("trips")
+	tbl := ks.TimeSeriesTable("trips", "Time", "Id", 24*time.Hour, Trip{})
+
+	stmt, err := tbl.CreateStatement()
+	require.NoError(t, err)
+	assert.Contains(t, stmt.Query(), "\nWITH compaction = {'class': 'TimeWindowCompactionStrategy', "+
+		"'compaction_window_size': '1', 'compaction_window_unit': 'DAYS'}\n")
+
+	// The defaults can be overridden
+	stmt, err = tbl.WithOptions(Options{Properties: TableProperties{
+		Compaction: &CompactionStrategy{Class: "SizeTieredCompactionStrategy"},
+		DefaultTTL: 30 * 24 * time.Hour,
+	}}).CreateStatement()
+	require.NoError(t, err)
+	assert.Contains(t, stmt.Query(), "\nWITH compaction = {'class': 'SizeTieredCompactionStrategy'}\n"+
+		"AND default_time_to_live = 2592000\n")
+}
@@ -0,0 +1,148 @@
+package gocassa
+
+import (
+	"context"
+	"sync"
+)
+
+// Attributes set on the spans of ops
+const (
+	KeyspaceAttribute    = "gocassa.keyspace"
+	TableAttribute       = "gocassa.table"
+	OpAttribute          = "gocassa.op"
+	StatementAttribute   = "gocassa.statement"
+	RowsAttribute        = "gocassa.rows"
+	ConsistencyAttribute = "gocassa.consistency"
+	BatchTypeAttribute   = "gocassa.batch_type"
+	// OpsAttribute is the number of ops run by a multi op or batch
+	OpsAttribute = "gocassa.ops"
+)
+
+// Tracer starts spans around the ops run through a Connection. It can be
+// implemented on top of any tracing library, such as OpenTelemetry.
+type Tracer interface {
+	// StartSpan starts a span which is a child of the span in ctx (if any),
+	// and returns a context holding the new span
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+	SetAttribute(key string, value interface{})
+	// End ends the span, err is the error the op returned if it failed
+	End(err error)
+}
+
+// WithTracer starts a span for every op run with the Connection, as a child
+// of any span in Options.Context. Ops run as part of a multi op are children
+// of its span
+func WithTracer(tracer Tracer) ConnectionOption {
+	return func(c *connection) {
+		c.tracer = tracer
+	}
+}
+
+func spanContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+func (o *singleOp) runTraced(tracer Tracer) error {
+	ctx, span := tracer.StartSpan(spanContext(o.options.Context), "gocassa."+string(o.queryType()))
+	// The span context is set rather than merged in, so no option of the op is dropped
+	traced := *o
+	traced.options.Context = ctx
+	op := &traced
+
+	span.SetAttribute(KeyspaceAttribute, op.f.t.keySpace.name)
+	span.SetAttribute(TableAttribute, op.f.t.Name())
+	span.SetAttribute(OpAttribute, string(op.queryType()))
+	span.SetAttribute(StatementAttribute, op.GenerateStatement().Query())
+	if op.options.Consistency != nil {
+		span.SetAttribute(ConsistencyAttribute, op.options.Consistency.String())
+	}
+
+	rows, err := op.runLogged()
+	if !op.isWrite() {
+		span.SetAttribute(RowsAttribute, rows)
+	}
+	span.End(err)
+	return err
+}
+
+// tracer returns the tracer of the keyspace of the ops, if it has one
+func (mo multiOp) tracer() Tracer {
+	if ks := mo.keySpace(); ks != nil {
+		return ks.tracer
+	}
+	return nil
+}
+
+// SpanRecorder is a Tracer which keeps the spans it starts in memory, for
+// testing the spans of ops without a collector
+type SpanRecorder struct {
+	mtx   sync.Mutex
+	spans []*RecordedSpan
+}
+
+// RecordedSpan is a span started by a SpanRecorder
+type RecordedSpan struct {
+	Name string
+	// Parent is the span this span is a child of, or nil
+	Parent     *RecordedSpan
+	Attributes map[string]interface{}
+	Ended      bool
+	Err        error
+
+	recorder *SpanRecorder
+}
+
+type recordedSpanKey struct{}
+
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+func (r *SpanRecorder) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	parent, _ := ctx.Value(recordedSpanKey{}).(*RecordedSpan)
+	span := &RecordedSpan{
+		Name:       name,
+		Parent:     parent,
+		Attributes: map[string]interface{}{},
+		recorder:   r,
+	}
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+// Spans returns the spans started so far, in the order they were started
+func (r *SpanRecorder) Spans() []*RecordedSpan {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]*RecordedSpan{}, r.spans...)
+}
+
+// Reset forgets the spans started so far
+func (r *SpanRecorder) Reset() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.spans = nil
+}
+
+func (s *RecordedSpan) SetAttribute(key string, value interface{}) {
+	s.recorder.mtx.Lock()
+	defer s.recorder.mtx.Unlock()
+	s.Attributes[key] = value
+}
+
+func (s *RecordedSpan) End(err error) {
+	s.recorder.mtx.Lock()
+	defer s.recorder.mtx.Unlock()
+	s.Ended = true
+	s.Err = err
+}
@@ -0,0 +1,95 @@
+package gocassa
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tracingQE returns canned rows for reads, and fails writes with err
+type tracingQE struct {
+	*OptionCheckingQE
+	rows []map[string]interface{}
+	err  error
+}
+
+func (qe *tracingQE) QueryWithOptions(opts Options, stmt Statement, scanner Scanner) error {
+	_, err := scanner.ScanIter(newMockIterator(qe.rows, stmt.(SelectStatement).fields))
+	return err
+}
+
+func (qe *tracingQE) ExecuteWithOptions(opts Options, stmt Statement) error {
+	return qe.err
+}
+
+func TestTracingSingleOps(t *testing.T) {
+	qe := &tracingQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	recorder := NewSpanRecorder()
+	tbl := NewConnection(qe, WithTracer(recorder)).KeySpace("app").MapTable("users", "Id", interceptedUser{})
+
+	parentCtx, parent := recorder.StartSpan(context.Background(), "request")
+	qe.rows = []map[string]interface{}{{"Id": "1", "Name": "Jane"}}
+	cons := gocql.One
+	user := interceptedUser{}
+	require.NoError(t, tbl.Read("1", &user).WithOptions(Options{Consistency: &cons, Context: parentCtx}).Run())
+	assert.Equal(t, "Jane", user.Name)
+
+	qe.err = errors.New("timed out")
+	assert.Equal(t, qe.err, tbl.Delete("1").Run())
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, parent, spans[1].Parent)
+	assert.Equal(t, "gocassa.read", spans[1].Name)
+	assert.True(t, spans[1].Ended)
+	assert.NoError(t, spans[1].Err)
+	assert.Equal(t, map[string]interface{}{
+		KeyspaceAttribute:    "app",
+		TableAttribute:       "users_map_Id",
+		OpAttribute:          "read",
+		StatementAttribute:   "SELECT id, name FROM app.users_map_Id WHERE id = ?",
+		ConsistencyAttribute: "ONE",
+		RowsAttribute:        1,
+	}, spans[1].Attributes)
+
+	assert.Nil(t, spans[2].Parent)
+	assert.Equal(t, "gocassa.delete", spans[2].Name)
+	assert.Equal(t, qe.err, spans[2].Err)
+	assert.Equal(t, "DELETE FROM app.users_map_Id WHERE id = ?", spans[2].Attributes[StatementAttribute])
+	assert.NotContains(t, spans[2].Attributes, RowsAttribute)
+}
+
+func TestTracingMultiOps(t *testing.T) {
+	qe := &tracingQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	recorder := NewSpanRecorder()
+	tbl := NewConnection(qe, WithTracer(recorder)).KeySpace("app").MapTable("users", "Id", interceptedUser{})
+
+	op := tbl.Set(interceptedUser{Id: "1"}).Add(tbl.Delete("2"))
+	require.NoError(t, op.Run())
+	spans := recorder.Spans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "gocassa.multi", spans[0].Name)
+	assert.Equal(t, 2, spans[0].Attributes[OpsAttribute])
+	assert.Equal(t, "gocassa.update", spans[1].Name)
+	assert.Equal(t, spans[0], spans[1].Parent)
+	assert.Equal(t, "gocassa.delete", spans[2].Name)
+	assert.Equal(t, spans[0], spans[2].Parent)
+
+	recorder.Reset()
+	require.NoError(t, op.RunAtomically())
+	spans = recorder.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "gocassa.batch", spans[0].Name)
+	assert.Equal(t, 2, spans[0].Attributes[OpsAttribute])
+	assert.True(t, spans[0].Ended)
+}
+
+func TestTracingDisabled(t *testing.T) {
+	qe := &tracingQE{OptionCheckingQE: &OptionCheckingQE{opts: &Options{}}}
+	tbl := NewConnection(qe).KeySpace("app").MapTable("users", "Id", interceptedUser{})
+	require.NoError(t, tbl.Set(interceptedUser{Id: "1"}).Add(tbl.Delete("2")).Run())
+}
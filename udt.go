@@ -0,0 +1,92 @@
+package gocassa
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gocql/gocql"
+
+	r "github.com/monzo/gocassa/reflect"
+)
+
+// udtValue binds a struct (or a pointer to one) to a Cassandra user-defined
+// type. Elements of the type are matched to fields of the struct ignoring
+// case, as Cassandra lowercases unquoted names, whereas gocql on its own only
+// matches them exactly
+type udtValue struct {
+	v reflect.Value
+}
+
+func (u udtValue) MarshalUDT(name string, info gocql.TypeInfo) ([]byte, error) {
+	v := reflect.Indirect(u.v)
+	field, ok, err := udtField(v.Type(), name)
+	if err != nil || !ok {
+		// Elements without a field are left null
+		return nil, err
+	}
+
+	fv := v.FieldByIndex(field.Index())
+	if field.UDT() != "" {
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			return nil, nil
+		}
+		return gocql.Marshal(info, udtValue{v: fv})
+	}
+	return gocql.Marshal(info, fv.Interface())
+}
+
+func (u udtValue) UnmarshalUDT(name string, info gocql.TypeInfo, data []byte) error {
+	v := u.v
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	field, ok, err := udtField(v.Type(), name)
+	if err != nil || !ok {
+		// Elements without a field are ignored
+		return err
+	}
+
+	fv := v.FieldByIndex(field.Index())
+	if field.UDT() != "" {
+		return gocql.Unmarshal(info, data, &udtValue{v: fv})
+	}
+	return gocql.Unmarshal(info, data, fv.Addr().Interface())
+}
+
+// udtField returns the field of the struct type an element of a
+// user-defined type is stored in
+func udtField(typ reflect.Type, name string) (r.Field, bool, error) {
+	fieldMap, err := r.StructFieldMap(typ, true)
+	if err != nil {
+		return r.Field{}, false, fmt.Errorf("could not map user-defined type to %v: %v", typ, err)
+	}
+	field, ok := fieldMap[strings.ToLower(name)]
+	return field, ok, nil
+}
+
+// bindUDTs returns a copy of the field map where the values of user-defined
+// type fields are wrapped so that gocql can marshal them
+func bindUDTs(fieldInfo map[string]r.Field, m map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		ret[k] = v
+		field, ok := fieldInfo[strings.ToLower(k)]
+		if !ok || field.UDT() == "" {
+			continue
+		}
+		// Leave anything other than the struct alone, such as a null pointer
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() || getNonPtrType(rv.Type()) != getNonPtrType(field.Type()) {
+			continue
+		}
+		if rv.Kind() == reflect.Struct || !rv.IsNil() {
+			ret[k] = udtValue{v: rv}
+		}
+	}
+	return ret
+}
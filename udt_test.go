@@ -0,0 +1,57 @@
+package gocassa
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUDTValueRoundTrip(t *testing.T) {
+	native := func(typ gocql.Type) gocql.NativeType {
+		return gocql.NewNativeType(4, typ, "")
+	}
+	geoPointInfo := gocql.UDTTypeInfo{
+		NativeType: native(gocql.TypeUDT),
+		Name:       "geo_point",
+		Elements: []gocql.UDTField{
+			{Name: "lat", Type: native(gocql.TypeDouble)},
+			{Name: "lng", Type: native(gocql.TypeDouble)},
+		},
+	}
+	addressInfo := gocql.UDTTypeInfo{
+		NativeType: native(gocql.TypeUDT),
+		Name:       "postal_address",
+		Elements: []gocql.UDTField{
+			{Name: "street", Type: native(gocql.TypeVarchar)},
+			{Name: "postcode", Type: native(gocql.TypeVarchar)},
+			{Name: "location", Type: geoPointInfo},
+			{Name: "unknown", Type: native(gocql.TypeVarchar)},
+		},
+	}
+
+	in := customerWithAddress{Id: "1", Address: postalAddress{
+		Street:   "1 Main St",
+		Postcode: "EC1",
+		Location: &geoPoint{Lat: 51.5, Lng: -0.1},
+	}}
+	fieldInfo := newTableInfo("ks", "user", Keys{}, in, nil).fieldInfo
+	data, err := gocql.Marshal(addressInfo, bindUDTs(fieldInfo, map[string]interface{}{"Address": in.Address})["Address"])
+	require.NoError(t, err)
+
+	var out customerWithAddress
+	ptrs := generatePtrs([]string{"address"}, fieldInfo, reflect.ValueOf(&out).Elem())
+	require.NoError(t, gocql.Unmarshal(addressInfo, data, ptrs[0]))
+	assert.Equal(t, in.Address, out.Address)
+
+	// A null location is left as a nil pointer
+	in.Address.Location = nil
+	data, err = gocql.Marshal(addressInfo, udtValue{v: reflect.ValueOf(in.Address)})
+	require.NoError(t, err)
+	out = customerWithAddress{}
+	ptrs = generatePtrs([]string{"address"}, fieldInfo, reflect.ValueOf(&out).Elem())
+	require.NoError(t, gocql.Unmarshal(addressInfo, data, ptrs[0]))
+	assert.Equal(t, in.Address, out.Address)
+}